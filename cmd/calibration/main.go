@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/pipeline"
+	"time-series-rag-agent/internal/storage/postgresql"
+	"time-series-rag-agent/pkg/logger"
+)
+
+// รัน: go run ./cmd/calibration/ -symbol ETHUSDT -interval 15m -days 30
+// Buckets logged LLM confidence by decile against the realized next-bar move.
+func main() {
+	symbol := flag.String("symbol", "ETHUSDT", "trading pair symbol (e.g. BTCUSDT)")
+	interval := flag.String("interval", "15m", "candle interval (e.g. 15m, 1h)")
+	days := flag.Int("days", 30, "look back this many days of signals")
+	flag.Parse()
+
+	logger := logger.SetupLogger()
+	cfg := config.LoadConfig()
+
+	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
+		cfg.Database.DBUser, cfg.Database.DBPassword,
+		cfg.Database.DBHost, cfg.Database.DBPort, cfg.Database.DBName,
+	)
+	ctx := context.Background()
+	db, err := postgresql.NewPostgresDB(ctx, connString, postgresql.PoolConfigFrom(cfg.Database), *logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Calibration] DB connection: %v", err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	since := time.Now().AddDate(0, 0, -*days)
+	outcomes, err := db.SignalOutcomes(ctx, *symbol, *interval, since)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Calibration] %v", err))
+		os.Exit(1)
+	}
+	fmt.Print(pipeline.FormatCalibrationReport(pipeline.BucketCalibration(outcomes)))
+}
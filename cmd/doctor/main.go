@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/pipeline"
+	"time-series-rag-agent/pkg/logger"
+)
+
+// รัน: go run ./cmd/doctor/
+// Checks config, DB + pgvector, Binance, LLM key, and AWS before going live.
+func main() {
+	logger.SetupLogger()
+	cfg := config.LoadConfig()
+
+	results := pipeline.RunDoctor(context.Background(), pipeline.NewDoctorChecks(cfg), 15*time.Second)
+	report, ok := pipeline.FormatDoctorReport(results)
+	fmt.Print(report)
+
+	if !ok {
+		os.Exit(1)
+	}
+}
@@ -3,23 +3,36 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"sync"
 	"sync/atomic"
 	"syscall"
+	"time"
 	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/engine"
 	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/health"
+	"time-series-rag-agent/internal/llm"
+	"time-series-rag-agent/internal/metrics"
 	"time-series-rag-agent/internal/pipeline"
+	"time-series-rag-agent/internal/storage/postgresql"
+	"time-series-rag-agent/internal/trade"
 	"time-series-rag-agent/pkg/logger"
 	pkg "time-series-rag-agent/pkg/notifier"
+
+	"github.com/adshao/go-binance/v2/futures"
 )
 
 const (
 	INTERVAL    = "15m"
 	VECTOR_SIZE = 30
-)
 
-var SYMBOLS = []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "XRPUSDT", "BNBUSDT"}
+	// SHUTDOWN_DRAIN is how long SIGINT/SIGTERM waits for a running bar
+	// (ingest, LLM, order placement) before exiting anyway.
+	SHUTDOWN_DRAIN = 2 * time.Minute
+)
 
 // cmd/live/main.go
 func main() {
@@ -28,51 +41,265 @@ func main() {
 	cfg := config.LoadConfig()
 
 	logger.Info(fmt.Sprintf("[Entrypoint] leverage: %d", cfg.Agent.Leverage))
+	symbols := cfg.Agent.Symbols
 
-	discord := pkg.NewDiscordClient(cfg.Discord.DISCORD_NOTIFY_WEBHOOK_URL, cfg.Discord.DISCORD_NOTIFY_WEBHOOK_URL)
+	notify := newNotifier(cfg)
 
 	binanceClient, err := exchange.NewBinanceClient(context.Background(), cfg)
 	if err != nil {
 		logger.Info("[Entrypoint] Error at Binance client initiate")
+		notify.NotifyError(err, "live bot startup: Binance client init")
+		return
+	}
+	logger.Info("[Entrypoint] Binance client ready — starting poll", "market", cfg.Market.Exchange, "testnet", cfg.Market.Testnet)
+
+	streamer, err := exchange.NewMarketStreamer(cfg.Market.Exchange, binanceClient, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Entrypoint] %v", err))
+		notify.NotifyError(err, "live bot startup: market streamer")
 		return
 	}
-	logger.Info("[Entrypoint] Binance client ready — starting poll")
+	adapter := streamer.Klines()
 
-	adapter := exchange.NewBinanceAdapter(binanceClient)
+	reconcileCtx, cancelReconcile := context.WithTimeout(context.Background(), 60*time.Second)
+	if err := pipeline.ReconcileOrders(reconcileCtx, logger, binanceClient, symbols); err != nil {
+		notify.NotifyError(err, "live bot startup: order reconciliation")
+	}
+	cancelReconcile()
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	var pipelineRunning atomic.Int32
+	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
+		cfg.Database.DBUser, cfg.Database.DBPassword,
+		cfg.Database.DBHost, cfg.Database.DBPort, cfg.Database.DBName,
+	)
+	if err := migrateStore(ctx, connString, cfg, logger); err != nil {
+		logger.Error(fmt.Sprintf("[Entrypoint] %v", err))
+		notify.NotifyError(err, "live bot startup: schema migration")
+		return
+	}
 
-	exchange.StartMultiSymbolKlineWebsocket(ctx, adapter, SYMBOLS, INTERVAL, logger, func(candles map[string]exchange.WsCandle) {
-		if !pipelineRunning.CompareAndSwap(0, 1) {
-			logger.Warn("[Entrypoint] previous pipeline still running, dropping bar")
-			return
+	if cfg.Database.RetentionDays > 0 {
+		pruneDB, err := postgresql.NewPostgresDB(ctx, connString, postgresql.PoolConfigFrom(cfg.Database), *logger)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("[Entrypoint] pattern pruning disabled: %v", err))
+		} else {
+			defer pruneDB.Close()
+			pruneDB.SetReadOnly(cfg.Database.ReadOnly)
+			retention := time.Duration(cfg.Database.RetentionDays) * 24 * time.Hour
+			go pipeline.StartPatternPruner(ctx, logger, pruneDB, symbols, INTERVAL, retention, 24*time.Hour)
+		}
+	}
+
+	barDuration, _ := time.ParseDuration(INTERVAL)
+	if cfg.Agent.EarlyPeek {
+		peekDB, err := pipeline.OpenLiveStore(ctx, cfg, logger)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("[Entrypoint] early peek disabled: %v", err))
+		} else {
+			defer peekDB.Close()
+			go pipeline.StartEarlyPeek(ctx, logger, adapter, peekDB, cfg.Embedding, symbols, INTERVAL, VECTOR_SIZE, cfg.LLM.TopN, barDuration/3)
 		}
+	}
 
+	status := health.NewTracker(3 * barDuration)
+	if cfg.Health.Port > 0 {
 		go func() {
-			defer pipelineRunning.Store(0)
+			if err := health.Serve(ctx, cfg.Health.Port, status, metrics.Handler(), logger); err != nil {
+				logger.Error(fmt.Sprintf("[Entrypoint] %v", err))
+			}
+		}()
+	}
+
+	// One client for every bar and symbol, so the daily token budget and the
+	// token metric see all calls.
+	llmService := pipeline.LLMServiceFrom(cfg)
+	if err := metrics.RegisterLLMTokens(llmService.TotalTokens); err != nil {
+		logger.Warn(fmt.Sprintf("[Entrypoint] llm token metric: %v", err))
+	}
+
+	var inflight pipeline.Inflight
+	// A signal stops new bars, but a bar already running keeps a live context
+	// so it never stops between an upsert and its labels or an entry and its SL.
+	barCtx := context.WithoutCancel(ctx)
+
+	if cfg.Agent.MultiSymbol {
+		eng, closeEngine, err := newEngine(ctx, cfg, logger, binanceClient, adapter, llmService, notify, status, symbols)
+		if err != nil {
+			logger.Error(fmt.Sprintf("[Entrypoint] %v", err))
+			notify.NotifyError(err, "live bot startup: engine")
+			return
+		}
+		defer closeEngine()
+		logger.Info("[Entrypoint] multi-symbol engine ready", "symbols", eng.Symbols())
 
-			winner, winnerCandle, ok := pipeline.SelectBestOpportunity(
-				ctx, adapter, candles, SYMBOLS, INTERVAL, VECTOR_SIZE, cfg.LLM.PrefilterThreshold,
-			)
-			if !ok {
-				logger.Info("[Entrypoint] no symbol passed prefilter — holding all")
+		streamer.Stream(ctx, symbols, INTERVAL, func(candles map[string]exchange.WsCandle) {
+			status.SetCandle(latestCandleTime(candles))
+			inflight.Go(func() {
+				defer refreshStatus(barCtx, logger, binanceClient, status)
+				if err := eng.RunCycle(barCtx, candles); err != nil {
+					logger.Error(fmt.Sprintf("[Entrypoint] Live pipeline error: %v", err))
+					return
+				}
+				logger.Info("[Entrypoint] Finished engine cycle")
+			})
+		})
+	} else {
+		var pipelineRunning atomic.Int32
+		streamer.Stream(ctx, symbols, INTERVAL, func(candles map[string]exchange.WsCandle) {
+			status.SetCandle(latestCandleTime(candles))
+
+			if !pipelineRunning.CompareAndSwap(0, 1) {
+				logger.Warn("[Entrypoint] previous pipeline still running, dropping bar")
 				return
 			}
-			logger.Info("[Entrypoint] selected winner", "symbol", winner, "close", winnerCandle.Close)
 
-			hooks := discord.NewPipelineHooks(winner, INTERVAL)
-			if err := pipeline.NewLivePipeline(ctx, logger, binanceClient, hooks,
-				[]exchange.WsCandle{winnerCandle}, winner, INTERVAL, VECTOR_SIZE, winnerCandle.Close,
-			); err != nil {
-				logger.Error(fmt.Sprintf("[Entrypoint] Live pipeline error: %v", err))
-				return
+			started := inflight.Go(func() {
+				defer pipelineRunning.Store(0)
+				defer refreshStatus(barCtx, logger, binanceClient, status)
+
+				winner, winnerCandle, ok := pipeline.SelectBestOpportunity(
+					barCtx, adapter, candles, symbols, INTERVAL, VECTOR_SIZE, cfg.LLM.PrefilterThreshold,
+				)
+				if !ok {
+					logger.Info("[Entrypoint] no symbol passed prefilter — holding all")
+					return
+				}
+				logger.Info("[Entrypoint] selected winner", "symbol", winner, "close", winnerCandle.Close)
+
+				hooks := newHooks(notify, status, winner)
+				deps := pipeline.LiveDeps{Klines: adapter, LLM: llmService}
+				if err := pipeline.RunLivePipeline(barCtx, deps, logger, binanceClient, hooks,
+					[]exchange.WsCandle{winnerCandle}, winner, INTERVAL, cfg.Embedding.WindowFor(winner, VECTOR_SIZE), winnerCandle.Close,
+				); err != nil {
+					logger.Error(fmt.Sprintf("[Entrypoint] Live pipeline error: %v", err))
+					return
+				}
+				logger.Info("[Entrypoint] Finished live pipeline", "symbol", winner)
+			})
+			if !started {
+				pipelineRunning.Store(0)
 			}
-			logger.Info("[Entrypoint] Finished live pipeline", "symbol", winner)
-		}()
-	})
+		})
+	}
+
+	logger.Info("[Entrypoint] shutting down, waiting for the running bar", "timeout", SHUTDOWN_DRAIN)
+	if !inflight.Drain(SHUTDOWN_DRAIN) {
+		logger.Warn("[Entrypoint] running bar did not finish in time, exiting anyway")
+	}
+	logger.Info("shutdown complete", "llm_tokens", llmService.TotalTokens())
+}
+
+// newEngine builds one worker per symbol on a shared pattern store, ingest
+// queue and LLM client. The returned func flushes the queue and closes the
+// store; call it once the running bar has drained.
+func newEngine(ctx context.Context, cfg *config.AppConfig, logger *slog.Logger, client *futures.Client, klines exchange.KlineService, llmService *llm.LLMService, notify pkg.Notifier, status *health.Tracker, symbols []string) (*engine.Engine, func(), error) {
+	store, err := pipeline.OpenLiveStore(ctx, cfg, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("engine store: %w", err)
+	}
+	queue := postgresql.NewIngestQueue(store, 0, 0, logger)
+	shared := engine.Shared{Client: client, Klines: klines, Store: store, Ingest: queue, LLM: llmService, Orders: &sync.Mutex{}, Logger: logger}
+	workers := make([]engine.Worker, len(symbols))
+	for i, sym := range symbols {
+		workers[i] = engine.NewSymbolWorker(shared, cfg, sym, INTERVAL, cfg.Embedding.WindowFor(sym, VECTOR_SIZE), newHooks(notify, status, sym))
+	}
+	closeAll := func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), SHUTDOWN_DRAIN)
+		defer cancel()
+		if err := queue.Close(flushCtx); err != nil {
+			logger.Error(fmt.Sprintf("[Entrypoint] final ingest flush: %v", err))
+		}
+		store.Close()
+	}
+	return engine.New(logger, workers...), closeAll, nil
+}
+
+// newHooks notifies about symbol's pipeline and feeds /status and metrics.
+func newHooks(notify pkg.Notifier, status *health.Tracker, symbol string) *pkg.PipelineHooks {
+	hooks := pkg.NewPipelineHooks(notify, symbol, INTERVAL)
+	notifyOrder := hooks.OnOrderExecuted
+	hooks.OnOrderExecuted = func(sym, signal string, price float64, synthesis, patternRead, priceActionRead string) {
+		status.SetSignal(health.Signal{Symbol: sym, Action: signal, Price: price, Time: time.Now()})
+		metrics.Signals.WithLabelValues(sym, signal).Inc()
+		notifyOrder(sym, signal, price, synthesis, patternRead, priceActionRead)
+	}
+	notifyError := hooks.OnPipelineError
+	hooks.OnPipelineError = func(phase string, err error) {
+		metrics.Errors.WithLabelValues(phase).Inc()
+		notifyError(phase, err)
+	}
+	return hooks
+}
+
+// newNotifier sends alerts to Discord and, when a bot is configured, to
+// Telegram as well.
+func newNotifier(cfg *config.AppConfig) pkg.Notifier {
+	discord := pkg.NewDiscordClientForEnv(cfg.Env,
+		pkg.DiscordWebhooks{Notify: cfg.Discord.DISCORD_NOTIFY_WEBHOOK_URL, Alert: cfg.Discord.DISCORD_ALERT_WEBHOOK_URL},
+		pkg.DiscordWebhooks{Notify: cfg.Discord.DISCORD_DEV_NOTIFY_WEBHOOK_URL, Alert: cfg.Discord.DISCORD_DEV_ALERT_WEBHOOK_URL},
+		time.Duration(cfg.Discord.CoalesceSecs)*time.Second,
+	)
+	if cfg.Telegram.BotToken == "" || cfg.Telegram.ChatID == "" {
+		return discord
+	}
+	telegram := pkg.NewTelegramClient(cfg.Telegram.BotToken, cfg.Telegram.ChatID)
+	telegram.Prefix = pkg.EnvPrefix(cfg.Env)
+	return pkg.MultiNotifier{discord, telegram}
+}
+
+func latestCandleTime(candles map[string]exchange.WsCandle) time.Time {
+	var latest int64
+	for _, c := range candles {
+		latest = max(latest, c.Time)
+	}
+	return time.Unix(latest, 0)
+}
+
+// refreshStatus reads open positions and today's PnL for /status after a bar.
+// Failures only leave the previous values in place.
+func refreshStatus(ctx context.Context, logger *slog.Logger, client *futures.Client, status *health.Tracker) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	risks, err := client.NewGetPositionRiskService().Do(ctx)
+	if err != nil {
+		logger.Warn("[Entrypoint] status: positions", "err", err)
+	} else {
+		var positions []health.Position
+		for _, p := range risks {
+			amt, err := exchange.ParseNumber("positionAmt", p.PositionAmt)
+			if err != nil || amt == 0 {
+				continue
+			}
+			side := "LONG"
+			if amt < 0 {
+				side = "SHORT"
+			}
+			positions = append(positions, health.Position{Symbol: p.Symbol, Side: side, Amount: amt})
+		}
+		status.SetPositions(positions)
+	}
+
+	if pnl, roi, err := trade.CalculateDailyROI(client); err != nil {
+		logger.Warn("[Entrypoint] status: daily PnL", "err", err)
+	} else {
+		status.SetDailyPnL(pnl, roi)
+	}
+}
 
-	logger.Info("shutdown complete")
+// migrateStore runs the idempotent schema migrations once before streaming,
+// so per-bar pipelines never have to.
+func migrateStore(ctx context.Context, connString string, cfg *config.AppConfig, logger *slog.Logger) error {
+	db, err := postgresql.NewPostgresDB(ctx, connString, postgresql.PoolConfigFrom(cfg.Database), *logger)
+	if err != nil {
+		return fmt.Errorf("migrate: connect db: %w", err)
+	}
+	defer db.Close()
+	db.SetReadOnly(cfg.Database.ReadOnly)
+	if err := db.Migrate(ctx, cfg.Embedding.SlopeWindows, cfg.Search.IVFFlatLists); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/pipeline"
+	"time-series-rag-agent/internal/storage/postgresql"
+	"time-series-rag-agent/pkg/logger"
+)
+
+// รัน: go run ./cmd/query/ -symbol BTCUSDT -interval 15m -closes "100,101.2,100.8,..."
+func main() {
+	symbol := flag.String("symbol", "BTCUSDT", "trading pair symbol (e.g. BTCUSDT)")
+	interval := flag.String("interval", "15m", "candle interval (e.g. 15m, 1h)")
+	closesFlag := flag.String("closes", "", "comma-separated close prices, oldest first")
+	volumesFlag := flag.String("volumes", "", "comma-separated volumes matching -closes (needed with EMBEDDING_VOLUME)")
+	topN := flag.Int("topn", 10, "number of matches to return")
+	flag.Parse()
+
+	logger := logger.SetupLogger()
+	cfg := config.LoadConfig()
+
+	closes, err := parseFloats(*closesFlag)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Query] invalid closes: %v", err))
+		os.Exit(1)
+	}
+	var volumes []float64
+	if *volumesFlag != "" {
+		if volumes, err = parseFloats(*volumesFlag); err != nil {
+			logger.Error(fmt.Sprintf("[Query] invalid volumes: %v", err))
+			os.Exit(1)
+		}
+	}
+	fc, err := pipeline.NewConfiguredFeatureCalculator(cfg.Embedding, *symbol, *interval, len(closes)-1)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Query] embedding config: %v", err))
+		os.Exit(1)
+	}
+
+	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
+		cfg.Database.DBUser, cfg.Database.DBPassword,
+		cfg.Database.DBHost, cfg.Database.DBPort, cfg.Database.DBName,
+	)
+	ctx := context.Background()
+	db, err := postgresql.NewPostgresDB(ctx, connString, postgresql.PoolConfigFrom(cfg.Database), *logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Query] DB connection: %v", err))
+		os.Exit(1)
+	}
+	defer db.Close()
+	db.SetSearchParams(cfg.Search.HNSWEfSearch, cfg.Search.IVFFlatProbes)
+	db.SetEmbeddingVersion(fc.Version())
+
+	res, err := pipeline.NewCustomWindowQuery(ctx, db, fc, closes, volumes, *topN)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Query] %v", err))
+		os.Exit(1)
+	}
+	fmt.Print(pipeline.FormatCustomQueryResult(res))
+}
+
+// parseFloats splits a comma-separated list of numbers.
+func parseFloats(list string) ([]float64, error) {
+	var out []float64
+	for _, raw := range strings.Split(list, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", raw, err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/backtest"
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/pkg/logger"
+)
+
+// รัน: go run ./cmd/sweep/ -symbol ETHUSDT -interval 15m -days 30 -topn 5,10 -windows 20,30 -thresholds 0.4,0.6
+func main() {
+	symbol := flag.String("symbol", "BTCUSDT", "trading pair symbol (e.g. BTCUSDT)")
+	interval := flag.String("interval", "15m", "candle interval (e.g. 15m, 1h)")
+	days := flag.Int("days", 30, "number of days of history to replay")
+	topNFlag := flag.String("topn", "5,10", "comma-separated top_k values")
+	windowsFlag := flag.String("windows", "30", "comma-separated vector windows")
+	thresholdsFlag := flag.String("thresholds", "0.4,0.6", "comma-separated consensus thresholds (0-1)")
+	metric := flag.String("metric", "profit_factor", "ranking metric: profit_factor | pnl")
+	workers := flag.Int("workers", 4, "parallel backtests")
+	show := flag.Int("show", 10, "number of best combinations to print")
+	flag.Parse()
+
+	logger := logger.SetupLogger()
+	cfg := config.LoadConfig()
+	ctx := context.Background()
+
+	topN, err := parseInts(*topNFlag)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Sweep] -topn: %v", err))
+		os.Exit(1)
+	}
+	windows, err := parseInts(*windowsFlag)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Sweep] -windows: %v", err))
+		os.Exit(1)
+	}
+	thresholds, err := parseFloats(*thresholdsFlag)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Sweep] -thresholds: %v", err))
+		os.Exit(1)
+	}
+	returnType, err := embedding.ParseReturnType(cfg.Embedding.ReturnType)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Sweep] %v", err))
+		os.Exit(1)
+	}
+
+	rank := backtest.ProfitFactor
+	if *metric == "pnl" {
+		rank = backtest.TotalPnL
+	}
+
+	client, err := exchange.NewBinanceClient(ctx, cfg)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Sweep] binance client: %v", err))
+		os.Exit(1)
+	}
+	end := time.Now()
+	rest, err := exchange.FetchHistoryByTime(client, *symbol, *interval, end.AddDate(0, 0, -*days), end)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Sweep] fetch history: %v", err))
+		os.Exit(1)
+	}
+	history := exchange.RestToWsRest(rest)
+	logger.Info(fmt.Sprintf("[Sweep] replaying %d candles", len(history)))
+
+	results := backtest.Sweep(ctx, history,
+		backtest.SweepGrid{TopN: topN, VectorWindow: windows, Threshold: thresholds},
+		backtest.SweepConfig{
+			Symbol:     *symbol,
+			Interval:   *interval,
+			ReturnType: returnType,
+			Risk: &exchange.Executor{
+				Leverage:     cfg.Agent.Leverage,
+				SLPercentage: cfg.Agent.SLPercentage,
+				TPPercentage: cfg.Agent.TPPercentage,
+			},
+			Metric:  rank,
+			Workers: *workers,
+		},
+	)
+
+	fmt.Printf("%-6s %-7s %-9s %10s %7s %9s %8s %9s\n", "top_k", "window", "threshold", "score", "trades", "win_rate", "pnl_%", "max_dd_%")
+	for i, r := range results {
+		if i >= *show {
+			break
+		}
+		if r.Err != nil {
+			fmt.Printf("%-6d %-7d %-9.2f error: %v\n", r.Params.TopN, r.Params.VectorWindow, r.Params.Threshold, r.Err)
+			continue
+		}
+		fmt.Printf("%-6d %-7d %-9.2f %10.3f %7d %8.1f%% %8.2f %9.2f\n",
+			r.Params.TopN, r.Params.VectorWindow, r.Params.Threshold, r.Score,
+			len(r.Result.Trades), r.Result.WinRate, r.Result.TotalPnLPct, r.Result.MaxDrawdownPct)
+	}
+}
+
+func parseInts(s string) ([]int, error) {
+	var out []int
+	for _, raw := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", raw, err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func parseFloats(s string) ([]float64, error) {
+	var out []float64
+	for _, raw := range strings.Split(s, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", raw, err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
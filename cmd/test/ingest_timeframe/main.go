@@ -13,12 +13,12 @@ const (
 func main() {
 	logger := logger.SetupLogger()
 
-	err1Minute := pipeline.RestIngestVectorFlow(logger, symbol, "1m", vectorSize)
+	err1Minute := pipeline.RestIngestVectorFlow(logger, nil, symbol, "1m", vectorSize)
 	if err1Minute != nil {
 		logger.Error("[Error] Tested error")
 	}
 
-	err1Hour := pipeline.RestIngestVectorFlow(logger, symbol, "1h", vectorSize)
+	err1Hour := pipeline.RestIngestVectorFlow(logger, nil, symbol, "1h", vectorSize)
 	if err1Hour != nil {
 		logger.Error("[Error] Tested error")
 	}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -14,14 +15,56 @@ import (
 )
 
 type AppConfig struct {
+	Env        string // deployment tag: "prod" uses the prod Discord channels, anything else the dev ones
 	Market     BinanceMarketConfig
 	Database   DatabaseConfig
 	OpenRouter OpenRouterConfig
 	Discord    DiscordConfig
+	Telegram   TelegramConfig
 	Agent      AgentConfig
 	Que        QueConfig
 	Regime     RegimeConfig
 	LLM        LLMConfig
+	Search     SearchConfig
+	Candle     CandleConfig
+	Embedding  EmbeddingConfig
+	Webhook    WebhookConfig
+	ChartStore ChartStoreConfig
+	Health     HealthConfig
+}
+
+// EmbeddingConfig selects how close prices are turned into embedding vectors.
+type EmbeddingConfig struct {
+	ReturnType    string         // "log" (default) or "pct"
+	ZClip         float64        // clip embedding z-scores to ±ZClip; 0 = off
+	WithVolume    bool           // append z-scored log-volume deltas to the embedding
+	SlopeWindows  []int          // forward slope label lengths in bars, from "3,5,10,20"
+	VectorWindows map[string]int // per-symbol VectorWindow override, from "ADAUSDT:30,ETHUSDT:60"
+	RSIPeriod     int            // store the window's final Wilder RSI and show it to the LLM; 0 = off
+}
+
+// WindowFor returns the symbol's configured vector window, or fallback.
+func (e EmbeddingConfig) WindowFor(symbol string, fallback int) int {
+	if w, ok := e.VectorWindows[symbol]; ok && w > 0 {
+		return w
+	}
+	return fallback
+}
+
+// CandleConfig tunes the candle continuity check applied when merging WS + REST,
+// and the palette and moving averages candle charts are drawn with.
+type CandleConfig struct {
+	GapHealBars    int    // heal gaps of up to this many missing bars
+	GapSlackSecs   int64  // accept a diff within ±this many seconds of the interval
+	ChartTheme     string // candle chart palette: binance | high-contrast | colorblind
+	ChartMAPeriods []int  // moving averages drawn on the candle chart and named in the prompt
+}
+
+// SearchConfig tunes pgvector ANN recall vs latency at query time.
+type SearchConfig struct {
+	HNSWEfSearch  int // hnsw.ef_search; 0 = server default
+	IVFFlatProbes int // ivfflat.probes; 0 = server default
+	IVFFlatLists  int // build an ivfflat index with this many lists on startup; 0 = no index
 }
 
 type RegimeConfig struct {
@@ -39,8 +82,59 @@ type AgentConfig struct {
 	TPPercentage               float64
 	StopROI                    float64
 	StopLossROI                float64
+	MaxDailyLoss               float64 // halt new entries once today's realized loss (USDT) reaches this; 0 = off
 	ReduceRoiTrigger           float64
 	ReductionAviableTradeRatio float64
+	CloseVerifyRetries         int                // extra flatten attempts when a close leaves residual qty
+	LeverageTiers              string             // "confidence:leverage,..." e.g. "80:10,65:5"; empty = fixed Leverage
+	PatternDedupeBars          int                // bars before the same nearest-match pattern may trade again; 0 = off
+	EarlyPeek                  bool               // log provisional signals on the forming candle (never traded)
+	EmptyMatchAlertBars        int                // alert after this many consecutive bars with no matches on a non-empty store; 0 = off
+	WarmupBars                 int                // bars after startup that are analyzed but never traded; 0 = off
+	TrailingStop               bool               // replace the fixed TP with a trailing stop activated at the TP price
+	CallbackRate               float64            // trailing stop callback in percent (0.1-10)
+	TPLevels                   string             // scaled TP "move:fraction,..." e.g. "0.5:0.5,1:0.3,2:0.2"; empty = single TP
+	EntryPriceSource           string             // limit entry reference: close | typical | mid | book
+	EntryType                  string             // entry order type: limit (GTC at the entry price) | market
+	FeeRate                    float64            // taker fee estimate deducted when sizing orders, e.g. 0.0005
+	MarginBuffer               float64            // fraction of tradeable balance left unused when sizing, e.g. 0.01
+	MaxHoldBars                int                // force-close a position after this many bars; 0 = off
+	MaxHoldBarsBySymbol        map[string]int     // per-symbol MaxHoldBars override, from "ETHUSDT:16,BTCUSDT:32"
+	MaxHoldLimitExitSecs       int                // max-hold exits rest a reduce-only limit at the bar close this long before going to market; 0 = market
+	SizingMode                 string             // position sizing: balance (balance*ratio*leverage) | risk (RiskPct of equity at the SL)
+	RiskPct                    float64            // equity fraction risked per trade in risk sizing, e.g. 0.01
+	BreakevenR                 float64            // move the SL to entry at this many R of profit; 0 = off
+	BreakevenBuffer            float64            // fraction of entry the break-even stop sits past entry, covering fees
+	StopMode                   string             // initial stop: percent (SLPercentage/Leverage) | atr (ATRMultiplier x ATR)
+	ATRPeriod                  int                // Wilder ATR length in bars for STOP_MODE=atr
+	ATRMultiplier              float64            // ATRs between entry and the stop for STOP_MODE=atr
+	LeverageBySymbol           map[string]int     // per-symbol Leverage override, from "ETHUSDT:5,BTCUSDT:10"
+	TradeRatioBySymbol         map[string]float64 // per-symbol AviableTradeRatio override, from "ETHUSDT:0.3,BTCUSDT:0.5"
+	Symbols                    []string           // pairs the live bot streams, from "BTCUSDT,ETHUSDT"
+	MultiSymbol                bool               // run every symbol's pipeline each bar instead of only the best prefilter score
+}
+
+// ForSymbol returns a copy of c with symbol's per-symbol Agent overrides
+// applied. A ratio override also caps ReductionAviableTradeRatio, so a
+// reduced day never trades more of the balance than a normal one.
+func (c *AppConfig) ForSymbol(symbol string) *AppConfig {
+	own := *c
+	if n, ok := c.Agent.LeverageBySymbol[symbol]; ok {
+		own.Agent.Leverage = n
+	}
+	if r, ok := c.Agent.TradeRatioBySymbol[symbol]; ok {
+		own.Agent.AviableTradeRatio = r
+		own.Agent.ReductionAviableTradeRatio = min(own.Agent.ReductionAviableTradeRatio, r)
+	}
+	return &own
+}
+
+// MaxHoldFor returns the symbol's max hold in bars, or MaxHoldBars.
+func (a AgentConfig) MaxHoldFor(symbol string) int {
+	if n, ok := a.MaxHoldBarsBySymbol[symbol]; ok {
+		return n
+	}
+	return a.MaxHoldBars
 }
 
 type LLMConfig struct {
@@ -50,10 +144,35 @@ type LLMConfig struct {
 	LimitTradeHistory   int
 	MaxDailyTokens      int
 	PrefilterThreshold  float64 // minimum score (0-100) to proceed to LLM; 0 = use package default (35)
+	MaxTokens           int     // max_tokens per LLM call
+	RetryMaxTokens      int     // max_tokens for one retry when the reply is truncated; 0 = no retry
+	MaxAttempts         int     // tries per LLM call on 429/5xx/timeouts; 1 = no retry
+	ConsensusStrong     float64 // consensus strength (0-1) treated as Tier 1
+	ConsensusWeak       float64 // consensus strength (0-1) treated as Tier 2
+	ConsensusAdjust     int     // confidence points the bar moves for Tier 1/2; 0 = fixed threshold
+	LogMatches          bool    // log every pattern match (time, distance, slopes, return) per decision
+	MaxMatchDistance    float64 // drop matches farther than this cosine distance; 0 = keep all topN
+	PromptVersion       string  // system prompt template version under internal/llm/prompts
+}
+
+// ChartStoreConfig archives each decision's candle chart in S3.
+type ChartStoreConfig struct {
+	S3Bucket string // empty = charts stay on local disk only
+	S3Prefix string // key prefix inside the bucket
+
+	PresignTTLMinutes int // log a presigned GET link valid this long per upload; 0 = off
+}
+
+// HealthConfig serves /healthz and /status from the live bot.
+type HealthConfig struct {
+	Port int // listen port; 0 = off
 }
 
 type QueConfig struct {
-	QueUrl string
+	QueUrl      string
+	DLQUrl      string // trading-log dead-letter queue; empty = rely on the queue's redrive policy
+	MaxReceives int    // deliveries before a failing message is dead-lettered
+	Region      string // SQS region; empty = the AWS SDK default chain
 }
 
 type AwsSecretData struct {
@@ -67,15 +186,37 @@ type AwsSecretData struct {
 type BinanceMarketConfig struct {
 	ApiKey    string
 	ApiSecret string
+	Exchange  string // market data source: "binance" (default) or "bybit"; orders always go to Binance
+	Testnet   bool   // route Binance REST, WebSocket and orders to the futures testnet
 }
 
 type DiscordConfig struct {
 	DISCORD_ALERT_WEBHOOK_URL  string
 	DISCORD_NOTIFY_WEBHOOK_URL string
+
+	DISCORD_DEV_ALERT_WEBHOOK_URL  string // used instead of the above when Env is not "prod"
+	DISCORD_DEV_NOTIFY_WEBHOOK_URL string
+
+	CoalesceSecs int // drop a repeat of the last message to a webhook within this many seconds; 0 = off. Errors always send
+}
+
+// TelegramConfig posts the same alerts as Discord through a bot; empty token
+// or chat ID disables it.
+type TelegramConfig struct {
+	BotToken string // from @BotFather
+	ChatID   string // user, group or channel id the bot may post to
+}
+
+// WebhookConfig forwards every live decision to a user-owned HTTP endpoint.
+type WebhookConfig struct {
+	URL    string // POST target; empty = off
+	Secret string // HMAC-SHA256 signing key; empty = unsigned
 }
 
 type OpenRouterConfig struct {
-	ApiKey string
+	ApiKey  string
+	Model   string // LLM model id; empty = llm.MODEL_NAME
+	BaseURL string // Messages API root; empty = llm.LLM_BASE_URL
 }
 
 type DatabaseConfig struct {
@@ -84,15 +225,36 @@ type DatabaseConfig struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+	// PersistWindow stores the raw OHLCV window behind each embedding in the
+	// candle_window JSONB column (requires the column to exist).
+	PersistWindow bool
+	// ReadOnly skips every write (ingest, labels, signal log) for a
+	// trader-only process pointed at a replica; search still works.
+	ReadOnly bool
+	// RetentionDays prunes patterns older than this many days once a day in
+	// the live process; 0 keeps everything.
+	RetentionDays int
+	// Pool sizing for each PatternStore; 0 = the store's default.
+	PoolMaxConns           int // DB_POOL_MAX_CONNS
+	PoolMinConns           int // DB_POOL_MIN_CONNS
+	PoolMaxConnLifetimeMin int // DB_POOL_MAX_CONN_LIFETIME_MIN
+	PoolHealthCheckSec     int // DB_POOL_HEALTH_CHECK_SEC
+	ConnectTimeoutSec      int // DB_CONNECT_TIMEOUT_SEC
+	// EmbeddingDim is the fixed dimension of the embedding column; feature
+	// writes of any other length are rejected. 0 = no check.
+	EmbeddingDim int
 }
 
 func LoadConfig() *AppConfig {
 	// 1. Initialize the base config with Env vars (fallbacks or non-secret values)
 	cfg := &AppConfig{
+		Env: getEnv("APP_ENV", "prod"),
 		Market: BinanceMarketConfig{
 			// These might be empty initially if they are only in AWS
 			ApiKey:    getEnv("BINANCE_API_KEY", ""),
 			ApiSecret: getEnv("BINANCE_API_SECRET", ""),
+			Exchange:  getEnv("MARKET_EXCHANGE", "binance"),
+			Testnet:   getEnvAsBool("BINANCE_TESTNET", false),
 		},
 		Database: DatabaseConfig{
 			DBHost:     getEnv("DB_HOST", ""),
@@ -100,13 +262,39 @@ func LoadConfig() *AppConfig {
 			DBUser:     getEnv("DB_USER", ""),
 			DBPassword: getEnv("DB_PASSWORD", ""), // Will be overwritten
 			DBName:     getEnv("DB_NAME", ""),
+
+			PersistWindow: getEnvAsBool("PERSIST_CANDLE_WINDOW", false),
+			ReadOnly:      getEnvAsBool("DB_READ_ONLY", false),
+			RetentionDays: getEnvAsInt("PATTERN_RETENTION_DAYS", 0),
+
+			PoolMaxConns:           getEnvAsInt("DB_POOL_MAX_CONNS", 10),
+			PoolMinConns:           getEnvAsInt("DB_POOL_MIN_CONNS", 1),
+			PoolMaxConnLifetimeMin: getEnvAsInt("DB_POOL_MAX_CONN_LIFETIME_MIN", 30),
+			PoolHealthCheckSec:     getEnvAsInt("DB_POOL_HEALTH_CHECK_SEC", 60),
+			ConnectTimeoutSec:      getEnvAsInt("DB_CONNECT_TIMEOUT_SEC", 10),
+			EmbeddingDim:           getEnvAsInt("EMBEDDING_DIM", 0),
 		},
 		OpenRouter: OpenRouterConfig{
-			ApiKey: getEnv("OPENAI_API_KEY", ""),
+			ApiKey:  getEnv("OPENAI_API_KEY", ""),
+			Model:   getEnv("LLM_MODEL", ""),
+			BaseURL: getEnv("LLM_BASE_URL", ""),
 		},
 		Discord: DiscordConfig{
 			DISCORD_ALERT_WEBHOOK_URL:  getEnv("DISCORD_ALERT_WEBHOOK_URL", ""),
 			DISCORD_NOTIFY_WEBHOOK_URL: getEnv("DISCORD_NOTIFY_WEBHOOK_URL", ""),
+
+			DISCORD_DEV_ALERT_WEBHOOK_URL:  getEnv("DISCORD_DEV_ALERT_WEBHOOK_URL", ""),
+			DISCORD_DEV_NOTIFY_WEBHOOK_URL: getEnv("DISCORD_DEV_NOTIFY_WEBHOOK_URL", ""),
+
+			CoalesceSecs: getEnvAsInt("DISCORD_COALESCE_SECS", 0),
+		},
+		Telegram: TelegramConfig{
+			BotToken: getEnv("TELEGRAM_BOT_TOKEN", ""),
+			ChatID:   getEnv("TELEGRAM_CHAT_ID", ""),
+		},
+		Webhook: WebhookConfig{
+			URL:    getEnv("DECISION_WEBHOOK_URL", ""),
+			Secret: getEnv("DECISION_WEBHOOK_SECRET", ""),
 		},
 		Agent: AgentConfig{
 			AviableTradeRatio:          getEnvAsFloat("AVIABLE_TRADE_RATIO", 0.90),
@@ -115,11 +303,51 @@ func LoadConfig() *AppConfig {
 			TPPercentage:               getEnvAsFloat("TP_PERCENTAGE", 0.7),
 			StopROI:                    getEnvAsFloat("STOP_ROI", 15.0),
 			StopLossROI:                getEnvAsFloat("STOP_LOSS_ROI", -5.0),
+			MaxDailyLoss:               getEnvAsFloat("MAX_DAILY_LOSS", 0),
 			ReduceRoiTrigger:           getEnvAsFloat("REDUCE_ROI_TRIGGER", 5.0),
 			ReductionAviableTradeRatio: getEnvAsFloat("REDUCTION_AVIABLE_TRADE_RATIO", 0.70),
+			CloseVerifyRetries:         getEnvAsInt("CLOSE_VERIFY_RETRIES", 3),
+			LeverageTiers:              getEnv("LEVERAGE_TIERS", ""),
+			PatternDedupeBars:          getEnvAsInt("PATTERN_DEDUPE_BARS", 0),
+			EarlyPeek:                  getEnvAsBool("EARLY_PEEK", false),
+			EmptyMatchAlertBars:        getEnvAsInt("EMPTY_MATCH_ALERT_BARS", 5),
+			WarmupBars:                 getEnvAsInt("WARMUP_BARS", 0),
+			TrailingStop:               getEnvAsBool("TRAILING_STOP", false),
+			CallbackRate:               getEnvAsFloat("TRAILING_CALLBACK_RATE", 1.0),
+			TPLevels:                   getEnv("TP_LEVELS", ""),
+			EntryPriceSource:           getEnv("ENTRY_PRICE_SOURCE", "close"),
+			EntryType:                  getEnv("ENTRY_TYPE", "limit"),
+			FeeRate:                    getEnvAsFloat("FEE_RATE", 0.0005),
+			MarginBuffer:               getEnvAsFloat("MARGIN_BUFFER", 0.01),
+			MaxHoldBars:                getEnvAsInt("MAX_HOLD_BARS", 0),
+			MaxHoldBarsBySymbol:        getEnvAsIntMap("MAX_HOLD_BARS_BY_SYMBOL"),
+			MaxHoldLimitExitSecs:       getEnvAsInt("MAX_HOLD_LIMIT_EXIT_SECS", 0),
+			LeverageBySymbol:           getEnvAsIntMap("LEVERAGE_BY_SYMBOL"),
+			TradeRatioBySymbol:         getEnvAsFloatMap("TRADE_RATIO_BY_SYMBOL"),
+			SizingMode:                 getEnv("SIZING_MODE", "balance"),
+			RiskPct:                    getEnvAsFloat("RISK_PCT", 0.01),
+			BreakevenR:                 getEnvAsFloat("BREAKEVEN_R", 0),
+			BreakevenBuffer:            getEnvAsFloat("BREAKEVEN_BUFFER", 0.001),
+			StopMode:                   getEnv("STOP_MODE", "percent"),
+			ATRPeriod:                  getEnvAsInt("ATR_PERIOD", 14),
+			ATRMultiplier:              getEnvAsFloat("ATR_MULTIPLIER", 1.5),
+			Symbols:                    getEnvAsStringList("LIVE_SYMBOLS", []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "XRPUSDT", "BNBUSDT"}),
+			MultiSymbol:                getEnvAsBool("LIVE_MULTI_SYMBOL", false),
+		},
+		ChartStore: ChartStoreConfig{
+			S3Bucket: getEnv("CHART_S3_BUCKET", ""),
+			S3Prefix: getEnv("CHART_S3_PREFIX", "charts"),
+
+			PresignTTLMinutes: getEnvAsInt("CHART_PRESIGN_TTL_MINUTES", 0),
+		},
+		Health: HealthConfig{
+			Port: getEnvAsInt("HEALTH_PORT", 8080),
 		},
 		Que: QueConfig{
-			QueUrl: getEnv("SQS_URL", ""),
+			QueUrl:      getEnv("SQS_URL", ""),
+			DLQUrl:      getEnv("SQS_DLQ_URL", ""),
+			MaxReceives: getEnvAsInt("SQS_MAX_RECEIVES", 5),
+			Region:      getEnv("SQS_REGION", os.Getenv("AWS_REGION")),
 		},
 		Regime: RegimeConfig{
 			ADXTrendThreshold:    getEnvAsFloat("ADX_TREND_THRESHOLD", 25.0),
@@ -135,6 +363,34 @@ func LoadConfig() *AppConfig {
 			LimitTradeHistory:   getEnvAsInt("LimitTradeHistory", 5),
 			MaxDailyTokens:      getEnvAsInt("MAX_DAILY_TOKENS", 0),
 			PrefilterThreshold:  getEnvAsFloat("PREFILTER_THRESHOLD", 35.0),
+			MaxTokens:           getEnvAsInt("LLM_MAX_TOKENS", 1000),
+			RetryMaxTokens:      getEnvAsInt("LLM_RETRY_MAX_TOKENS", 2000),
+			MaxAttempts:         getEnvAsInt("LLM_MAX_ATTEMPTS", 3),
+			ConsensusStrong:     getEnvAsFloat("CONSENSUS_STRONG", 0.6),
+			ConsensusWeak:       getEnvAsFloat("CONSENSUS_WEAK", 0.2),
+			ConsensusAdjust:     getEnvAsInt("CONSENSUS_CONFIDENCE_ADJUST", 0),
+			LogMatches:          getEnvAsBool("LOG_MATCHES", false),
+			MaxMatchDistance:    getEnvAsFloat("MAX_MATCH_DISTANCE", 0),
+			PromptVersion:       getEnv("PROMPT_VERSION", "v1"),
+		},
+		Candle: CandleConfig{
+			GapHealBars:    getEnvAsInt("CANDLE_GAP_HEAL_BARS", 1),
+			GapSlackSecs:   int64(getEnvAsInt("CANDLE_GAP_SLACK_SECS", 0)),
+			ChartTheme:     getEnv("CHART_THEME", "binance"),
+			ChartMAPeriods: getEnvAsIntList("CHART_MA_PERIODS", []int{7, 25, 99}),
+		},
+		Embedding: EmbeddingConfig{
+			ReturnType:    getEnv("EMBEDDING_RETURN_TYPE", "log"),
+			ZClip:         getEnvAsFloat("EMBEDDING_ZCLIP", 0),
+			WithVolume:    getEnvAsBool("EMBEDDING_VOLUME", false),
+			SlopeWindows:  getEnvAsIntList("LABEL_SLOPE_WINDOWS", []int{3, 5}),
+			VectorWindows: getEnvAsIntMap("VECTOR_WINDOWS"),
+			RSIPeriod:     getEnvAsInt("EMBEDDING_RSI_PERIOD", 0),
+		},
+		Search: SearchConfig{
+			HNSWEfSearch:  getEnvAsInt("HNSW_EF_SEARCH", 0),
+			IVFFlatProbes: getEnvAsInt("IVFFLAT_PROBES", 0),
+			IVFFlatLists:  getEnvAsInt("IVFFLAT_LISTS", 0),
 		},
 	}
 
@@ -168,6 +424,16 @@ func LoadConfig() *AppConfig {
 	return cfg
 }
 
+// CheckAwsAccess verifies the AWS credential chain can read AWS_SECRET_NAME.
+func CheckAwsAccess() error {
+	secretName := os.Getenv("AWS_SECRET_NAME")
+	if secretName == "" {
+		return fmt.Errorf("AWS_SECRET_NAME not set")
+	}
+	_, err := fetchAwsSecrets(secretName)
+	return err
+}
+
 func fetchAwsSecrets(secretName string) (AwsSecretData, error) {
 	awsCfg, err := config.LoadDefaultConfig(context.TODO())
 	if err != nil {
@@ -218,3 +484,88 @@ func getEnvAsFloat(key string, fallback float64) float64 {
 	}
 	return fallback
 }
+
+func getEnvAsBool(key string, fallback bool) bool {
+	if valueStr, exists := os.LookupEnv(key); exists {
+		if value, err := strconv.ParseBool(valueStr); err == nil {
+			return value
+		}
+	}
+	return fallback
+}
+
+// getEnvAsIntMap parses "KEY:1,KEY2:2"; malformed pairs are skipped.
+func getEnvAsIntMap(key string) map[string]int {
+	out := map[string]int{}
+	valueStr, exists := os.LookupEnv(key)
+	if !exists {
+		return out
+	}
+	for _, pair := range strings.Split(valueStr, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			out[strings.TrimSpace(k)] = n
+		}
+	}
+	return out
+}
+
+// getEnvAsFloatMap parses "KEY:0.5,KEY2:0.3"; malformed pairs are skipped.
+func getEnvAsFloatMap(key string) map[string]float64 {
+	out := map[string]float64{}
+	valueStr, exists := os.LookupEnv(key)
+	if !exists {
+		return out
+	}
+	for _, pair := range strings.Split(valueStr, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			out[strings.TrimSpace(k)] = f
+		}
+	}
+	return out
+}
+
+// getEnvAsIntList parses "3,5,10"; malformed entries are skipped and an
+// unset or fully malformed value yields fallback.
+func getEnvAsIntList(key string, fallback []int) []int {
+	valueStr, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	var out []int
+	for _, part := range strings.Split(valueStr, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			out = append(out, n)
+		}
+	}
+	if len(out) == 0 {
+		return fallback
+	}
+	return out
+}
+
+// getEnvAsStringList parses "A,B,C" into trimmed, upper-cased entries; blank
+// entries are skipped and an unset or empty value yields fallback.
+func getEnvAsStringList(key string, fallback []string) []string {
+	valueStr, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	var out []string
+	for _, part := range strings.Split(valueStr, ",") {
+		if part = strings.ToUpper(strings.TrimSpace(part)); part != "" {
+			out = append(out, part)
+		}
+	}
+	if len(out) == 0 {
+		return fallback
+	}
+	return out
+}
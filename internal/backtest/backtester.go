@@ -0,0 +1,193 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/storage"
+)
+
+// SignalFunc decides "LONG", "SHORT" or "HOLD" for one closed bar from its
+// feature and nearest historical matches. Inject a stub for deterministic tests
+// or wrap an LLM/prefilter for a full replay.
+type SignalFunc func(feature embedding.PatternFeature, matches []embedding.PatternLabel) string
+
+// Trade is one simulated round trip.
+type Trade struct {
+	Side       string
+	EntryTime  time.Time
+	ExitTime   time.Time
+	Entry      float64
+	Exit       float64
+	ExitReason string  // "TP", "SL" or "END" (still open when history ran out)
+	PnLPct     float64 // leveraged return on margin, in percent
+}
+
+// BacktestResult summarises a replay. PnL and drawdown are in percent of
+// margin, summed per trade (no compounding).
+type BacktestResult struct {
+	Trades         []Trade
+	TotalPnLPct    float64
+	WinRate        float64 // percent of trades with PnLPct > 0
+	MaxDrawdownPct float64 // largest peak-to-trough drop of cumulative PnL
+}
+
+// Backtester replays candles bar by bar through the feature → search → signal
+// path, using the live Executor's SL/TP math for exits.
+type Backtester struct {
+	Features *embedding.FeatureCalculator
+	Labels   *embedding.LabelCalculator
+	Store    storage.PatternStore // memory.Store for offline runs, or postgresql.PatternStore
+	Signal   SignalFunc
+	Risk     *exchange.Executor // only Leverage / SLPercentage / TPPercentage are used
+	TopN     int
+}
+
+func NewBacktester(fc *embedding.FeatureCalculator, store storage.PatternStore, signal SignalFunc, risk *exchange.Executor, topN int) *Backtester {
+	return &Backtester{
+		Features: fc,
+		Labels:   embedding.NewLabelCalculator(),
+		Store:    store,
+		Signal:   signal,
+		Risk:     risk,
+		TopN:     topN,
+	}
+}
+
+type openPosition struct {
+	side      string
+	entry     float64
+	entryTime time.Time
+	sl, tp    float64
+}
+
+// Run walks history oldest-first. At each bar it labels only bars whose future
+// is already known, searches before ingesting the current bar (no self-match,
+// no lookahead), then ingests it.
+func (b *Backtester) Run(ctx context.Context, history []exchange.WsRestCandle) (BacktestResult, error) {
+	vw := b.Features.VectorWindow
+	if len(history) <= vw {
+		return BacktestResult{}, fmt.Errorf("[Backtest] need more than %d candles, got %d", vw, len(history))
+	}
+
+	var (
+		trades []Trade
+		pos    *openPosition
+	)
+
+	for i := vw; i < len(history); i++ {
+		bar := history[i]
+
+		// 1) Exits on this bar for a position opened earlier. SL is checked
+		// first: with only OHLC we can't tell which was touched first.
+		if pos != nil {
+			if exit, reason, hit := checkExit(pos, bar); hit {
+				trades = append(trades, b.closeTrade(pos, exit, time.Unix(bar.Time, 0), reason))
+				pos = nil
+			}
+		}
+
+		// 2) Labels unlocked by this bar.
+		if labels := b.Labels.CalculateFromHistory(history[:i+1]); len(labels) > 0 {
+			if err := b.Store.UpsertLabels(ctx, b.Features.Symbol, b.Features.Interval, labels); err != nil {
+				return BacktestResult{}, fmt.Errorf("[Backtest] upsert labels: %w", err)
+			}
+		}
+
+		// 3) Feature + search + signal.
+		feature := b.Features.Calculate(history[:i+1])
+		if feature == nil {
+			continue
+		}
+		matches, err := b.Store.QueryTopN(ctx, feature.Symbol, feature.Interval, feature.Embedding, b.TopN)
+		if err != nil {
+			return BacktestResult{}, fmt.Errorf("[Backtest] search: %w", err)
+		}
+		if err := b.Store.UpsertFeature(ctx, *feature); err != nil {
+			return BacktestResult{}, fmt.Errorf("[Backtest] upsert feature: %w", err)
+		}
+
+		if pos != nil {
+			continue
+		}
+		switch side := b.Signal(*feature, matches); side {
+		case "LONG", "SHORT":
+			pos = &openPosition{
+				side:      side,
+				entry:     bar.Close,
+				entryTime: time.Unix(bar.Time, 0),
+				sl:        b.Risk.CalculateSL(bar.Close, side),
+				tp:        b.Risk.CalculateTP(bar.Close, side),
+			}
+		}
+	}
+
+	if pos != nil {
+		last := history[len(history)-1]
+		trades = append(trades, b.closeTrade(pos, last.Close, time.Unix(last.Time, 0), "END"))
+	}
+
+	return summarise(trades), nil
+}
+
+func checkExit(pos *openPosition, bar exchange.WsRestCandle) (float64, string, bool) {
+	if pos.side == "LONG" {
+		if bar.Low <= pos.sl {
+			return pos.sl, "SL", true
+		}
+		if bar.High >= pos.tp {
+			return pos.tp, "TP", true
+		}
+		return 0, "", false
+	}
+	if bar.High >= pos.sl {
+		return pos.sl, "SL", true
+	}
+	if bar.Low <= pos.tp {
+		return pos.tp, "TP", true
+	}
+	return 0, "", false
+}
+
+func (b *Backtester) closeTrade(pos *openPosition, exit float64, at time.Time, reason string) Trade {
+	move := (exit - pos.entry) / pos.entry
+	if pos.side == "SHORT" {
+		move = -move
+	}
+	return Trade{
+		Side:       pos.side,
+		EntryTime:  pos.entryTime,
+		ExitTime:   at,
+		Entry:      pos.entry,
+		Exit:       exit,
+		ExitReason: reason,
+		PnLPct:     move * float64(b.Risk.Leverage) * 100,
+	}
+}
+
+func summarise(trades []Trade) BacktestResult {
+	res := BacktestResult{Trades: trades}
+	if len(trades) == 0 {
+		return res
+	}
+	wins := 0
+	peak, cum := 0.0, 0.0
+	for _, t := range trades {
+		if t.PnLPct > 0 {
+			wins++
+		}
+		cum += t.PnLPct
+		if cum > peak {
+			peak = cum
+		}
+		if dd := peak - cum; dd > res.MaxDrawdownPct {
+			res.MaxDrawdownPct = dd
+		}
+	}
+	res.TotalPnLPct = cum
+	res.WinRate = float64(wins) / float64(len(trades)) * 100
+	return res
+}
@@ -0,0 +1,122 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/storage/memory"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flatCandles returns n bars closing at 100 with a ±0.1 wick, 15m apart.
+func flatCandles(n int) []exchange.WsRestCandle {
+	out := make([]exchange.WsRestCandle, n)
+	for i := range out {
+		out[i] = exchange.WsRestCandle{Time: int64(i) * 900, Open: 100, High: 100.1, Low: 99.9, Close: 100}
+	}
+	return out
+}
+
+// signalAt fires side on the bar closing at barTime and HOLDs otherwise.
+func signalAt(barTime int64, side string) SignalFunc {
+	return func(f embedding.PatternFeature, _ []embedding.PatternLabel) string {
+		if f.Time.Unix() == barTime {
+			return side
+		}
+		return "HOLD"
+	}
+}
+
+func newTestBacktester(signal SignalFunc) *Backtester {
+	risk := &exchange.Executor{Leverage: 5, SLPercentage: 0.05, TPPercentage: 0.10} // 1% SL, 2% TP price move
+	return NewBacktester(embedding.NewFeatureCalculator("ETHUSDT", "15m", 3), memory.NewStore(), signal, risk, 5)
+}
+
+func TestRun_LongHitsTP(t *testing.T) {
+	h := flatCandles(10)
+	h[6].High = 102.5 // entry at bar 5 close (100) → TP 102
+
+	res, err := newTestBacktester(signalAt(h[5].Time, "LONG")).Run(context.Background(), h)
+
+	assert.NoError(t, err)
+	assert.Len(t, res.Trades, 1)
+	tr := res.Trades[0]
+	assert.Equal(t, "TP", tr.ExitReason)
+	assert.InDelta(t, 102.0, tr.Exit, 1e-9)
+	assert.InDelta(t, 10.0, tr.PnLPct, 1e-9) // 2% move × 5x
+	assert.InDelta(t, 100.0, res.WinRate, 1e-9)
+	assert.Zero(t, res.MaxDrawdownPct)
+}
+
+func TestRun_ShortHitsSL(t *testing.T) {
+	h := flatCandles(10)
+	h[7].High = 101.5 // entry 100 → short SL 101
+
+	res, err := newTestBacktester(signalAt(h[5].Time, "SHORT")).Run(context.Background(), h)
+
+	assert.NoError(t, err)
+	assert.Len(t, res.Trades, 1)
+	assert.Equal(t, "SL", res.Trades[0].ExitReason)
+	assert.InDelta(t, -5.0, res.Trades[0].PnLPct, 1e-9)
+	assert.InDelta(t, 0.0, res.WinRate, 1e-9)
+	assert.InDelta(t, 5.0, res.MaxDrawdownPct, 1e-9)
+}
+
+func TestRun_DrawdownAcrossTrades(t *testing.T) {
+	h := flatCandles(14)
+	h[5].High = 102.5 // LONG from bar 4 → +10
+	h[8].Low = 98.5   // LONG from bar 6 → -5
+	h[10].Low = 98.5  // LONG from bar 9 → -5
+	entries := map[int64]bool{h[4].Time: true, h[6].Time: true, h[9].Time: true}
+	signal := func(f embedding.PatternFeature, _ []embedding.PatternLabel) string {
+		if entries[f.Time.Unix()] {
+			return "LONG"
+		}
+		return "HOLD"
+	}
+
+	res, err := newTestBacktester(signal).Run(context.Background(), h)
+
+	assert.NoError(t, err)
+	assert.Len(t, res.Trades, 3)
+	assert.InDelta(t, 0.0, res.TotalPnLPct, 1e-9)
+	assert.InDelta(t, 100.0/3, res.WinRate, 1e-9)
+	assert.InDelta(t, 10.0, res.MaxDrawdownPct, 1e-9)
+}
+
+func TestRun_OpenPositionClosedAtEnd(t *testing.T) {
+	h := flatCandles(8)
+	h[7].Close = 100.5
+
+	res, err := newTestBacktester(signalAt(h[5].Time, "LONG")).Run(context.Background(), h)
+
+	assert.NoError(t, err)
+	assert.Len(t, res.Trades, 1)
+	assert.Equal(t, "END", res.Trades[0].ExitReason)
+	assert.InDelta(t, 2.5, res.Trades[0].PnLPct, 1e-9)
+}
+
+func TestRun_SearchSeesOnlyPastBars(t *testing.T) {
+	h := flatCandles(10)
+	for i := range h {
+		h[i].Close = 100 + float64(i%3) // non-constant so z-scores are finite
+	}
+	signal := func(f embedding.PatternFeature, matches []embedding.PatternLabel) string {
+		for _, m := range matches {
+			assert.True(t, m.Time.Before(f.Time), "match %v not before %v", m.Time, f.Time)
+		}
+		return "HOLD"
+	}
+
+	_, err := newTestBacktester(signal).Run(context.Background(), h)
+
+	assert.NoError(t, err)
+}
+
+func TestRun_ShortHistory(t *testing.T) {
+	_, err := newTestBacktester(signalAt(0, "LONG")).Run(context.Background(), flatCandles(3))
+	assert.Error(t, err)
+}
@@ -0,0 +1,165 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/storage"
+	"time-series-rag-agent/internal/storage/memory"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// SweepParams is one point of the parameter grid.
+type SweepParams struct {
+	TopN         int
+	VectorWindow int
+	Threshold    float64 // passed to NewSignal; ConsensusSignal reads it as min consensus strength
+}
+
+// SweepGrid lists the values to try per parameter; every combination runs.
+type SweepGrid struct {
+	TopN         []int
+	VectorWindow []int
+	Threshold    []float64
+}
+
+func (g SweepGrid) Combinations() []SweepParams {
+	var out []SweepParams
+	for _, n := range g.TopN {
+		for _, w := range g.VectorWindow {
+			for _, th := range g.Threshold {
+				out = append(out, SweepParams{TopN: n, VectorWindow: w, Threshold: th})
+			}
+		}
+	}
+	return out
+}
+
+// Metric scores a result; higher is better.
+type Metric func(BacktestResult) float64
+
+// ProfitFactor is gross profit / gross loss. No losses with some profit
+// scores +Inf; no trades scores 0.
+func ProfitFactor(r BacktestResult) float64 {
+	var gain, loss float64
+	for _, t := range r.Trades {
+		if t.PnLPct > 0 {
+			gain += t.PnLPct
+		} else {
+			loss -= t.PnLPct
+		}
+	}
+	if loss == 0 {
+		if gain > 0 {
+			return math.Inf(1)
+		}
+		return 0
+	}
+	return gain / loss
+}
+
+func TotalPnL(r BacktestResult) float64 { return r.TotalPnLPct }
+
+// SweepConfig holds everything that stays fixed across the grid.
+type SweepConfig struct {
+	Symbol     string
+	Interval   string
+	ReturnType embedding.ReturnType
+	Risk       *exchange.Executor
+
+	NewSignal func(p SweepParams) SignalFunc // nil = ConsensusSignal(p.Threshold)
+	NewStore  func() storage.PatternStore    // nil = memory.NewStore; called once per run
+	Metric    Metric                         // nil = ProfitFactor
+	Workers   int                            // <= 0 = 4
+}
+
+// SweepResult is one grid point's outcome. Err is set when the run failed;
+// such results rank last.
+type SweepResult struct {
+	Params SweepParams
+	Result BacktestResult
+	Score  float64
+	Err    error
+}
+
+// Sweep backtests every grid combination on a worker pool and returns the
+// results best-first by cfg.Metric.
+func Sweep(ctx context.Context, history []exchange.WsRestCandle, grid SweepGrid, cfg SweepConfig) []SweepResult {
+	if cfg.NewSignal == nil {
+		cfg.NewSignal = func(p SweepParams) SignalFunc { return ConsensusSignal(p.Threshold) }
+	}
+	if cfg.NewStore == nil {
+		cfg.NewStore = func() storage.PatternStore { return memory.NewStore() }
+	}
+	if cfg.Metric == nil {
+		cfg.Metric = ProfitFactor
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+
+	combos := grid.Combinations()
+	results := make([]SweepResult, len(combos))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(cfg.Workers)
+	for i, p := range combos {
+		g.Go(func() error {
+			fc := embedding.NewFeatureCalculator(cfg.Symbol, cfg.Interval, p.VectorWindow)
+			fc.ReturnType = cfg.ReturnType
+			bt := NewBacktester(fc, cfg.NewStore(), cfg.NewSignal(p), cfg.Risk, p.TopN)
+
+			res, err := bt.Run(gctx, history)
+			results[i] = SweepResult{Params: p, Result: res, Err: err}
+			if err == nil {
+				results[i].Score = cfg.Metric(res)
+			}
+			return nil // one bad combo must not cancel the rest
+		})
+	}
+	g.Wait()
+
+	sort.SliceStable(results, func(a, b int) bool {
+		if (results[a].Err == nil) != (results[b].Err == nil) {
+			return results[a].Err == nil
+		}
+		return results[a].Score > results[b].Score
+	})
+	return results
+}
+
+// ConsensusSignal trades the majority direction of the matches' forward
+// slope when |up-down|/n reaches threshold, otherwise HOLDs.
+func ConsensusSignal(threshold float64) SignalFunc {
+	return func(_ embedding.PatternFeature, matches []embedding.PatternLabel) string {
+		if len(matches) == 0 {
+			return "HOLD"
+		}
+		up, down := 0, 0
+		for _, m := range matches {
+			slope := m.NextSlope3
+			if slope == 0 {
+				slope = m.NextSlope5
+			}
+			switch {
+			case slope > 0:
+				up++
+			case slope < 0:
+				down++
+			}
+		}
+		strength := math.Abs(float64(up-down)) / float64(len(matches))
+		switch {
+		case strength < threshold || up == down:
+			return "HOLD"
+		case up > down:
+			return "LONG"
+		default:
+			return "SHORT"
+		}
+	}
+}
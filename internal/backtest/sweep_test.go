@@ -0,0 +1,74 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSweep_RanksByMetric(t *testing.T) {
+	h := flatCandles(12)
+	h[6].High = 102.5 // a LONG from bar 5 hits TP (+10)
+	h[7].High = 101.5 // a SHORT from bar 5 hits SL (-5)
+
+	sides := map[int]string{1: "LONG", 2: "SHORT", 3: "HOLD"}
+	cfg := SweepConfig{
+		Symbol:   "ETHUSDT",
+		Interval: "15m",
+		Risk:     &exchange.Executor{Leverage: 5, SLPercentage: 0.05, TPPercentage: 0.10},
+		NewSignal: func(p SweepParams) SignalFunc {
+			return signalAt(h[5].Time, sides[p.TopN])
+		},
+		Metric:  TotalPnL,
+		Workers: 2,
+	}
+	grid := SweepGrid{TopN: []int{2, 3, 1}, VectorWindow: []int{3}, Threshold: []float64{0}}
+
+	got := Sweep(context.Background(), h, grid, cfg)
+
+	assert.Len(t, got, 3)
+	assert.Equal(t, []int{1, 3, 2}, []int{got[0].Params.TopN, got[1].Params.TopN, got[2].Params.TopN})
+	assert.InDelta(t, 10.0, got[0].Score, 1e-9)
+	assert.InDelta(t, 0.0, got[1].Score, 1e-9)
+	assert.InDelta(t, -5.0, got[2].Score, 1e-9)
+}
+
+func TestSweep_FailedRunsRankLast(t *testing.T) {
+	h := flatCandles(8)
+	cfg := SweepConfig{
+		Symbol:    "ETHUSDT",
+		Interval:  "15m",
+		Risk:      &exchange.Executor{Leverage: 5, SLPercentage: 0.05, TPPercentage: 0.10},
+		NewSignal: func(SweepParams) SignalFunc { return signalAt(0, "HOLD") },
+	}
+	grid := SweepGrid{TopN: []int{5}, VectorWindow: []int{20, 3}, Threshold: []float64{0}}
+
+	got := Sweep(context.Background(), h, grid, cfg)
+
+	assert.NoError(t, got[0].Err)
+	assert.Equal(t, 3, got[0].Params.VectorWindow)
+	assert.Error(t, got[1].Err) // window 20 needs more history than 8 bars
+}
+
+func TestProfitFactor(t *testing.T) {
+	r := BacktestResult{Trades: []Trade{{PnLPct: 10}, {PnLPct: -5}, {PnLPct: 5}, {PnLPct: -5}}}
+	assert.InDelta(t, 1.5, ProfitFactor(r), 1e-9)
+	assert.True(t, math.IsInf(ProfitFactor(BacktestResult{Trades: []Trade{{PnLPct: 1}}}), 1))
+	assert.Zero(t, ProfitFactor(BacktestResult{}))
+}
+
+func TestConsensusSignal(t *testing.T) {
+	up := embedding.PatternLabel{NextSlope3: 0.01}
+	down := embedding.PatternLabel{NextSlope3: -0.01}
+	sig := ConsensusSignal(0.5)
+
+	assert.Equal(t, "LONG", sig(embedding.PatternFeature{}, []embedding.PatternLabel{up, up, up, down}))
+	assert.Equal(t, "HOLD", sig(embedding.PatternFeature{}, []embedding.PatternLabel{up, up, down}))
+	assert.Equal(t, "SHORT", sig(embedding.PatternFeature{}, []embedding.PatternLabel{down, down}))
+	assert.Equal(t, "HOLD", sig(embedding.PatternFeature{}, nil))
+}
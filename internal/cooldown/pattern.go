@@ -0,0 +1,72 @@
+package cooldown
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+)
+
+// Rules:
+//   a trade records the nearest historical match (its "cluster") per symbol
+//   same cluster again within minBars → suppress
+//   different cluster               → allowed immediately
+
+// PatternGuard stops a recurring pattern from re-triggering back-to-back
+// trades while it oscillates. Safe for concurrent use.
+type PatternGuard struct {
+	mu   sync.Mutex
+	last map[string]patternTrade // keyed by symbol
+}
+
+type patternTrade struct {
+	clusterID string
+	barTime   time.Time
+}
+
+func NewPatternGuard() *PatternGuard {
+	return &PatternGuard{last: make(map[string]patternTrade)}
+}
+
+// NearestClusterID identifies a pattern by its closest historical match,
+// skipping the row for self (the bar that was just upserted).
+// Returns "" when no other match exists.
+func NearestClusterID(matches []embedding.PatternLabel, self time.Time) string {
+	for _, m := range matches {
+		if m.Time.Equal(self) {
+			continue
+		}
+		return fmt.Sprintf("%s|%s|%d", m.Symbol, m.Interval, m.Time.Unix())
+	}
+	return ""
+}
+
+// --- State transitions ---
+
+func (g *PatternGuard) RecordTrade(symbol, clusterID string, barTime time.Time) {
+	if clusterID == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.last[symbol] = patternTrade{clusterID: clusterID, barTime: barTime}
+}
+
+// --- Query ---
+
+// IsSuppressed reports whether clusterID last traded on symbol fewer than
+// minBars bars before barTime.
+func (g *PatternGuard) IsSuppressed(symbol, clusterID string, barTime time.Time, interval time.Duration, minBars int) bool {
+	if clusterID == "" || minBars <= 0 || interval <= 0 {
+		return false
+	}
+	g.mu.Lock()
+	prev, ok := g.last[symbol]
+	g.mu.Unlock()
+	if !ok || prev.clusterID != clusterID {
+		return false
+	}
+	bars := int(barTime.Sub(prev.barTime) / interval)
+	return bars < minBars
+}
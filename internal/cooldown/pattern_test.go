@@ -0,0 +1,44 @@
+package cooldown
+
+import (
+	"testing"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNearestClusterID_SkipsSelf(t *testing.T) {
+	self := time.Unix(1700001800, 0)
+	matches := []embedding.PatternLabel{
+		{Time: self, Symbol: "ETHUSDT", Interval: "15m"},
+		{Time: time.Unix(1690000000, 0), Symbol: "ETHUSDT", Interval: "15m"},
+	}
+
+	assert.Equal(t, "ETHUSDT|15m|1690000000", NearestClusterID(matches, self))
+	assert.Equal(t, "", NearestClusterID(matches[:1], self))
+}
+
+func TestPatternGuard_RepeatedPatternSuppressed(t *testing.T) {
+	g := NewPatternGuard()
+	bar := time.Unix(1700000000, 0)
+	interval := 15 * time.Minute
+	g.RecordTrade("ETHUSDT", "ETHUSDT|15m|1690000000", bar)
+
+	// same nearest match two bars later → suppressed
+	assert.True(t, g.IsSuppressed("ETHUSDT", "ETHUSDT|15m|1690000000", bar.Add(2*interval), interval, 4))
+	// window elapsed → allowed
+	assert.False(t, g.IsSuppressed("ETHUSDT", "ETHUSDT|15m|1690000000", bar.Add(4*interval), interval, 4))
+	// different pattern or symbol → allowed
+	assert.False(t, g.IsSuppressed("ETHUSDT", "ETHUSDT|15m|1680000000", bar.Add(interval), interval, 4))
+	assert.False(t, g.IsSuppressed("BTCUSDT", "ETHUSDT|15m|1690000000", bar.Add(interval), interval, 4))
+}
+
+func TestPatternGuard_DisabledWhenMinBarsZero(t *testing.T) {
+	g := NewPatternGuard()
+	bar := time.Unix(1700000000, 0)
+	g.RecordTrade("ETHUSDT", "c1", bar)
+
+	assert.False(t, g.IsSuppressed("ETHUSDT", "c1", bar, 15*time.Minute, 0))
+}
@@ -1,21 +1,70 @@
 package embedding
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"time"
 	"time-series-rag-agent/internal/exchange"
 )
 
+// Reasons CalculateE refuses a window; match them with errors.Is.
+var (
+	ErrNotEnoughData    = errors.New("not enough data")
+	ErrNonPositivePrice = errors.New("contains non-positive prices")
+	ErrNaNEmbedding     = errors.New("NaN after z-score")
+)
+
 // FeatureCalculatorI allows mocking in tests.
 type FeatureCalculatorI interface {
 	Calculate(history []exchange.WsRestCandle) *PatternFeature
 }
 
+// ReturnType selects the return series an embedding is built from.
+type ReturnType string
+
+const (
+	ReturnLog ReturnType = "log" // natural-log returns (default)
+	ReturnPct ReturnType = "pct" // simple percentage returns
+)
+
+// ParseReturnType maps a config string to a ReturnType; empty means log.
+func ParseReturnType(s string) (ReturnType, error) {
+	switch ReturnType(s) {
+	case "", ReturnLog:
+		return ReturnLog, nil
+	case ReturnPct:
+		return ReturnPct, nil
+	}
+	return "", fmt.Errorf("unknown return type %q (want log or pct)", s)
+}
+
+// EmbeddingVersion tags features so vectors built from different return
+// series are never compared against each other by mistake.
+func (r ReturnType) EmbeddingVersion() string {
+	if r == ReturnPct {
+		return "pct-v1"
+	}
+	return "log-v1"
+}
+
+// Returns computes the return series for closes according to r.
+func (r ReturnType) Returns(closes []float64) []float64 {
+	if r == ReturnPct {
+		return CalculatePctReturn(closes)
+	}
+	return CalculateLogReturn(closes)
+}
+
 // FeatureCalculator computes embeddings from a rolling window of candles.
 type FeatureCalculator struct {
 	Symbol       string
 	Interval     string
 	VectorWindow int
+	ReturnType   ReturnType // zero value = log returns
+	ZClip        float64    // clip z-scores to ±ZClip; 0 = no clipping
+	WithVolume   bool       // append z-scored log-volume deltas (doubles the vector)
+	RSIPeriod    int        // also record the window's final RSI(RSIPeriod); 0 = off
 }
 
 func NewFeatureCalculator(symbol, interval string, vectorWindow int) *FeatureCalculator {
@@ -26,23 +75,95 @@ func NewFeatureCalculator(symbol, interval string, vectorWindow int) *FeatureCal
 	}
 }
 
+// embed turns closes into the (optionally clipped) z-scored return vector.
+// With WithVolume set the z-scored log-volume deltas follow the price part,
+// so two windows with the same shape but different participation differ.
+func (f *FeatureCalculator) embed(closes, volumes []float64) []float64 {
+	vec := ClipZScore(CalculateZScore(f.ReturnType.Returns(closes)), f.ZClip)
+	if !f.WithVolume {
+		return vec
+	}
+	return append(vec, ClipZScore(CalculateZScore(CalculateLogVolumeDelta(volumes)), f.ZClip)...)
+}
+
+// rsi is the RSI at the last close of the window, or nil when RSIPeriod is
+// off or the window is too short for it. It is computed from the window
+// alone, so backfilled and live features agree.
+func (f *FeatureCalculator) rsi(closes []float64) *float64 {
+	if f.RSIPeriod <= 0 {
+		return nil
+	}
+	v := CalculateRSI(closes, f.RSIPeriod)[len(closes)-1]
+	if math.IsNaN(v) {
+		return nil
+	}
+	return &v
+}
+
+// Version tags the embedding recipe. Clipping changes the vector, so a
+// clipped embedding gets its own version, e.g. "log-v1-clip3"; so does a
+// volume-augmented one, e.g. "log-v1-vol".
+func (f *FeatureCalculator) Version() string {
+	if f.ZClip > 0 {
+		return fmt.Sprintf("%s-clip%g", f.version(), f.ZClip)
+	}
+	return f.version()
+}
+
+// version is the unclipped recipe tag, e.g. "log-v1" or "log-v1-vol".
+func (f *FeatureCalculator) version() string {
+	if f.WithVolume {
+		return f.ReturnType.EmbeddingVersion() + "-vol"
+	}
+	return f.ReturnType.EmbeddingVersion()
+}
+
 // Calculate returns a PatternFeature from the last (VectorWindow+1) candles.
-// Returns nil if history is too short.
+// Returns nil if history is too short or the window is unusable; see
+// CalculateE for the reason.
 func (f *FeatureCalculator) Calculate(history []exchange.WsRestCandle) *PatternFeature {
+	feature, _ := f.CalculateE(history)
+	return feature
+}
+
+// CalculateE is Calculate with a reason when no feature can be built: too
+// short a history, a close <= 0 (usually a bad parse defaulting to 0), or an
+// embedding that is not finite after the z-score.
+func (f *FeatureCalculator) CalculateE(history []exchange.WsRestCandle) (*PatternFeature, error) {
 	reqLen := f.VectorWindow + 1
 	if len(history) < reqLen {
-		return nil
+		return nil, fmt.Errorf("%w: got %d candles, need %d", ErrNotEnoughData, len(history), reqLen)
+	}
+	for _, c := range history[len(history)-reqLen:] {
+		if c.Close <= 0 {
+			return nil, fmt.Errorf("%w: close %g at %d", ErrNonPositivePrice, c.Close, c.Time)
+		}
 	}
 
-	window := history[len(history)-reqLen:]
+	feature := f.build(history)
+	for i, v := range feature.Embedding {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%w: embedding[%d] = %g", ErrNaNEmbedding, i, v)
+		}
+	}
+	return feature, nil
+}
+
+// build embeds the last (VectorWindow+1) candles; history must be long enough.
+func (f *FeatureCalculator) build(history []exchange.WsRestCandle) *PatternFeature {
+	reqLen := f.VectorWindow + 1
+
+	// Copy so Window doesn't pin or alias the caller's (possibly reused) buffer.
+	window := append([]exchange.WsRestCandle(nil), history[len(history)-reqLen:]...)
 
 	closes := make([]float64, len(window))
+	volumes := make([]float64, len(window))
 	for i, d := range window {
 		closes[i] = d.Close
+		volumes[i] = d.Volume
 	}
 
-	logReturns := CalculateLogReturn(closes)
-	embedding := CalculateZScore(logReturns)
+	embedding := f.embed(closes, volumes)
 	lastCandle := window[len(window)-1]
 
 	return &PatternFeature{
@@ -51,6 +172,9 @@ func (f *FeatureCalculator) Calculate(history []exchange.WsRestCandle) *PatternF
 		Interval:   f.Interval,
 		Embedding:  embedding,
 		ClosePrice: lastCandle.Close,
+		Version:    f.Version(),
+		Window:     window,
+		RSI:        f.rsi(closes),
 	}
 }
 
@@ -63,14 +187,15 @@ func (f *FeatureCalculator) CalculateRest(history []exchange.RestCandle) *Patter
 	window := history[len(history)-reqLen:]
 
 	closes := make([]float64, len(window))
+	volumes := make([]float64, len(window))
 	for i, d := range window {
 		closes[i] = d.Close
+		volumes[i] = d.Volume
 	}
 
 	fmt.Println("closes: ", closes)
 
-	logReturns := CalculateLogReturn(closes)
-	embedding := CalculateZScore(logReturns)
+	embedding := f.embed(closes, volumes)
 	lastCandle := window[len(window)-1]
 
 	return &PatternFeature{
@@ -79,6 +204,8 @@ func (f *FeatureCalculator) CalculateRest(history []exchange.RestCandle) *Patter
 		Interval:   f.Interval,
 		Embedding:  embedding,
 		ClosePrice: lastCandle.Close,
+		Version:    f.Version(),
+		RSI:        f.rsi(closes),
 	}
 }
 
@@ -91,12 +218,13 @@ func (f *FeatureCalculator) BulkCalculate(history []exchange.RestCandle) *Patter
 	window := history[len(history)-reqLen:]
 
 	closes := make([]float64, len(window))
+	volumes := make([]float64, len(window))
 	for i, d := range window {
 		closes[i] = d.Close
+		volumes[i] = d.Volume
 	}
 
-	logReturns := CalculateLogReturn(closes)
-	embedding := CalculateZScore(logReturns)
+	embedding := f.embed(closes, volumes)
 	lastCandle := window[len(window)-1]
 
 	return &PatternFeature{
@@ -105,5 +233,24 @@ func (f *FeatureCalculator) BulkCalculate(history []exchange.RestCandle) *Patter
 		Interval:   f.Interval,
 		Embedding:  embedding,
 		ClosePrice: lastCandle.Close,
+		Version:    f.Version(),
+		RSI:        f.rsi(closes),
+	}
+}
+
+// EmbedCloses builds a query embedding from a bare close series with the same
+// recipe as Calculate, for searches that have no candle feed. volumes is only
+// read with WithVolume set and must then match closes in length. The price
+// part has len(closes)-1 dimensions.
+func (f *FeatureCalculator) EmbedCloses(closes, volumes []float64) ([]float64, error) {
+	if len(closes) < 2 {
+		return nil, fmt.Errorf("need at least 2 closes, got %d", len(closes))
+	}
+	if err := ValidateCloses(closes); err != nil {
+		return nil, err
+	}
+	if f.WithVolume && len(volumes) != len(closes) {
+		return nil, fmt.Errorf("volume embedding needs %d volumes, got %d", len(closes), len(volumes))
 	}
+	return f.embed(closes, volumes), nil
 }
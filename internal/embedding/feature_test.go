@@ -1,6 +1,8 @@
 package embedding
 
 import (
+	"errors"
+	"math"
 	"testing"
 	"time"
 	"time-series-rag-agent/internal/exchange"
@@ -242,14 +244,26 @@ func TestCalculate_HistoryExactlyVectorWindow_ReturnsNil(t *testing.T) {
 	assert.Nil(t, result)
 }
 
-func TestCalculate_ZeroClosePrice_EmbeddingFinite(t *testing.T) {
-	// Arrange — PlanckConstant prevents log(0), should not panic or NaN
+func TestCalculate_ZeroClosePrice_SkipsWindow(t *testing.T) {
+	// Arrange — a zero close (bad parse) must not reach the stored embedding
 	fc := NewFeatureCalculator("BTCUSDT", "1h", 3)
 	history := makeHistory([]float64{0.0, 100.0, 110.0, 120.0})
 
 	// Act
 	result := fc.Calculate(history)
 
+	// Assert
+	assert.Nil(t, result)
+}
+
+func TestBulkCalculate_ZeroClosePrice_EmbeddingFinite(t *testing.T) {
+	// Arrange — the bulk REST path has no validation, the log guard alone must hold
+	fc := NewFeatureCalculator("BTCUSDT", "1h", 3)
+	history := []exchange.RestCandle{{Close: 100}, {Close: 0}, {Close: 110}, {Close: 120}}
+
+	// Act
+	result := fc.BulkCalculate(history)
+
 	// Assert
 	assert.NotNil(t, result)
 	for _, v := range result.Embedding {
@@ -285,3 +299,191 @@ func isNaN(v float64) bool {
 func isInf(v float64) bool {
 	return v > 1e308 || v < -1e308
 }
+
+// --- ReturnType ---
+
+func TestCalculate_PctVsLogReturns(t *testing.T) {
+	// Arrange — large moves so log and pct returns diverge visibly
+	history := makeHistory([]float64{100.0, 150.0, 90.0, 180.0, 120.0, 240.0})
+	logFC := NewFeatureCalculator("BTCUSDT", "1h", 5)
+	pctFC := NewFeatureCalculator("BTCUSDT", "1h", 5)
+	pctFC.ReturnType = ReturnPct
+
+	// Act
+	logF := logFC.Calculate(history)
+	pctF := pctFC.Calculate(history)
+
+	// Assert
+	assert.Equal(t, "log-v1", logF.Version)
+	assert.Equal(t, "pct-v1", pctF.Version)
+	assert.Len(t, pctF.Embedding, len(logF.Embedding))
+	assert.NotEqual(t, logF.Embedding, pctF.Embedding)
+	assert.Equal(t, CalculateZScore(CalculatePctReturn([]float64{100.0, 150.0, 90.0, 180.0, 120.0, 240.0})), pctF.Embedding)
+	for i := range logF.Embedding {
+		// same series, same up/down shape — only the magnitudes differ
+		assert.Equal(t, logF.Embedding[i] > 0, pctF.Embedding[i] > 0, "index %d", i)
+	}
+}
+
+func TestParseReturnType(t *testing.T) {
+	rt, err := ParseReturnType("")
+	assert.NoError(t, err)
+	assert.Equal(t, ReturnLog, rt)
+
+	rt, err = ParseReturnType("pct")
+	assert.NoError(t, err)
+	assert.Equal(t, ReturnPct, rt)
+
+	_, err = ParseReturnType("simple")
+	assert.Error(t, err)
+}
+
+// --- Z-score clipping ---
+
+func TestCalculate_ZClipBoundsSpike(t *testing.T) {
+	// Arrange — 20 quiet bars then one +20% candle
+	closes := make([]float64, 22)
+	for i := range closes {
+		closes[i] = 100.0 + float64(i%2)*0.1
+	}
+	closes[len(closes)-1] = closes[len(closes)-2] * 1.2
+	history := makeHistory(closes)
+	rawFC := NewFeatureCalculator("BTCUSDT", "1h", 21)
+	clipFC := NewFeatureCalculator("BTCUSDT", "1h", 21)
+	clipFC.ZClip = 3
+
+	// Act
+	raw := rawFC.Calculate(history)
+	clipped := clipFC.Calculate(history)
+
+	// Assert
+	last := len(raw.Embedding) - 1
+	assert.Greater(t, raw.Embedding[last], 3.0, "spike should exceed the bound unclipped")
+	assert.Equal(t, 3.0, clipped.Embedding[last])
+	for _, v := range clipped.Embedding {
+		assert.LessOrEqual(t, math.Abs(v), 3.0)
+	}
+	assert.Equal(t, "log-v1", raw.Version)
+	assert.Equal(t, "log-v1-clip3", clipped.Version)
+}
+
+func TestCalculate_WindowDoesNotAliasHistory(t *testing.T) {
+	// Arrange — a rolling buffer that gets overwritten after the call
+	history := makeHistory([]float64{100, 101, 102, 103, 104})
+	fc := NewFeatureCalculator("BTCUSDT", "15m", 3)
+
+	// Act
+	f := fc.Calculate(history)
+	history[len(history)-1].Close = 999
+	_ = append(history[:2], exchange.WsRestCandle{Time: 1}, exchange.WsRestCandle{Time: 2})
+
+	// Assert
+	assert.Len(t, f.Window, 4)
+	assert.Equal(t, 101.0, f.Window[0].Close)
+	assert.Equal(t, 104.0, f.Window[3].Close)
+}
+
+func TestCalculate_WithVolume_DoublesEmbeddingAndSeesVolume(t *testing.T) {
+	// Arrange — identical closes, different volume profiles
+	closes := []float64{100, 101, 100.5, 102, 101.5, 103}
+	quiet := makeHistory(closes)
+	loud := makeHistory(closes)
+	for i := range quiet {
+		quiet[i].Volume = 10
+		loud[i].Volume = 10 * float64(i%3+1)
+	}
+	quiet[len(quiet)-1].Volume = 12
+	priceOnly := NewFeatureCalculator("BTCUSDT", "1h", 5)
+	withVol := NewFeatureCalculator("BTCUSDT", "1h", 5)
+	withVol.WithVolume = true
+
+	// Act
+	base := priceOnly.Calculate(quiet)
+	a := withVol.Calculate(quiet)
+	b := withVol.Calculate(loud)
+
+	// Assert
+	assert.Len(t, a.Embedding, 2*len(base.Embedding))
+	assert.Equal(t, base.Embedding, a.Embedding[:5], "price part is unchanged")
+	assert.Equal(t, a.Embedding[:5], b.Embedding[:5])
+	assert.NotEqual(t, a.Embedding[5:], b.Embedding[5:], "volume profile must move the vector")
+	assert.Equal(t, "log-v1-vol", a.Version)
+}
+
+// --- CalculateE: error reasons ---
+
+func TestCalculateE_TooShortHistory_ErrNotEnoughData(t *testing.T) {
+	// Arrange
+	fc := NewFeatureCalculator("BTCUSDT", "1h", 5)
+
+	// Act
+	f, err := fc.CalculateE(makeHistory([]float64{100, 101}))
+
+	// Assert
+	assert.Nil(t, f)
+	assert.True(t, errors.Is(err, ErrNotEnoughData))
+	assert.ErrorContains(t, err, "got 2 candles, need 6")
+}
+
+func TestCalculateE_ZeroClose_ErrNonPositivePrice(t *testing.T) {
+	// Arrange
+	fc := NewFeatureCalculator("BTCUSDT", "1h", 3)
+
+	// Act
+	f, err := fc.CalculateE(makeHistory([]float64{100, 0, 110, 120}))
+
+	// Assert
+	assert.Nil(t, f)
+	assert.True(t, errors.Is(err, ErrNonPositivePrice))
+}
+
+func TestCalculateE_NaNClose_ErrNaNEmbedding(t *testing.T) {
+	// Arrange — NaN slips past the <= 0 check but poisons the z-score
+	fc := NewFeatureCalculator("BTCUSDT", "1h", 3)
+
+	// Act
+	f, err := fc.CalculateE(makeHistory([]float64{100, math.NaN(), 110, 120}))
+
+	// Assert
+	assert.Nil(t, f)
+	assert.True(t, errors.Is(err, ErrNaNEmbedding))
+}
+
+func TestCalculateE_ValidHistory_MatchesCalculate(t *testing.T) {
+	// Arrange
+	fc := NewFeatureCalculator("BTCUSDT", "1h", 3)
+	history := makeHistory([]float64{100, 101, 99, 102})
+
+	// Act
+	f, err := fc.CalculateE(history)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, fc.Calculate(history).Embedding, f.Embedding)
+}
+
+// --- RSIPeriod ---
+
+func TestCalculate_RSIPeriod_RecordsLastBarRSI(t *testing.T) {
+	closes := []float64{100, 101, 100.5, 102, 103, 102.5, 104}
+	fc := NewFeatureCalculator("BTCUSDT", "1h", len(closes)-1)
+	fc.RSIPeriod = 3
+
+	feature := fc.Calculate(makeHistory(closes))
+
+	assert.NotNil(t, feature)
+	if assert.NotNil(t, feature.RSI) {
+		rsi := CalculateRSI(closes, 3)
+		assert.Equal(t, rsi[len(rsi)-1], *feature.RSI)
+	}
+}
+
+func TestCalculate_RSIPeriod_OffOrWindowTooShort(t *testing.T) {
+	closes := []float64{100, 101, 100.5, 102}
+	fc := NewFeatureCalculator("BTCUSDT", "1h", len(closes)-1)
+
+	assert.Nil(t, fc.Calculate(makeHistory(closes)).RSI, "RSIPeriod 0 = off")
+
+	fc.RSIPeriod = 14
+	assert.Nil(t, fc.Calculate(makeHistory(closes)).RSI, "window shorter than the period")
+}
@@ -1,6 +1,7 @@
 package embedding
 
 import (
+	"fmt"
 	"sort"
 	"time-series-rag-agent/internal/exchange"
 )
@@ -9,25 +10,11 @@ func MergeCandles(ws []exchange.WsCandle, rest []exchange.RestCandle) []exchange
 	merged := make(map[int64]exchange.WsRestCandle)
 
 	for _, c := range rest {
-		merged[c.Time] = exchange.WsRestCandle{
-			Time:   c.Time,
-			Open:   c.Open,
-			High:   c.High,
-			Low:    c.Low,
-			Close:  c.Close,
-			Volume: c.Volume,
-		}
+		merged[c.Time] = c.ToWsRest()
 	}
 
 	for _, c := range ws {
-		merged[c.Time] = exchange.WsRestCandle{
-			Time:   c.Time,
-			Open:   c.Open,
-			High:   c.High,
-			Low:    c.Low,
-			Close:  c.Close,
-			Volume: c.Volume,
-		}
+		merged[c.Time] = c.ToWsRest()
 	}
 
 	result := make([]exchange.WsRestCandle, 0, len(merged))
@@ -41,3 +28,57 @@ func MergeCandles(ws []exchange.WsCandle, rest []exchange.RestCandle) []exchange
 
 	return result
 }
+
+// ContinuityTolerance controls how SafeMerge treats gaps between consecutive
+// candles. The zero value is strict: every diff must equal the interval.
+type ContinuityTolerance struct {
+	// MaxHealBars heals a gap that is an exact multiple (2..MaxHealBars+1) of
+	// the interval by inserting flat zero-volume candles at the prior close.
+	MaxHealBars int
+	// SlackSecs accepts a diff within ±SlackSecs of the interval as-is
+	// (clock adjustments, maintenance windows).
+	SlackSecs int64
+}
+
+// SafeMerge merges ws over rest like MergeCandles, then verifies the series is
+// continuous at intervalSecs. Gaps within tol are healed or accepted; anything
+// else (misaligned timestamps, long outages) is rejected with an error.
+// The result is always freshly allocated and never shares a backing array
+// with ws or rest, so callers may keep and reuse their input buffers.
+func SafeMerge(ws []exchange.WsCandle, rest []exchange.RestCandle, intervalSecs int64, tol ContinuityTolerance) ([]exchange.WsRestCandle, error) {
+	merged := MergeCandles(ws, rest)
+	if len(merged) < 2 || intervalSecs <= 0 {
+		return merged, nil
+	}
+
+	result := make([]exchange.WsRestCandle, 0, len(merged))
+	result = append(result, merged[0])
+	for i := 1; i < len(merged); i++ {
+		prev := result[len(result)-1]
+		curr := merged[i]
+		diff := curr.Time - prev.Time
+
+		switch {
+		case diff == intervalSecs:
+		case absInt64(diff-intervalSecs) <= tol.SlackSecs:
+		case diff%intervalSecs == 0 && diff/intervalSecs-1 <= int64(tol.MaxHealBars):
+			for t := prev.Time + intervalSecs; t < curr.Time; t += intervalSecs {
+				result = append(result, exchange.WsRestCandle{
+					Time: t, Open: prev.Close, High: prev.Close,
+					Low: prev.Close, Close: prev.Close,
+				})
+			}
+		default:
+			return nil, fmt.Errorf("candle gap at %d: diff %ds, want %ds", curr.Time, diff, intervalSecs)
+		}
+		result = append(result, curr)
+	}
+	return result, nil
+}
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -91,3 +91,200 @@ func TestMergeCandles_BothEmpty(t *testing.T) {
 	// Assert
 	assert.Len(t, result, 0)
 }
+
+func TestSafeMerge_ExactDoubleGapHealed(t *testing.T) {
+	// Arrange — 900s interval, candle at 1900 missing (2× gap)
+	rest := []exchange.RestCandle{
+		{Time: 1000, Open: 100.0, High: 105.0, Low: 99.0, Close: 103.0, Volume: 500.0},
+		{Time: 2800, Open: 103.0, High: 108.0, Low: 102.0, Close: 107.0, Volume: 600.0},
+	}
+
+	// Act
+	result, err := SafeMerge(nil, rest, 900, ContinuityTolerance{MaxHealBars: 1})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+	assert.Equal(t, int64(1900), result[1].Time)
+	assert.Equal(t, 103.0, result[1].Close)
+	assert.Equal(t, 0.0, result[1].Volume)
+}
+
+func TestSafeMerge_MisalignedGapRejected(t *testing.T) {
+	// Arrange — 1337s is not a multiple of the 900s interval
+	rest := []exchange.RestCandle{
+		{Time: 1000, Close: 103.0},
+		{Time: 2337, Close: 107.0},
+	}
+
+	// Act
+	_, err := SafeMerge(nil, rest, 900, ContinuityTolerance{MaxHealBars: 1})
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestSafeMerge_StrictRejectsDoubleGap(t *testing.T) {
+	rest := []exchange.RestCandle{
+		{Time: 1000, Close: 103.0},
+		{Time: 2800, Close: 107.0},
+	}
+
+	_, err := SafeMerge(nil, rest, 900, ContinuityTolerance{})
+
+	assert.Error(t, err)
+}
+
+func TestSafeMerge_SlackAcceptsSmallDrift(t *testing.T) {
+	rest := []exchange.RestCandle{
+		{Time: 1000, Close: 103.0},
+		{Time: 1901, Close: 107.0},
+	}
+
+	result, err := SafeMerge(nil, rest, 900, ContinuityTolerance{SlackSecs: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+}
+
+func TestSafeMerge_DoesNotMutateInputs(t *testing.T) {
+	// Arrange — unsorted ws overlapping rest, with a healable gap
+	rest := []exchange.RestCandle{
+		{Time: 1000, Close: 100.0},
+		{Time: 1900, Close: 101.0},
+		{Time: 3700, Close: 103.0},
+	}
+	ws := []exchange.WsCandle{
+		{Time: 4600, Close: 104.0},
+		{Time: 3700, Close: 103.5},
+	}
+	restBefore := append([]exchange.RestCandle(nil), rest...)
+	wsBefore := append([]exchange.WsCandle(nil), ws...)
+
+	// Act
+	result, err := SafeMerge(ws, rest, 900, ContinuityTolerance{MaxHealBars: 1})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, result, 5)
+	assert.Equal(t, restBefore, rest)
+	assert.Equal(t, wsBefore, ws)
+}
+
+func TestSafeMerge_ResultIsFreshEachCall(t *testing.T) {
+	rest := []exchange.RestCandle{{Time: 1000, Close: 100.0}, {Time: 1900, Close: 101.0}}
+
+	first, err := SafeMerge(nil, rest, 900, ContinuityTolerance{})
+	assert.NoError(t, err)
+	first[0].Close = -1
+	_ = append(first[:1], exchange.WsRestCandle{Time: 9999})
+
+	second, err := SafeMerge(nil, rest, 900, ContinuityTolerance{})
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, second[0].Close)
+	assert.Equal(t, int64(1900), second[1].Time)
+}
+
+func TestMergeCandles_KeepsVolumeFromBothSources(t *testing.T) {
+	rest := []exchange.RestCandle{{Time: 1000, Close: 100.0, Volume: 500.0}}
+	ws := []exchange.WsCandle{{Time: 1900, Close: 101.0, Volume: 42.0}}
+
+	result := MergeCandles(ws, rest)
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, 500.0, result[0].Volume)
+	assert.Equal(t, 42.0, result[1].Volume)
+}
+
+func TestSafeMerge_OverlapKeepsOneCandlePerTime(t *testing.T) {
+	// ws repeats the last two REST bars and adds the closed one
+	rest := []exchange.RestCandle{
+		{Time: 1000, Close: 100.0, Volume: 1},
+		{Time: 1900, Close: 101.0, Volume: 2},
+		{Time: 2800, Close: 102.0, Volume: 3},
+	}
+	ws := []exchange.WsCandle{
+		{Time: 1900, Close: 101.5, Volume: 20},
+		{Time: 2800, Close: 102.5, Volume: 30},
+		{Time: 3700, Close: 103.0, Volume: 40},
+	}
+
+	result, err := SafeMerge(ws, rest, 900, ContinuityTolerance{})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 4)
+	for i, want := range []int64{1000, 1900, 2800, 3700} {
+		assert.Equal(t, want, result[i].Time)
+	}
+	assert.Equal(t, 101.5, result[1].Close)
+	assert.Equal(t, 30.0, result[2].Volume)
+}
+
+func TestSafeMerge_HealsUpToMaxHealBars(t *testing.T) {
+	// 3 missing bars: exactly MaxHealBars
+	rest := []exchange.RestCandle{
+		{Time: 1000, Close: 100.0},
+		{Time: 4600, Close: 104.0},
+	}
+
+	result, err := SafeMerge(nil, rest, 900, ContinuityTolerance{MaxHealBars: 3})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 5)
+	for i, c := range result[1:4] {
+		assert.Equal(t, int64(1900+900*i), c.Time)
+		assert.Equal(t, 100.0, c.Close)
+		assert.Equal(t, 0.0, c.Volume)
+	}
+}
+
+func TestSafeMerge_RejectsOneBarPastMaxHealBars(t *testing.T) {
+	// 4 missing bars with MaxHealBars 3
+	rest := []exchange.RestCandle{
+		{Time: 1000, Close: 100.0},
+		{Time: 5500, Close: 105.0},
+	}
+
+	_, err := SafeMerge(nil, rest, 900, ContinuityTolerance{MaxHealBars: 3})
+
+	assert.Error(t, err)
+}
+
+func TestSafeMerge_StrictRejectsOffByOneSecond(t *testing.T) {
+	for _, next := range []int64{1899, 1901} {
+		rest := []exchange.RestCandle{{Time: 1000}, {Time: next}}
+
+		_, err := SafeMerge(nil, rest, 900, ContinuityTolerance{})
+
+		assert.Error(t, err, "diff %d", next-1000)
+	}
+}
+
+func TestSafeMerge_SlackBoundary(t *testing.T) {
+	tol := ContinuityTolerance{SlackSecs: 2}
+	for next, ok := range map[int64]bool{
+		1898: true, // interval - SlackSecs
+		1902: true, // interval + SlackSecs
+		1897: false,
+		1903: false,
+	} {
+		rest := []exchange.RestCandle{{Time: 1000}, {Time: next}}
+
+		_, err := SafeMerge(nil, rest, 900, tol)
+
+		if ok {
+			assert.NoError(t, err, "diff %d", next-1000)
+		} else {
+			assert.Error(t, err, "diff %d", next-1000)
+		}
+	}
+}
+
+func TestSafeMerge_OffByOneGapNotHealed(t *testing.T) {
+	// one second short of a clean double gap
+	rest := []exchange.RestCandle{{Time: 1000}, {Time: 2799}}
+
+	_, err := SafeMerge(nil, rest, 900, ContinuityTolerance{MaxHealBars: 5})
+
+	assert.Error(t, err)
+}
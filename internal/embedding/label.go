@@ -1,6 +1,10 @@
 package embedding
 
-import "time-series-rag-agent/internal/exchange"
+import (
+	"fmt"
+
+	"time-series-rag-agent/internal/exchange"
+)
 
 // LabelCalculatorI allows mocking in tests.
 type LabelCalculatorI interface {
@@ -8,11 +12,28 @@ type LabelCalculatorI interface {
 	CalculateLookahead(history []exchange.WsRestCandle, idx int, targetTime int64) []LabelUpdate
 }
 
+// DefaultSlopeWindows are the slope lookaheads stored in next_slope_3/5.
+var DefaultSlopeWindows = []int{3, 5}
+
+// SlopeColumn is the DB column holding the k-bar forward slope.
+func SlopeColumn(k int) string {
+	return fmt.Sprintf("next_slope_%d", k)
+}
+
 // LabelCalculator computes label updates for training data.
-type LabelCalculator struct{}
+type LabelCalculator struct {
+	SlopeWindows []int // forward slope lengths in bars; empty = DefaultSlopeWindows
+}
 
 func NewLabelCalculator() *LabelCalculator {
-	return &LabelCalculator{}
+	return &LabelCalculator{SlopeWindows: DefaultSlopeWindows}
+}
+
+func (l *LabelCalculator) windows() []int {
+	if len(l.SlopeWindows) == 0 {
+		return DefaultSlopeWindows
+	}
+	return l.SlopeWindows
 }
 
 // CalculateFromHistory generates label updates for past candles based on recent data.
@@ -29,25 +50,16 @@ func (l *LabelCalculator) CalculateFromHistory(history []exchange.WsRestCandle)
 		updates = append(updates, update)
 	}
 
-	// Label B: Slope 3 for candle at T-3
-	targetIdx3 := n - 4
-	if targetIdx3 >= 0 {
-		futurePrices := closesSlice(history, n-3, n)
-		updates = append(updates, LabelUpdate{
-			TargetTime: history[targetIdx3].Time,
-			Column:     "next_slope_3",
-			Value:      CalculateSlope(futurePrices),
-		})
-	}
-
-	// Label C: Slope 5 for candle at T-5
-	targetIdx5 := n - 6
-	if targetIdx5 >= 0 {
-		futurePrices := closesSlice(history, targetIdx5+1, n)
+	// Label B: Slope k for candle at T-k, from the k closes after it
+	for _, k := range l.windows() {
+		targetIdx := n - k - 1
+		if k <= 0 || targetIdx < 0 {
+			continue
+		}
 		updates = append(updates, LabelUpdate{
-			TargetTime: history[targetIdx5].Time,
-			Column:     "next_slope_5",
-			Value:      CalculateSlope(futurePrices),
+			TargetTime: history[targetIdx].Time,
+			Column:     SlopeColumn(k),
+			Value:      CalculateSlope(closesSlice(history, targetIdx+1, n)),
 		})
 	}
 
@@ -55,40 +67,7 @@ func (l *LabelCalculator) CalculateFromHistory(history []exchange.WsRestCandle)
 }
 
 func (l *LabelCalculator) CalculateCanelFromHistory(history []exchange.WsRestCandle) []LabelUpdate {
-	updates := []LabelUpdate{}
-	n := len(history)
-	if n < 2 {
-		return updates
-	}
-
-	// Label A: Next Return for candle at T-1
-	if update, ok := l.calcNextReturn(history, n-2, n-1); ok {
-		updates = append(updates, update)
-	}
-
-	// Label B: Slope 3 for candle at T-3
-	targetIdx3 := n - 4
-	if targetIdx3 >= 0 {
-		futurePrices := closesSlice(history, n-3, n)
-		updates = append(updates, LabelUpdate{
-			TargetTime: history[targetIdx3].Time,
-			Column:     "next_slope_3",
-			Value:      CalculateSlope(futurePrices),
-		})
-	}
-
-	// Label C: Slope 5 for candle at T-5
-	targetIdx5 := n - 6
-	if targetIdx5 >= 0 {
-		futurePrices := closesSlice(history, targetIdx5+1, n)
-		updates = append(updates, LabelUpdate{
-			TargetTime: history[targetIdx5].Time,
-			Column:     "next_slope_5",
-			Value:      CalculateSlope(futurePrices),
-		})
-	}
-
-	return updates
+	return l.CalculateFromHistory(history)
 }
 
 // CalculateLookahead generates labels by looking AHEAD from idx.
@@ -105,23 +84,15 @@ func (l *LabelCalculator) CalculateLookahead(history []exchange.WsRestCandle, id
 		}
 	}
 
-	// Label B: Slope 3 (T+1 to T+3)
-	if idx+3 < n {
-		futurePrices := closesSlice(history, idx+1, idx+4)
-		updates = append(updates, LabelUpdate{
-			TargetTime: targetTime,
-			Column:     "next_slope_3",
-			Value:      CalculateSlope(futurePrices),
-		})
-	}
-
-	// Label C: Slope 5 (T+1 to T+5)
-	if idx+5 < n {
-		futurePrices := closesSlice(history, idx+1, idx+6)
+	// Label B: Slope k (T+1 to T+k)
+	for _, k := range l.windows() {
+		if k <= 0 || idx+k >= n {
+			continue
+		}
 		updates = append(updates, LabelUpdate{
 			TargetTime: targetTime,
-			Column:     "next_slope_5",
-			Value:      CalculateSlope(futurePrices),
+			Column:     SlopeColumn(k),
+			Value:      CalculateSlope(closesSlice(history, idx+1, idx+k+1)),
 		})
 	}
 
@@ -331,3 +331,105 @@ func findByColumn(updates []LabelUpdate, column string) *LabelUpdate {
 	}
 	return nil
 }
+
+// --- custom slope windows ---
+
+func TestCalculateFromHistory_CustomWindows_TargetsAndValues(t *testing.T) {
+	// Arrange — 12 candles, times 0..11000, windows 2 and 10
+	lc := &LabelCalculator{SlopeWindows: []int{2, 10}}
+	entries := make([][2]float64, 12)
+	for i := range entries {
+		entries[i] = [2]float64{float64(i * 1000), 100 + float64(i*i)}
+	}
+	history := makeHistoryWithTime(entries)
+
+	// Act
+	result := lc.CalculateFromHistory(history)
+
+	// Assert — slope k labels candle T-k from the k closes after it
+	slope2 := findByColumn(result, "next_slope_2")
+	slope10 := findByColumn(result, "next_slope_10")
+	assert.NotNil(t, slope2)
+	assert.NotNil(t, slope10)
+	assert.Equal(t, int64(9000), slope2.TargetTime)
+	assert.Equal(t, int64(1000), slope10.TargetTime)
+	assert.InDelta(t, CalculateSlope(closesSlice(history, 10, 12)), slope2.Value, 1e-12)
+	assert.InDelta(t, CalculateSlope(closesSlice(history, 2, 12)), slope10.Value, 1e-12)
+	assert.NotContains(t, columnsOf(result), "next_slope_3")
+}
+
+func TestCalculateLookahead_CustomWindows_SkipsTooLong(t *testing.T) {
+	// Arrange — idx=0 with 10 future candles: 10 fits, 20 does not
+	lc := &LabelCalculator{SlopeWindows: []int{10, 20}}
+	closes := make([]float64, 11)
+	for i := range closes {
+		closes[i] = 100 + float64(i)
+	}
+	history := makeHistory(closes)
+
+	// Act
+	result := lc.CalculateLookahead(history, 0, 4242)
+
+	// Assert
+	slope10 := findByColumn(result, "next_slope_10")
+	assert.NotNil(t, slope10)
+	assert.Equal(t, int64(4242), slope10.TargetTime)
+	assert.InDelta(t, CalculateSlope(closes[1:]), slope10.Value, 1e-12)
+	assert.NotContains(t, columnsOf(result), "next_slope_20")
+}
+
+func TestLabelCalculator_ZeroValue_UsesDefaultWindows(t *testing.T) {
+	lc := &LabelCalculator{}
+
+	result := lc.CalculateFromHistory(makeHistory([]float64{1, 2, 3, 4, 5, 6}))
+
+	assert.Contains(t, columnsOf(result), "next_slope_3")
+	assert.Contains(t, columnsOf(result), "next_slope_5")
+}
+
+// --- Live back-fill ---
+
+func TestCalculateFromHistory_SlidingWindow_BackfillsSameLabelsAsLookahead(t *testing.T) {
+	// Arrange: replay a live session one bar at a time over the last window+1
+	// candles, the slice NewEmbeddingPipeline labels from.
+	full := makeHistory([]float64{100, 101, 99, 102, 104, 103, 105, 108, 107, 106, 109, 111, 110, 112})
+	const window = 6
+	horizon := map[string]int{"next_return": 1, "next_slope_3": 3, "next_slope_5": 5}
+	lc := NewLabelCalculator()
+
+	// Act
+	live := map[int64]map[string]float64{}
+	for end := window + 1; end <= len(full); end++ {
+		for _, u := range lc.CalculateFromHistory(full[end-window-1 : end]) {
+			if live[u.TargetTime] == nil {
+				live[u.TargetTime] = map[string]float64{}
+			}
+			_, dup := live[u.TargetTime][u.Column]
+			assert.False(t, dup, "%s written twice for %d", u.Column, u.TargetTime)
+			live[u.TargetTime][u.Column] = u.Value
+		}
+	}
+
+	// Assert: each candle gets exactly the labels bulk mode computes, once its
+	// horizon has elapsed. Candles older than the first live window are never
+	// revisited, which is what ReconcileLabels is for.
+	written := 0
+	for i, c := range full {
+		for _, want := range lc.CalculateLookahead(full, i, c.Time) {
+			v, ok := live[c.Time][want.Column]
+			if i < window-horizon[want.Column] {
+				assert.False(t, ok, "candle %d %s predates the first live bar", i, want.Column)
+				continue
+			}
+			if assert.True(t, ok, "candle %d missing %s", i, want.Column) {
+				assert.InDelta(t, want.Value, v, 1e-12, "candle %d %s", i, want.Column)
+				written++
+			}
+		}
+	}
+	total := 0
+	for _, cols := range live {
+		total += len(cols)
+	}
+	assert.Equal(t, written, total, "no label landed on a timestamp bulk mode would not label")
+}
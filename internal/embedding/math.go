@@ -1,11 +1,16 @@
 package embedding
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
 
 // PlanckConstant is used as a numerical stability epsilon.
 const PlanckConstant = 6.62607015e-34
 
 // CalculateLogReturn returns log returns from a slice of close prices.
+// A step touching a close <= 0 (log is -Inf/NaN there) yields 0; callers that
+// must reject such windows use ValidateCloses first.
 // Output length = len(closes) - 1.
 func CalculateLogReturn(closes []float64) []float64 {
 	if len(closes) < 2 {
@@ -13,6 +18,9 @@ func CalculateLogReturn(closes []float64) []float64 {
 	}
 	res := make([]float64, len(closes)-1)
 	for i := 1; i < len(closes); i++ {
+		if closes[i] <= 0 || closes[i-1] <= 0 {
+			continue
+		}
 		curr := math.Log(closes[i] + PlanckConstant)
 		prev := math.Log(closes[i-1] + PlanckConstant)
 		res[i-1] = curr - prev
@@ -20,6 +28,47 @@ func CalculateLogReturn(closes []float64) []float64 {
 	return res
 }
 
+// ValidateCloses reports the first close <= 0, typically a failed REST parse
+// that defaulted to 0.
+func ValidateCloses(closes []float64) error {
+	for i, c := range closes {
+		if c <= 0 {
+			return fmt.Errorf("%w: closes[%d] = %g", ErrNonPositivePrice, i, c)
+		}
+	}
+	return nil
+}
+
+// CalculateLogVolumeDelta returns log1p(v[i]) - log1p(v[i-1]), so empty
+// bars (zero volume) stay finite. Output length = len(volumes) - 1.
+func CalculateLogVolumeDelta(volumes []float64) []float64 {
+	if len(volumes) < 2 {
+		return []float64{}
+	}
+	res := make([]float64, len(volumes)-1)
+	for i := 1; i < len(volumes); i++ {
+		res[i-1] = math.Log1p(math.Max(volumes[i], 0)) - math.Log1p(math.Max(volumes[i-1], 0))
+	}
+	return res
+}
+
+// CalculatePctReturn returns simple percentage returns (curr/prev - 1) from a
+// slice of close prices. A zero previous close yields 0 for that step.
+// Output length = len(closes) - 1.
+func CalculatePctReturn(closes []float64) []float64 {
+	if len(closes) < 2 {
+		return []float64{}
+	}
+	res := make([]float64, len(closes)-1)
+	for i := 1; i < len(closes); i++ {
+		if closes[i-1] == 0 {
+			continue
+		}
+		res[i-1] = closes[i]/closes[i-1] - 1
+	}
+	return res
+}
+
 // CalculateZScore normalizes a slice to zero mean and unit variance.
 func CalculateZScore(data []float64) []float64 {
 	if len(data) == 0 {
@@ -45,6 +94,61 @@ func CalculateZScore(data []float64) []float64 {
 	return res
 }
 
+// ClipZScore bounds each value to ±bound in place and returns data, so a
+// single spike cannot dominate cosine distance. bound <= 0 leaves data as is.
+func ClipZScore(data []float64, bound float64) []float64 {
+	if bound <= 0 {
+		return data
+	}
+	for i, v := range data {
+		data[i] = math.Max(-bound, math.Min(bound, v))
+	}
+	return data
+}
+
+// CalculateRSI returns Wilder's relative strength index: the first average
+// gain and loss are simple means over period changes, later ones are smoothed
+// as avg = (avg*(period-1) + current) / period. Output length = len(closes);
+// the first period values (and all of them when closes is too short or
+// period < 1) are NaN. A window with no losses reads 100, a flat one 50.
+func CalculateRSI(closes []float64, period int) []float64 {
+	res := make([]float64, len(closes))
+	for i := range res {
+		res[i] = math.NaN()
+	}
+	if period < 1 || len(closes) <= period {
+		return res
+	}
+
+	var avgGain, avgLoss float64
+	for i := 1; i <= period; i++ {
+		change := closes[i] - closes[i-1]
+		avgGain += math.Max(change, 0)
+		avgLoss += math.Max(-change, 0)
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+	res[period] = rsiFrom(avgGain, avgLoss)
+
+	for i := period + 1; i < len(closes); i++ {
+		change := closes[i] - closes[i-1]
+		avgGain = (avgGain*float64(period-1) + math.Max(change, 0)) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + math.Max(-change, 0)) / float64(period)
+		res[i] = rsiFrom(avgGain, avgLoss)
+	}
+	return res
+}
+
+func rsiFrom(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
+
 // CalculateSlope computes the linear regression slope of normalized prices.
 // Equivalent to np.polyfit(x, y_norm, 1)[0].
 func CalculateSlope(prices []float64) float64 {
@@ -56,6 +56,26 @@ func TestCalculateLogReturn_Empty_ReturnsEmpty(t *testing.T) {
 	assert.Empty(t, result)
 }
 
+func TestCalculateLogReturn_ZeroClose_NoInfOrNaN(t *testing.T) {
+	// Act
+	res := CalculateLogReturn([]float64{100, 0, 110, 121})
+	z := CalculateZScore(res)
+
+	// Assert
+	assert.Equal(t, 0.0, res[0])
+	assert.Equal(t, 0.0, res[1])
+	assert.InDelta(t, math.Log(1.1), res[2], 1e-12)
+	for _, v := range z {
+		assert.False(t, math.IsNaN(v) || math.IsInf(v, 0))
+	}
+}
+
+func TestValidateCloses_RejectsNonPositive(t *testing.T) {
+	assert.NoError(t, ValidateCloses([]float64{1, 2, 3}))
+	assert.ErrorIs(t, ValidateCloses([]float64{1, -2, 3}), ErrNonPositivePrice)
+	assert.ErrorContains(t, ValidateCloses([]float64{1, 0}), "closes[1] = 0")
+}
+
 func TestCalculateLogReturn_FlatPrices_ReturnsNearZero(t *testing.T) {
 	// Arrange
 	closes := []float64{100.0, 100.0, 100.0}
@@ -214,3 +234,80 @@ func TestCalculateSlope_StartValueZero_NoNaNOrPanic(t *testing.T) {
 	assert.False(t, math.IsNaN(result))
 	assert.False(t, math.IsInf(result, 0))
 }
+
+// --- CalculatePctReturn ---
+
+func TestCalculatePctReturn_NormalInput(t *testing.T) {
+	// Arrange
+	closes := []float64{100.0, 110.0, 99.0}
+
+	// Act
+	result := CalculatePctReturn(closes)
+
+	// Assert
+	assert.Len(t, result, 2)
+	assert.InDelta(t, 0.10, result[0], 1e-9)
+	assert.InDelta(t, -0.10, result[1], 1e-9)
+}
+
+func TestCalculatePctReturn_ZeroPrev_ReturnsZero(t *testing.T) {
+	// Arrange
+	closes := []float64{0.0, 5.0}
+
+	// Act
+	result := CalculatePctReturn(closes)
+
+	// Assert
+	assert.Equal(t, []float64{0}, result)
+}
+
+func TestClipZScore_ClampsBothSides(t *testing.T) {
+	assert.Equal(t, []float64{-2.5, 0.4, 2.5}, ClipZScore([]float64{-9, 0.4, 10}, 2.5))
+	assert.Equal(t, []float64{-9, 10}, ClipZScore([]float64{-9, 10}, 0), "zero bound disables clipping")
+}
+
+func TestCalculateLogVolumeDelta_ZeroVolumeStaysFinite(t *testing.T) {
+	// Act
+	res := CalculateLogVolumeDelta([]float64{0, 10, 0})
+
+	// Assert
+	assert.Len(t, res, 2)
+	assert.InDelta(t, math.Log1p(10), res[0], 1e-12)
+	assert.InDelta(t, -math.Log1p(10), res[1], 1e-12)
+}
+
+// Wilder's worked example (14-period) as tabulated by StockCharts.
+func TestCalculateRSI_WilderReference(t *testing.T) {
+	closes := []float64{
+		44.3389, 44.0902, 44.1497, 43.6124, 44.3278, 44.8264, 45.0955, 45.4245, 45.8433, 46.0826,
+		45.8931, 46.0328, 45.6140, 46.2820, 46.2820, 46.0028, 46.0328, 46.4116, 46.2222, 45.6439,
+		46.2122, 46.2521, 45.7137, 46.4515, 45.7835, 45.3548, 44.0288, 44.1783, 44.2181, 44.5672,
+		43.4205, 42.6628, 43.1314,
+	}
+	want := []float64{
+		70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93, 63.26, 56.06, 62.38,
+		54.71, 50.42, 39.99, 41.46, 41.87, 45.46, 37.30, 33.08, 37.77,
+	}
+
+	got := CalculateRSI(closes, 14)
+
+	assert.Len(t, got, len(closes))
+	for i := 0; i < 14; i++ {
+		assert.True(t, math.IsNaN(got[i]), "index %d has no RSI yet", i)
+	}
+	for i, w := range want {
+		assert.InDelta(t, w, got[14+i], 0.01, "index %d", 14+i)
+	}
+}
+
+func TestCalculateRSI_Extremes(t *testing.T) {
+	rising := CalculateRSI([]float64{1, 2, 3, 4}, 3)
+	assert.Equal(t, 100.0, rising[3])
+
+	flat := CalculateRSI([]float64{5, 5, 5, 5}, 3)
+	assert.Equal(t, 50.0, flat[3])
+
+	for _, v := range CalculateRSI([]float64{1, 2, 3}, 3) {
+		assert.True(t, math.IsNaN(v), "too short")
+	}
+}
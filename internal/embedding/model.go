@@ -3,16 +3,20 @@ package embedding
 import (
 	"math"
 	"time"
+	"time-series-rag-agent/internal/exchange"
 
 	"github.com/pgvector/pgvector-go"
 )
 
 type PatternFeature struct {
-	Time       time.Time `json:"time"`
-	Symbol     string    `json:"symbol"`
-	Interval   string    `json:"interval"`
-	ClosePrice float64   `json:"close_price"`
-	Embedding  []float64 `json:"embedding"`
+	Time       time.Time               `json:"time"`
+	Symbol     string                  `json:"symbol"`
+	Interval   string                  `json:"interval"`
+	ClosePrice float64                 `json:"close_price"`
+	Embedding  []float64               `json:"embedding"`
+	Window     []exchange.WsRestCandle `json:"window,omitempty"`  // raw candles behind Embedding
+	Version    string                  `json:"version,omitempty"` // embedding recipe, e.g. "log-v1"
+	RSI        *float64                `json:"rsi,omitempty"`     // Wilder RSI at the last bar; nil = not computed
 }
 
 type PatternLabel struct {
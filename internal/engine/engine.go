@@ -0,0 +1,78 @@
+// Package engine runs the live pipeline for several symbols in one process.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"time-series-rag-agent/internal/exchange"
+)
+
+// Worker handles one symbol's closed bars.
+type Worker interface {
+	Symbol() string
+	RunCycle(ctx context.Context, candle exchange.WsCandle) error
+}
+
+// Engine hands each closed bar to every worker that has a candle in it.
+// Workers run concurrently and independently: one failing does not stop the
+// others, and a worker still busy with the previous bar skips the new one
+// instead of stacking up behind it.
+type Engine struct {
+	workers []Worker
+	busy    []atomic.Bool
+	logger  *slog.Logger
+}
+
+func New(logger *slog.Logger, workers ...Worker) *Engine {
+	return &Engine{
+		workers: workers,
+		busy:    make([]atomic.Bool, len(workers)),
+		logger:  logger,
+	}
+}
+
+// Symbols lists the workers' symbols in order, for the market stream.
+func (e *Engine) Symbols() []string {
+	out := make([]string, len(e.workers))
+	for i, w := range e.workers {
+		out[i] = w.Symbol()
+	}
+	return out
+}
+
+// RunCycle runs one bar and waits for the workers it started. The error
+// joins every worker failure, each prefixed with its symbol.
+func (e *Engine) RunCycle(ctx context.Context, candles map[string]exchange.WsCandle) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for i, w := range e.workers {
+		candle, ok := candles[w.Symbol()]
+		if !ok {
+			continue
+		}
+		if !e.busy[i].CompareAndSwap(false, true) {
+			e.logger.Warn("[Engine] previous cycle still running, dropping bar", "symbol", w.Symbol())
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer e.busy[i].Store(false)
+			if err := w.RunCycle(ctx, candle); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", w.Symbol(), err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
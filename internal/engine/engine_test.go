@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/exchange"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockWorker struct {
+	symbol  string
+	err     error
+	block   chan struct{} // when set, RunCycle waits for it to close
+	started chan struct{}
+	cycles  atomic.Int32
+	last    atomic.Value // exchange.WsCandle
+}
+
+func (m *mockWorker) Symbol() string { return m.symbol }
+
+func (m *mockWorker) RunCycle(ctx context.Context, candle exchange.WsCandle) error {
+	if m.started != nil {
+		close(m.started)
+	}
+	if m.block != nil {
+		<-m.block
+	}
+	m.last.Store(candle)
+	m.cycles.Add(1)
+	return m.err
+}
+
+func TestEngine_RunsEveryWorkerOnce(t *testing.T) {
+	btc := &mockWorker{symbol: "BTCUSDT"}
+	eth := &mockWorker{symbol: "ETHUSDT"}
+	e := New(slog.Default(), btc, eth)
+
+	err := e.RunCycle(context.Background(), map[string]exchange.WsCandle{
+		"BTCUSDT": {Close: 65000},
+		"ETHUSDT": {Close: 3200},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), btc.cycles.Load())
+	assert.Equal(t, int32(1), eth.cycles.Load())
+	assert.Equal(t, 65000.0, btc.last.Load().(exchange.WsCandle).Close)
+	assert.Equal(t, 3200.0, eth.last.Load().(exchange.WsCandle).Close)
+	assert.Equal(t, []string{"BTCUSDT", "ETHUSDT"}, e.Symbols())
+}
+
+func TestEngine_SkipsWorkerWithoutCandle(t *testing.T) {
+	btc := &mockWorker{symbol: "BTCUSDT"}
+	eth := &mockWorker{symbol: "ETHUSDT"}
+	e := New(slog.Default(), btc, eth)
+
+	require.NoError(t, e.RunCycle(context.Background(), map[string]exchange.WsCandle{"BTCUSDT": {Close: 1}}))
+	assert.Equal(t, int32(1), btc.cycles.Load())
+	assert.Zero(t, eth.cycles.Load())
+}
+
+func TestEngine_OneFailureDoesNotStopOthers(t *testing.T) {
+	boom := errors.New("llm: timeout")
+	btc := &mockWorker{symbol: "BTCUSDT", err: boom}
+	eth := &mockWorker{symbol: "ETHUSDT"}
+	e := New(slog.Default(), btc, eth)
+
+	err := e.RunCycle(context.Background(), map[string]exchange.WsCandle{"BTCUSDT": {}, "ETHUSDT": {}})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, boom)
+	assert.Contains(t, err.Error(), "BTCUSDT: llm: timeout")
+	assert.Equal(t, int32(1), eth.cycles.Load())
+}
+
+func TestEngine_BusyWorkerDropsBar(t *testing.T) {
+	slow := &mockWorker{symbol: "BTCUSDT", block: make(chan struct{}), started: make(chan struct{})}
+	e := New(slog.Default(), slow)
+	candles := map[string]exchange.WsCandle{"BTCUSDT": {}}
+
+	done := make(chan error)
+	go func() { done <- e.RunCycle(context.Background(), candles) }()
+	<-slow.started
+
+	// The first bar is still running, so this one is dropped.
+	require.NoError(t, e.RunCycle(context.Background(), candles))
+	close(slow.block)
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(1), slow.cycles.Load())
+}
+
+func TestNewSymbolWorker_PerSymbolConfigAndSharedOrderLock(t *testing.T) {
+	cfg := &config.AppConfig{Agent: config.AgentConfig{
+		Leverage: 10, AviableTradeRatio: 0.9, ReductionAviableTradeRatio: 0.7,
+		LeverageBySymbol:   map[string]int{"ETHUSDT": 5},
+		TradeRatioBySymbol: map[string]float64{"ETHUSDT": 0.4},
+	}}
+	shared := Shared{Client: futures.NewClient("", ""), Orders: &sync.Mutex{}, Logger: slog.Default()}
+
+	eth := NewSymbolWorker(shared, cfg, "ETHUSDT", "15m", 30, nil)
+	btc := NewSymbolWorker(shared, cfg, "BTCUSDT", "15m", 30, nil)
+
+	assert.Equal(t, 5, eth.deps.Config.Agent.Leverage)
+	assert.Equal(t, 0.4, eth.deps.Config.Agent.AviableTradeRatio)
+	assert.Equal(t, 0.4, eth.deps.Config.Agent.ReductionAviableTradeRatio, "reduction never exceeds the symbol's ratio")
+	assert.Equal(t, 5, eth.deps.Executor.Leverage)
+	assert.Equal(t, 10, btc.deps.Config.Agent.Leverage)
+	assert.Equal(t, 0.9, btc.deps.Config.Agent.AviableTradeRatio)
+	assert.Equal(t, 10, cfg.Agent.Leverage, "the base config is not modified")
+	assert.NotSame(t, eth.deps.Config, btc.deps.Config)
+	assert.Same(t, shared.Orders, eth.deps.Orders)
+	assert.Same(t, shared.Orders, btc.deps.Orders)
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/llm"
+	"time-series-rag-agent/internal/pipeline"
+	"time-series-rag-agent/internal/storage/postgresql"
+	pkg "time-series-rag-agent/pkg/notifier"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Shared is what every worker of one Engine uses together. The LLM client's
+// daily token budget therefore covers all symbols, and Orders makes workers
+// place entries one at a time, each sized off the balance the previous ones
+// left free.
+type Shared struct {
+	Client *futures.Client          // trading account
+	Klines exchange.KlineService    // REST history of the venue bars stream from
+	Store  *postgresql.PatternStore // from pipeline.OpenLiveStore
+	Ingest *postgresql.IngestQueue  // batches every worker's writes into Store
+	LLM    *llm.LLMService
+	Orders *sync.Mutex
+	Logger *slog.Logger
+}
+
+// SymbolWorker runs the full live pipeline for one symbol on each bar:
+// ingest, pattern search, LLM and order placement.
+type SymbolWorker struct {
+	symbol     string
+	interval   string
+	vectorSize int
+	client     *futures.Client
+	hooks      *pkg.PipelineHooks
+	deps       pipeline.LiveDeps
+	logger     *slog.Logger
+}
+
+var _ Worker = (*SymbolWorker)(nil)
+
+// NewSymbolWorker gives symbol its own executor and cfg.ForSymbol config on
+// top of the shared store, LLM client and order lock; hooks report its events.
+func NewSymbolWorker(shared Shared, cfg *config.AppConfig, symbol, interval string, vectorSize int, hooks *pkg.PipelineHooks) *SymbolWorker {
+	own := cfg.ForSymbol(symbol)
+	logger := shared.Logger.With("symbol", symbol)
+	w := &SymbolWorker{
+		symbol:     symbol,
+		interval:   interval,
+		vectorSize: vectorSize,
+		client:     shared.Client,
+		hooks:      hooks,
+		deps: pipeline.LiveDeps{
+			Klines:   shared.Klines,
+			Config:   own,
+			Executor: pipeline.NewLiveExecutor(shared.Client, symbol, own, logger),
+			Store:    shared.Store,
+			Ingest:   shared.Ingest,
+			LLM:      shared.LLM,
+		},
+		logger: logger,
+	}
+	if shared.Orders != nil {
+		w.deps.Orders = shared.Orders
+	}
+	return w
+}
+
+func (w *SymbolWorker) Symbol() string { return w.symbol }
+
+// RunCycle runs the live pipeline on the closed candle.
+func (w *SymbolWorker) RunCycle(ctx context.Context, candle exchange.WsCandle) error {
+	return pipeline.RunLivePipeline(ctx, w.deps, w.logger, w.client, w.hooks,
+		[]exchange.WsCandle{candle}, w.symbol, w.interval, w.vectorSize, candle.Close,
+	)
+}
@@ -0,0 +1,72 @@
+package exchange
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StopMode is how PlaceTrade places the initial stop loss.
+type StopMode string
+
+const (
+	StopPercent StopMode = "percent" // SLPercentage / Leverage away from entry (default)
+	StopATR     StopMode = "atr"     // ATRMultiplier * ATR(ATRPeriod) away from entry
+)
+
+const defaultATRPeriod = 14
+
+func ParseStopMode(s string) (StopMode, error) {
+	switch m := StopMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return StopPercent, nil
+	case StopPercent, StopATR:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown stop mode %q (want percent or atr)", s)
+	}
+}
+
+// CalculateATR is Wilder's average true range over candles, oldest first: a
+// simple mean of the first period true ranges, then smoothed to the last bar.
+// Returns 0 when there are not period+1 candles.
+func CalculateATR(candles []WsRestCandle, period int) float64 {
+	if period < 1 {
+		return 0
+	}
+	rest := make([]RestCandle, len(candles))
+	for i, c := range candles {
+		rest[i] = RestCandle(c)
+	}
+	return calcATR(rest, period)
+}
+
+// atrStop is entry moved against side by multiplier ATRs.
+func atrStop(side string, entry, atr, multiplier float64) float64 {
+	switch side {
+	case "LONG":
+		return entry - multiplier*atr
+	case "SHORT":
+		return entry + multiplier*atr
+	}
+	return 0
+}
+
+// StopLoss is the initial stop for an entry at price under StopMode. In ATR
+// mode it falls back to CalculateSL, reporting atr = 0, when candles are too
+// short for an ATR or the stop would not be a positive price: a trade is
+// never sent without a stop.
+func (e *Executor) StopLoss(price float64, side string, candles []WsRestCandle) (sl, atr float64) {
+	if e.StopMode != StopATR {
+		return e.CalculateSL(price, side), 0
+	}
+	period := e.ATRPeriod
+	if period <= 0 {
+		period = defaultATRPeriod
+	}
+	atr = CalculateATR(candles, period)
+	sl = atrStop(side, price, atr, e.ATRMultiplier)
+	if atr <= 0 || e.ATRMultiplier <= 0 || sl <= 0 {
+		return e.CalculateSL(price, side), 0
+	}
+	return sl, atr
+}
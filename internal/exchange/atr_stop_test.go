@@ -0,0 +1,88 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func atrCandles() []WsRestCandle {
+	return []WsRestCandle{
+		{High: 10, Low: 8, Close: 9},
+		{High: 11, Low: 9, Close: 10},    // TR 2
+		{High: 13, Low: 10, Close: 12},   // TR 3
+		{High: 12, Low: 11, Close: 11.5}, // TR 1 (low vs prev close)
+		{High: 15, Low: 11.5, Close: 14}, // TR 3.5
+	}
+}
+
+func TestCalculateATR_WilderSmoothing(t *testing.T) {
+	// Seed (2+3)/2 = 2.5, then 2.5/2+1/2 = 1.75, then 1.75/2+3.5/2 = 2.625.
+	assert.InDelta(t, 2.625, CalculateATR(atrCandles(), 2), 1e-12)
+	assert.InDelta(t, 2.5, CalculateATR(atrCandles()[:3], 2), 1e-12)
+}
+
+func TestCalculateATR_GapUsesPreviousClose(t *testing.T) {
+	candles := []WsRestCandle{
+		{High: 10, Low: 8, Close: 9},
+		{High: 20, Low: 19, Close: 19.5}, // range 1, gap from 9: TR 11
+	}
+
+	assert.InDelta(t, 11.0, CalculateATR(candles, 1), 1e-12)
+}
+
+func TestCalculateATR_TooShort(t *testing.T) {
+	assert.Zero(t, CalculateATR(atrCandles()[:2], 2))
+	assert.Zero(t, CalculateATR(atrCandles(), 0))
+}
+
+func TestParseStopMode(t *testing.T) {
+	for in, want := range map[string]StopMode{"": StopPercent, "percent": StopPercent, " ATR ": StopATR} {
+		got, err := ParseStopMode(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := ParseStopMode("chandelier")
+	assert.Error(t, err)
+}
+
+func TestStopLoss_BothModes(t *testing.T) {
+	e := &Executor{Leverage: 5, SLPercentage: 0.05} // 1% price move
+	candles := atrCandles()
+
+	sl, atr := e.StopLoss(2000, "LONG", candles)
+	assert.Equal(t, e.CalculateSL(2000, "LONG"), sl, "percent is the default")
+	assert.Zero(t, atr)
+
+	e.StopMode, e.ATRPeriod, e.ATRMultiplier = StopATR, 2, 2
+	sl, atr = e.StopLoss(2000, "LONG", candles)
+	assert.InDelta(t, 2.625, atr, 1e-12)
+	assert.InDelta(t, 2000-2*2.625, sl, 1e-9)
+	sl, _ = e.StopLoss(2000, "SHORT", candles)
+	assert.InDelta(t, 2000+2*2.625, sl, 1e-9)
+}
+
+func TestStopLoss_ATRFallsBackToPercent(t *testing.T) {
+	e := &Executor{Leverage: 5, SLPercentage: 0.05, StopMode: StopATR, ATRPeriod: 14, ATRMultiplier: 1.5}
+
+	sl, atr := e.StopLoss(2000, "LONG", atrCandles())
+
+	assert.Zero(t, atr, "5 candles cannot seed a 14-bar ATR")
+	assert.Equal(t, e.CalculateSL(2000, "LONG"), sl)
+}
+
+func TestPlaceTrade_ATRStop(t *testing.T) {
+	var algos []map[string]string
+	e := newTrailingExecutor(t, &algos)
+	e.TrailingStop = false
+	e.StopMode, e.ATRPeriod, e.ATRMultiplier = StopATR, 2, 2
+
+	err := e.PlaceTrade(context.Background(), "LONG", 2000, atrCandles())
+
+	require.NoError(t, err)
+	require.Len(t, algos, 2)
+	assert.Equal(t, "STOP_MARKET", algos[0]["type"])
+	assert.Equal(t, "1994.75", algos[0]["triggerPrice"], "2000 - 2 x ATR 2.625")
+}
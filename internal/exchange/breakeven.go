@@ -0,0 +1,129 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// breakevenTrigger is the price at which the trade is triggerR multiples of
+// its initial risk in profit; risk is the entry-to-stop distance.
+func breakevenTrigger(side string, entry, stop, triggerR float64) float64 {
+	risk := math.Abs(entry - stop)
+	if side == "SHORT" {
+		return entry - triggerR*risk
+	}
+	return entry + triggerR*risk
+}
+
+// breakevenStop is entry moved by buffer (a fraction of entry) to the
+// profitable side, so a stop-out there still covers the round-trip fees.
+func breakevenStop(side string, entry, buffer float64) float64 {
+	if side == "SHORT" {
+		return entry * (1 - buffer)
+	}
+	return entry * (1 + buffer)
+}
+
+// protects reports whether stop already locks in at least target: at or above
+// it for a LONG, at or below it for a SHORT.
+func protects(side string, stop, target float64) bool {
+	if side == "SHORT" {
+		return stop <= target
+	}
+	return stop >= target
+}
+
+// PositionEntryPrice is the average entry price of the open position; 0 when
+// flat.
+func (e *Executor) PositionEntryPrice(ctx context.Context) (float64, error) {
+	positions, err := e.Client.NewGetPositionRiskService().Symbol(e.Symbol).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("API error: %v", err)
+	}
+	for _, p := range positions {
+		if p.Symbol == e.Symbol {
+			return ParseNumber("entryPrice", p.EntryPrice)
+		}
+	}
+	return 0, nil
+}
+
+// MoveStopToBreakeven re-arms the position's STOP_MARKET at entry (plus
+// BreakevenBuffer) once currentPrice is BreakevenR multiples of the initial
+// risk in profit. The risk is read off the resting stop, so it matches the
+// leverage the trade was opened with. The new stop is placed before the old
+// one is cancelled, so the position is never unprotected; a stop already at
+// or past break-even is left alone, which makes repeated calls no-ops.
+// BreakevenR <= 0 disables the move.
+func (e *Executor) MoveStopToBreakeven(ctx context.Context, side string, entryPrice, currentPrice float64) error {
+	if e.BreakevenR <= 0 || entryPrice <= 0 {
+		return nil
+	}
+	closeSide := futures.SideTypeSell
+	if side == "SHORT" {
+		closeSide = futures.SideTypeBuy
+	}
+
+	algos, err := e.Client.NewListOpenAlgoOrdersService().Symbol(e.Symbol).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("breakeven: list algo orders: %w", err)
+	}
+	var stop *futures.GetAlgoOrderResp
+	for i, a := range algos {
+		if a.OrderType == futures.AlgoOrderTypeStopMarket && a.Side == closeSide && (a.ReduceOnly || a.ClosePosition) {
+			stop = &algos[i]
+			break
+		}
+	}
+	if stop == nil {
+		e.Log.Warn("[Executor] breakeven: no stop order found", "side", side)
+		return nil
+	}
+	stopPrice, err := ParseNumber("triggerPrice", stop.TriggerPrice)
+	if err != nil {
+		return fmt.Errorf("breakeven: %w", err)
+	}
+
+	// Compare against the tick-rounded price the stop would actually get, or
+	// a moved stop rounded the wrong way would be moved again every bar.
+	targetStr, err := e.FormatPrice(ctx, breakevenStop(side, entryPrice, e.BreakevenBuffer))
+	if err != nil {
+		return fmt.Errorf("breakeven: format stop price: %v", err)
+	}
+	target, err := ParseNumber("breakeven stop", targetStr)
+	if err != nil {
+		return fmt.Errorf("breakeven: %w", err)
+	}
+	if protects(side, stopPrice, target) {
+		return nil // already moved
+	}
+	if !protects(side, currentPrice, breakevenTrigger(side, entryPrice, stopPrice, e.BreakevenR)) {
+		return nil // not far enough in profit
+	}
+	if protects(side, target, currentPrice) {
+		return nil // price sits inside the fee buffer; the stop would fire at once
+	}
+
+	resp, err := e.Client.NewCreateAlgoOrderService().
+		Symbol(e.Symbol).
+		Side(closeSide).
+		AlgoType("CONDITIONAL").
+		Type(futures.AlgoOrderTypeStopMarket).
+		Quantity(stop.Quantity).
+		ReduceOnly(true).
+		TriggerPrice(targetStr).
+		ClientAlgoId(fmt.Sprintf("B-%d", stop.AlgoId)).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("breakeven: place stop at %s (old stop kept): %w", targetStr, err)
+	}
+	e.Log.Info(fmt.Sprintf("[Executor] 🛡️ Stop moved to break-even (Algo %d): %s -> %s\n", resp.AlgoId, stop.TriggerPrice, targetStr))
+
+	if _, err := e.Client.NewCancelAlgoOrderService().AlgoID(stop.AlgoId).Do(ctx); err != nil {
+		return fmt.Errorf("breakeven: cancel old stop %d: %w", stop.AlgoId, err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakevenTrigger(t *testing.T) {
+	// 1R = 20 from entry 2000, so +0.5R is 10 into profit.
+	assert.InDelta(t, 2010, breakevenTrigger("LONG", 2000, 1980, 0.5), 1e-9)
+	assert.InDelta(t, 1990, breakevenTrigger("SHORT", 2000, 2020, 0.5), 1e-9)
+
+	assert.InDelta(t, 2002, breakevenStop("LONG", 2000, 0.001), 1e-9)
+	assert.InDelta(t, 1998, breakevenStop("SHORT", 2000, 0.001), 1e-9)
+}
+
+// breakevenRoutes serves one resting stop and records the stop placed and
+// the algo cancelled.
+func breakevenRoutes(stop map[string]any, placed *[]string, cancelled *int) http.HandlerFunc {
+	base := trailingRoutes(&[]map[string]string{})
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /fapi/v1/openAlgoOrders":
+			json.NewEncoder(w).Encode([]map[string]any{stop})
+		case "POST /fapi/v1/algoOrder":
+			r.ParseForm()
+			*placed = append(*placed, r.FormValue("triggerPrice"))
+			json.NewEncoder(w).Encode(map[string]any{"algoId": 99})
+		case "DELETE /fapi/v1/algoOrder":
+			*cancelled++
+			json.NewEncoder(w).Encode(map[string]any{})
+		default:
+			base(w, r)
+		}
+	}
+}
+
+func TestMoveStopToBreakeven(t *testing.T) {
+	tests := []struct {
+		name         string
+		side         string
+		stopSide     string
+		stopPrice    string
+		current      float64
+		wantPlaced   []string
+		wantCanceled int
+	}{
+		{"long below trigger", "LONG", "SELL", "1980", 2009, nil, 0},
+		{"long at trigger", "LONG", "SELL", "1980", 2010, []string{"2002.00"}, 1},
+		{"long already moved", "LONG", "SELL", "2002", 2050, nil, 0},
+		{"short below trigger", "SHORT", "BUY", "2020", 1991, nil, 0},
+		{"short at trigger", "SHORT", "BUY", "2020", 1990, []string{"1998.00"}, 1},
+		{"short already moved", "SHORT", "BUY", "1998", 1950, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var placed []string
+			cancelled := 0
+			e := newTestExecutor(t, breakevenRoutes(map[string]any{
+				"algoId": 7, "symbol": "ETHUSDT", "orderType": "STOP_MARKET", "side": tt.stopSide,
+				"quantity": "0.250", "triggerPrice": tt.stopPrice, "reduceOnly": true,
+			}, &placed, &cancelled))
+			e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+			e.BreakevenR = 0.5
+			e.BreakevenBuffer = 0.001
+
+			err := e.MoveStopToBreakeven(context.Background(), tt.side, 2000, tt.current)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPlaced, placed)
+			assert.Equal(t, tt.wantCanceled, cancelled)
+		})
+	}
+}
+
+func TestMoveStopToBreakeven_Disabled(t *testing.T) {
+	hits := map[string]int{}
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{}, hits))
+
+	assert.NoError(t, e.MoveStopToBreakeven(context.Background(), "LONG", 2000, 3000))
+	assert.Empty(t, hits)
+}
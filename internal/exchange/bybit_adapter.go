@@ -0,0 +1,122 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// BybitAdapter serves Bybit USDT-perpetual klines through KlineService so the
+// rest of the engine (FetchLatestCandles, prefilter, embeddings) stays venue-agnostic.
+// Klines are converted to the futures.Kline shape the parsers already accept.
+type BybitAdapter struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewBybitAdapter() *BybitAdapter {
+	return &BybitAdapter{
+		BaseURL: "https://api.bybit.com",
+		Client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type bybitKlineResponse struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		List [][]string `json:"list"` // [startMs, open, high, low, close, volume, turnover], newest first
+	} `json:"result"`
+}
+
+func (b *BybitAdapter) FetchKlines(ctx context.Context, symbol, interval string, limit int) ([]*futures.Kline, error) {
+	bybitInterval, err := toBybitInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("category", "linear")
+	q.Set("symbol", symbol)
+	q.Set("interval", bybitInterval)
+	q.Set("limit", strconv.Itoa(limit))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.BaseURL+"/v5/market/kline?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("bybit kline: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bybit kline: HTTP %d", resp.StatusCode)
+	}
+
+	var body bybitKlineResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("bybit kline decode: %w", err)
+	}
+	if body.RetCode != 0 {
+		return nil, fmt.Errorf("bybit kline: %d %s", body.RetCode, body.RetMsg)
+	}
+
+	duration, err := parseIntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bybit returns newest first; the engine expects oldest first.
+	klines := make([]*futures.Kline, 0, len(body.Result.List))
+	for i := len(body.Result.List) - 1; i >= 0; i-- {
+		row := body.Result.List[i]
+		if len(row) < 6 {
+			return nil, fmt.Errorf("bybit kline: short row %v", row)
+		}
+		openMs, err := strconv.ParseInt(row[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bybit kline start time: %w", err)
+		}
+		klines = append(klines, &futures.Kline{
+			OpenTime:  openMs,
+			Open:      row[1],
+			High:      row[2],
+			Low:       row[3],
+			Close:     row[4],
+			Volume:    row[5],
+			CloseTime: openMs + duration.Milliseconds() - 1,
+		})
+	}
+	return klines, nil
+}
+
+// toBybitInterval maps Binance-style intervals ("15m", "4h", "1d") to Bybit's.
+func toBybitInterval(interval string) (string, error) {
+	switch interval {
+	case "1m", "3m", "5m", "15m", "30m":
+		return interval[:len(interval)-1], nil
+	case "1h":
+		return "60", nil
+	case "2h":
+		return "120", nil
+	case "4h":
+		return "240", nil
+	case "6h":
+		return "360", nil
+	case "12h":
+		return "720", nil
+	case "1d":
+		return "D", nil
+	case "1w":
+		return "W", nil
+	}
+	return "", fmt.Errorf("bybit: unsupported interval %q", interval)
+}
@@ -9,8 +9,20 @@ import (
 	"github.com/adshao/go-binance/v2/futures"
 )
 
-func NewBinanceClient(ctx context.Context, cfg *config.AppConfig) (*futures.Client, error) {
+// newFuturesClient builds the REST client for cfg. With Market.Testnet set it
+// also flips the go-binance futures.UseTestnet global, which the library's
+// WebSocket helpers read on every connect, so streams follow the client.
+func newFuturesClient(cfg *config.AppConfig) *futures.Client {
+	futures.UseTestnet = cfg.Market.Testnet
 	client := futures.NewClient(cfg.Market.ApiKey, cfg.Market.ApiSecret)
+	if cfg.Market.Testnet {
+		client.BaseURL = futures.BaseApiTestnetUrl
+	}
+	return client
+}
+
+func NewBinanceClient(ctx context.Context, cfg *config.AppConfig) (*futures.Client, error) {
+	client := newFuturesClient(cfg)
 
 	serverTime, err := client.NewServerTimeService().Do(ctx)
 	if err != nil {
@@ -0,0 +1,29 @@
+package exchange
+
+import (
+	"testing"
+	"time-series-rag-agent/config"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFuturesClient_Testnet(t *testing.T) {
+	defer func(v bool) { futures.UseTestnet = v }(futures.UseTestnet)
+
+	cfg := &config.AppConfig{Market: config.BinanceMarketConfig{Testnet: true}}
+	client := newFuturesClient(cfg)
+
+	assert.True(t, futures.UseTestnet)
+	assert.Equal(t, futures.BaseApiTestnetUrl, client.BaseURL)
+}
+
+func TestNewFuturesClient_Mainnet(t *testing.T) {
+	defer func(v bool) { futures.UseTestnet = v }(futures.UseTestnet)
+	futures.UseTestnet = true // left over from an earlier client
+
+	client := newFuturesClient(&config.AppConfig{})
+
+	assert.False(t, futures.UseTestnet)
+	assert.Equal(t, futures.BaseApiMainUrl, client.BaseURL)
+}
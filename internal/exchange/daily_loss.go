@@ -0,0 +1,55 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// RealizedPnLSince sums REALIZED_PNL, FUNDING_FEE and COMMISSION income from
+// since until now, i.e. what actually hit the wallet.
+func RealizedPnLSince(ctx context.Context, client *futures.Client, since time.Time) (float64, error) {
+	incomes, err := client.NewGetIncomeHistoryService().
+		StartTime(since.UnixMilli()).
+		Limit(1000).
+		Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, income := range incomes {
+		amt, err := ParseNumber("income", income.Income)
+		if err != nil {
+			continue
+		}
+		switch income.IncomeType {
+		case "REALIZED_PNL", "FUNDING_FEE", "COMMISSION":
+			total += amt
+		}
+	}
+	return total, nil
+}
+
+// CanTrade is the daily max-loss circuit breaker: it returns false once the
+// realized PnL for the current UTC day is at or below -maxDailyLoss (USDT),
+// along with that PnL. maxDailyLoss <= 0 disables the check.
+func (e *Executor) CanTrade(ctx context.Context, maxDailyLoss float64) (bool, float64, error) {
+	if maxDailyLoss <= 0 {
+		return true, 0, nil
+	}
+	now := time.Now().UTC()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	pnl, err := RealizedPnLSince(ctx, e.Client, startOfDay)
+	if err != nil {
+		return false, 0, fmt.Errorf("daily pnl: %w", err)
+	}
+	if pnl <= -maxDailyLoss {
+		e.Log.Warn(fmt.Sprintf("[Executor] 🛑 Daily loss %.2f USDT reached limit %.2f, no new positions today", pnl, maxDailyLoss))
+		return false, pnl, nil
+	}
+	return true, pnl, nil
+}
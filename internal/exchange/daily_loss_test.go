@@ -0,0 +1,49 @@
+package exchange
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTrade_BlocksPastDailyLoss(t *testing.T) {
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{"GET /fapi/v1/income": []map[string]any{
+		{"incomeType": "REALIZED_PNL", "income": "-18.5"},
+		{"incomeType": "COMMISSION", "income": "-1.7"},
+		{"incomeType": "FUNDING_FEE", "income": "0.2"},
+		{"incomeType": "TRANSFER", "income": "500"}, // deposits don't offset losses
+	}}, map[string]int{}))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ok, pnl, err := e.CanTrade(context.Background(), 20)
+
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.InDelta(t, -20.0, pnl, 1e-9)
+}
+
+func TestCanTrade_AllowsWithinLimit(t *testing.T) {
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{"GET /fapi/v1/income": []map[string]any{
+		{"incomeType": "REALIZED_PNL", "income": "-5"},
+	}}, map[string]int{}))
+
+	ok, pnl, err := e.CanTrade(context.Background(), 20)
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.InDelta(t, -5.0, pnl, 1e-9)
+}
+
+func TestCanTrade_DisabledSkipsAPI(t *testing.T) {
+	hits := map[string]int{}
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{}, hits))
+
+	ok, _, err := e.CanTrade(context.Background(), 0)
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Zero(t, hits["GET /fapi/v1/income"])
+}
@@ -0,0 +1,108 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// PriceSource picks the reference price a new limit entry is placed at.
+type PriceSource string
+
+const (
+	PriceClose   PriceSource = "close"   // candle close (default)
+	PriceTypical PriceSource = "typical" // (H+L+C)/3, a cheap VWAP stand-in
+	PriceMid     PriceSource = "mid"     // (bid+ask)/2 from the book
+	PriceBook    PriceSource = "book"    // passive side of the book: bid for LONG, ask for SHORT
+)
+
+func ParsePriceSource(s string) (PriceSource, error) {
+	switch src := PriceSource(strings.ToLower(strings.TrimSpace(s))); src {
+	case "":
+		return PriceClose, nil
+	case PriceClose, PriceTypical, PriceMid, PriceBook:
+		return src, nil
+	default:
+		return "", fmt.Errorf("unknown entry price source %q (want close, typical, mid or book)", s)
+	}
+}
+
+// EntryType is the order type PlaceTrade opens a position with.
+type EntryType string
+
+const (
+	EntryLimit  EntryType = "limit"  // GTC limit at the entry price (default); unfilled orders are swept before the next entry
+	EntryMarket EntryType = "market" // fills immediately at the book; the entry price only sizes the order and SL/TP
+)
+
+func ParseEntryType(s string) (EntryType, error) {
+	switch t := EntryType(strings.ToLower(strings.TrimSpace(s))); t {
+	case "":
+		return EntryLimit, nil
+	case EntryLimit, EntryMarket:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown entry type %q (want limit or market)", s)
+	}
+}
+
+// NeedsBook reports whether EntryPrice needs a live BookQuote.
+func (src PriceSource) NeedsBook() bool {
+	return src == PriceMid || src == PriceBook
+}
+
+// BookQuote is the best bid/ask at decision time.
+type BookQuote struct {
+	Bid float64
+	Ask float64
+}
+
+// EntryPrice computes the entry reference price for side from the decision
+// candle and, for book-based sources, the current quote.
+func EntryPrice(src PriceSource, candle WsRestCandle, side string, quote BookQuote) (float64, error) {
+	switch src {
+	case PriceClose, "":
+		return candle.Close, nil
+	case PriceTypical:
+		return (candle.High + candle.Low + candle.Close) / 3, nil
+	}
+
+	if quote.Bid <= 0 || quote.Ask <= 0 {
+		return 0, fmt.Errorf("entry price %s: empty book quote", src)
+	}
+	switch src {
+	case PriceMid:
+		return (quote.Bid + quote.Ask) / 2, nil
+	case PriceBook:
+		if side == "SHORT" {
+			return quote.Ask, nil
+		}
+		return quote.Bid, nil
+	}
+	return 0, fmt.Errorf("unknown entry price source %q", src)
+}
+
+// FetchBookQuote reads the best bid/ask for symbol.
+func FetchBookQuote(ctx context.Context, client *futures.Client, symbol string) (BookQuote, error) {
+	tickers, err := client.NewListBookTickersService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		return BookQuote{}, fmt.Errorf("book ticker: %w", err)
+	}
+	for _, t := range tickers {
+		if t.Symbol != symbol {
+			continue
+		}
+		bid, err := ParseNumber("bidPrice", t.BidPrice)
+		if err != nil {
+			return BookQuote{}, err
+		}
+		ask, err := ParseNumber("askPrice", t.AskPrice)
+		if err != nil {
+			return BookQuote{}, err
+		}
+		return BookQuote{Bid: bid, Ask: ask}, nil
+	}
+	return BookQuote{}, fmt.Errorf("book ticker: %s not found", symbol)
+}
@@ -0,0 +1,96 @@
+package exchange
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sampleCandle = WsRestCandle{Time: 1700000000, Open: 2000, High: 2030, Low: 1990, Close: 2010}
+
+func TestEntryPrice_Close(t *testing.T) {
+	p, err := EntryPrice(PriceClose, sampleCandle, "LONG", BookQuote{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2010.0, p)
+}
+
+func TestEntryPrice_Typical(t *testing.T) {
+	p, err := EntryPrice(PriceTypical, sampleCandle, "SHORT", BookQuote{})
+	assert.NoError(t, err)
+	assert.InDelta(t, 2010.0, p, 1e-9) // (2030+1990+2010)/3
+}
+
+func TestEntryPrice_Mid(t *testing.T) {
+	p, err := EntryPrice(PriceMid, sampleCandle, "LONG", BookQuote{Bid: 2009.5, Ask: 2010.5})
+	assert.NoError(t, err)
+	assert.InDelta(t, 2010.0, p, 1e-9)
+}
+
+func TestEntryPrice_Book(t *testing.T) {
+	q := BookQuote{Bid: 2009.5, Ask: 2010.5}
+
+	long, err := EntryPrice(PriceBook, sampleCandle, "LONG", q)
+	assert.NoError(t, err)
+	assert.Equal(t, 2009.5, long)
+
+	short, err := EntryPrice(PriceBook, sampleCandle, "SHORT", q)
+	assert.NoError(t, err)
+	assert.Equal(t, 2010.5, short)
+
+	_, err = EntryPrice(PriceBook, sampleCandle, "LONG", BookQuote{})
+	assert.Error(t, err)
+}
+
+func TestParsePriceSource(t *testing.T) {
+	src, err := ParsePriceSource("")
+	assert.NoError(t, err)
+	assert.Equal(t, PriceClose, src)
+
+	src, err = ParsePriceSource(" Typical ")
+	assert.NoError(t, err)
+	assert.Equal(t, PriceTypical, src)
+	assert.False(t, src.NeedsBook())
+	assert.True(t, PriceMid.NeedsBook())
+
+	_, err = ParsePriceSource("vwap-ish")
+	assert.Error(t, err)
+}
+
+func TestFetchBookQuote(t *testing.T) {
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+		"GET /fapi/v1/ticker/bookTicker": map[string]any{"symbol": "ETHUSDT", "bidPrice": "2009.50", "askPrice": "2010.50"},
+	}, map[string]int{}))
+
+	q, err := FetchBookQuote(context.Background(), e.Client, "ETHUSDT")
+
+	assert.NoError(t, err)
+	assert.Equal(t, BookQuote{Bid: 2009.5, Ask: 2010.5}, q)
+}
+
+func TestPlaceTrade_TypicalEntryPriceIsOnTick(t *testing.T) {
+	var algos []map[string]string
+	var entryPrices []string
+	routes := trailingRoutes(&algos)
+	e := newTestExecutor(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method+" "+r.URL.Path == "POST /fapi/v1/order" {
+			r.ParseForm()
+			entryPrices = append(entryPrices, r.FormValue("price"))
+		}
+		routes(w, r)
+	})
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+	e.AviableTradeRatio, e.Leverage, e.SLPercentage, e.TPPercentage = 0.9, 5, 0.05, 0.10
+	candle := WsRestCandle{High: 2030.05, Low: 1990.02, Close: 2010.01}
+	price, err := EntryPrice(PriceTypical, candle, "LONG", BookQuote{}) // 2010.0266…
+	require.NoError(t, err)
+
+	require.NoError(t, e.PlaceTrade(context.Background(), "LONG", price, nil))
+
+	require.Len(t, entryPrices, 1)
+	assert.Equal(t, "2010.03", entryPrices[0], "rounded to the 0.01 tick")
+}
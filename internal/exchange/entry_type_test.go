@@ -0,0 +1,81 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// entryOrderRoutes wraps trailingRoutes and records the params of every
+// POST /fapi/v1/order (the entry order).
+func entryOrderRoutes(orders *[]map[string]string) http.HandlerFunc {
+	var algos []map[string]string
+	base := trailingRoutes(&algos)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method+" "+r.URL.Path != "POST /fapi/v1/order" {
+			base(w, r)
+			return
+		}
+		r.ParseForm()
+		*orders = append(*orders, map[string]string{
+			"type":        r.FormValue("type"),
+			"price":       r.FormValue("price"),
+			"timeInForce": r.FormValue("timeInForce"),
+			"quantity":    r.FormValue("quantity"),
+		})
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"orderId": 1})
+	}
+}
+
+func TestPlaceTrade_EntryType(t *testing.T) {
+	tests := []struct {
+		entryType       EntryType
+		wantType        string
+		wantPrice       string
+		wantTimeInForce string
+	}{
+		{"", "LIMIT", "2000.00", "GTC"},
+		{EntryLimit, "LIMIT", "2000.00", "GTC"},
+		{EntryMarket, "MARKET", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.entryType), func(t *testing.T) {
+			var orders []map[string]string
+			e := newTestExecutor(t, entryOrderRoutes(&orders))
+			e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+			e.AviableTradeRatio = 0.9
+			e.Leverage = 5
+			e.SLPercentage = 0.05
+			e.TPPercentage = 0.10
+			e.EntryType = tt.entryType
+
+			err := e.PlaceTrade(context.Background(), "LONG", 2000, nil)
+
+			assert.NoError(t, err)
+			assert.Len(t, orders, 1)
+			assert.Equal(t, tt.wantType, orders[0]["type"])
+			assert.Equal(t, tt.wantPrice, orders[0]["price"])
+			assert.Equal(t, tt.wantTimeInForce, orders[0]["timeInForce"])
+			assert.NotEmpty(t, orders[0]["quantity"], "both modes size the order from the entry price")
+		})
+	}
+}
+
+func TestParseEntryType(t *testing.T) {
+	got, err := ParseEntryType(" Market ")
+	assert.NoError(t, err)
+	assert.Equal(t, EntryMarket, got)
+
+	got, err = ParseEntryType("")
+	assert.NoError(t, err)
+	assert.Equal(t, EntryLimit, got)
+
+	_, err = ParseEntryType("stop")
+	assert.ErrorContains(t, err, "unknown entry type")
+}
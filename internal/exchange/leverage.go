@@ -0,0 +1,106 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LeverageTier maps a minimum LLM confidence to the leverage used for the trade.
+// Tier 1 is the entry with the highest MinConfidence.
+type LeverageTier struct {
+	MinConfidence int
+	Leverage      int
+}
+
+// ParseLeverageTiers parses "80:10,65:5" (confidence:leverage pairs) into tiers
+// sorted from highest to lowest confidence. An empty string yields no tiers.
+func ParseLeverageTiers(s string) ([]LeverageTier, error) {
+	var tiers []LeverageTier
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		conf, lev, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("leverage tier %q: want confidence:leverage", part)
+		}
+		c, err := strconv.Atoi(strings.TrimSpace(conf))
+		if err != nil {
+			return nil, fmt.Errorf("leverage tier %q: confidence: %w", part, err)
+		}
+		l, err := strconv.Atoi(strings.TrimSpace(lev))
+		if err != nil || l < 1 {
+			return nil, fmt.Errorf("leverage tier %q: leverage must be a positive integer", part)
+		}
+		tiers = append(tiers, LeverageTier{MinConfidence: c, Leverage: l})
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinConfidence > tiers[j].MinConfidence })
+	return tiers, nil
+}
+
+// SelectLeverage returns the leverage of the highest tier whose MinConfidence
+// the signal meets, or fallback when no tier matches.
+func SelectLeverage(tiers []LeverageTier, confidence, fallback int) int {
+	for _, t := range tiers {
+		if confidence >= t.MinConfidence {
+			return t.Leverage
+		}
+	}
+	return fallback
+}
+
+// maxLeverage returns the highest initial leverage Binance allows for the symbol.
+func (e *Executor) maxLeverage(ctx context.Context) (int, error) {
+	brackets, err := e.Client.NewGetLeverageBracketService().Symbol(e.Symbol).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("leverage bracket: %w", err)
+	}
+	maxLev := 0
+	for _, b := range brackets {
+		for _, br := range b.Brackets {
+			if br.InitialLeverage > maxLev {
+				maxLev = br.InitialLeverage
+			}
+		}
+	}
+	if maxLev == 0 {
+		return 0, fmt.Errorf("leverage bracket: no brackets for %s", e.Symbol)
+	}
+	return maxLev, nil
+}
+
+// ApplyLeverage clamps leverage to the symbol's account limit, sets it on
+// Binance, verifies the value Binance reports back and makes it the
+// executor's working leverage (used for quantity, SL and TP).
+func (e *Executor) ApplyLeverage(ctx context.Context, leverage int) (int, error) {
+	maxLev, err := e.maxLeverage(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if leverage > maxLev {
+		e.Log.Warn(fmt.Sprintf("[Executor] Leverage %dx exceeds account limit, clamping to %dx", leverage, maxLev))
+		leverage = maxLev
+	}
+	if leverage < 1 {
+		leverage = 1
+	}
+
+	res, err := e.Client.NewChangeLeverageService().
+		Symbol(e.Symbol).
+		Leverage(leverage).
+		Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set leverage: %v", err)
+	}
+	if res.Leverage != leverage {
+		return 0, fmt.Errorf("leverage mismatch: requested %dx, Binance applied %dx", leverage, res.Leverage)
+	}
+
+	e.Leverage = leverage
+	e.Log.Info(fmt.Sprintf("[Executor] Leverage set to %dx on Binance", leverage))
+	return leverage, nil
+}
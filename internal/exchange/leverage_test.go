@@ -0,0 +1,81 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLeverageTiers_SortsHighestFirst(t *testing.T) {
+	tiers, err := ParseLeverageTiers("65:5, 80:10")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []LeverageTier{{MinConfidence: 80, Leverage: 10}, {MinConfidence: 65, Leverage: 5}}, tiers)
+}
+
+func TestParseLeverageTiers_Invalid(t *testing.T) {
+	for _, s := range []string{"80", "x:5", "80:0"} {
+		_, err := ParseLeverageTiers(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSelectLeverage_Tier1AboveTier2(t *testing.T) {
+	tiers, _ := ParseLeverageTiers("80:10,65:5")
+
+	tier1 := SelectLeverage(tiers, 90, 3)
+	tier2 := SelectLeverage(tiers, 70, 3)
+	none := SelectLeverage(tiers, 40, 3)
+
+	assert.Greater(t, tier1, tier2)
+	assert.Equal(t, 10, tier1)
+	assert.Equal(t, 5, tier2)
+	assert.Equal(t, 3, none)
+}
+
+func TestApplyLeverage_ClampsToAccountLimit(t *testing.T) {
+	var requested string
+	e := newTestExecutor(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/fapi/v1/leverageBracket":
+			json.NewEncoder(w).Encode(map[string]any{
+				"symbol":   "ETHUSDT",
+				"brackets": []map[string]any{{"bracket": 1, "initialLeverage": 20}},
+			})
+		case "/fapi/v1/leverage":
+			requested = r.FormValue("leverage")
+			json.NewEncoder(w).Encode(map[string]any{"symbol": "ETHUSDT", "leverage": 20})
+		}
+	})
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	applied, err := e.ApplyLeverage(context.Background(), 50)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 20, applied)
+	assert.Equal(t, "20", requested)
+	assert.Equal(t, 20, e.Leverage)
+}
+
+func TestApplyLeverage_MismatchIsError(t *testing.T) {
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+		"GET /fapi/v1/leverageBracket": map[string]any{
+			"symbol":   "ETHUSDT",
+			"brackets": []map[string]any{{"bracket": 1, "initialLeverage": 50}},
+		},
+		"POST /fapi/v1/leverage": map[string]any{"symbol": "ETHUSDT", "leverage": 5},
+	}, map[string]int{}))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+	e.Leverage = 5
+
+	_, err := e.ApplyLeverage(context.Background(), 10)
+
+	assert.Error(t, err)
+	assert.Equal(t, 5, e.Leverage)
+}
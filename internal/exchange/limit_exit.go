@@ -0,0 +1,85 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// exitPollInterval is how often ExitAtLimit re-checks the resting exit order.
+var exitPollInterval = time.Second
+
+// ExitAtLimit closes the open position with a reduce-only GTC LIMIT at price.
+// Its only caller is EnforceMaxHold, with the bar close. If the order has not
+// filled within timeout it is cancelled and whatever remains is flattened at
+// market by ClosePosition. Reports whether the limit itself filled; no
+// position is a no-op.
+func (e *Executor) ExitAtLimit(ctx context.Context, price float64, timeout time.Duration) (bool, error) {
+	hasPos, side, amt, err := e.HasOpenPosition(ctx)
+	if err != nil {
+		return false, fmt.Errorf("check position: %w", err)
+	}
+	if !hasPos {
+		return false, nil
+	}
+
+	closeSide := futures.SideTypeSell
+	if side == "SHORT" {
+		closeSide = futures.SideTypeBuy
+	}
+	qty, err := e.adjustQuantity(ctx, math.Abs(amt))
+	if err != nil {
+		return false, fmt.Errorf("failed to adjust quantity: %w", err)
+	}
+	priceStr, err := e.FormatPrice(ctx, price)
+	if err != nil {
+		return false, fmt.Errorf("failed to format exit price: %w", err)
+	}
+
+	order, err := e.Client.NewCreateOrderService().
+		Symbol(e.Symbol).
+		Side(closeSide).
+		Type(futures.OrderTypeLimit).
+		TimeInForce(futures.TimeInForceTypeGTC).
+		Price(priceStr).
+		Quantity(qty).
+		ReduceOnly(true).
+		Do(ctx)
+	if err != nil {
+		return false, fmt.Errorf("limit exit order failed: %w", err)
+	}
+	e.Log.Info(fmt.Sprintf("[Executor] 🎯 Limit exit placed: %d %s %s @ %s", order.OrderID, closeSide, qty, priceStr))
+
+	deadline := time.Now().Add(timeout)
+	for {
+		o, err := e.Client.NewGetOrderService().Symbol(e.Symbol).OrderID(order.OrderID).Do(ctx)
+		if err != nil {
+			e.Log.Warn(fmt.Sprintf("[Executor] ⚠️ Limit exit status check failed: %v", err))
+		} else if o.Status == futures.OrderStatusTypeFilled {
+			e.Log.Info(fmt.Sprintf("[Executor] ✅ Limit exit filled @ %s", priceStr))
+			return true, nil
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+		select {
+		case <-time.After(exitPollInterval):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+
+	// A failed cancel usually means it filled in the meantime; ClosePosition
+	// re-reads the position, so it only sends a market order for what is left.
+	e.Log.Warn(fmt.Sprintf("[Executor] ⏱️ Limit exit not filled within %s, falling back to market", timeout))
+	if _, err := e.Client.NewCancelOrderService().Symbol(e.Symbol).OrderID(order.OrderID).Do(ctx); err != nil {
+		e.Log.Warn(fmt.Sprintf("[Executor] ⚠️ Cancel limit exit %d failed: %v", order.OrderID, err))
+	}
+	if err := e.ClosePosition(ctx); err != nil {
+		return false, fmt.Errorf("market fallback: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,105 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var limitExitInfo = map[string]any{"symbols": []map[string]any{{
+	"symbol": "ETHUSDT", "quantityPrecision": 3, "pricePrecision": 2,
+	"filters": []map[string]any{
+		{"filterType": "LOT_SIZE", "stepSize": "0.001"},
+		{"filterType": "PRICE_FILTER", "tickSize": "0.01"},
+	},
+}}}
+
+// limitExitHandler serves a LONG that goes flat after the last entry in
+// positions, and answers order status queries with status.
+func limitExitHandler(positions []string, status string, posts *[]map[string]string, cancels *int) http.HandlerFunc {
+	reads := 0
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /fapi/v2/positionRisk":
+			amt := positions[min(reads, len(positions)-1)]
+			reads++
+			json.NewEncoder(w).Encode([]map[string]any{{"symbol": "ETHUSDT", "positionAmt": amt}})
+		case "GET /fapi/v1/exchangeInfo":
+			json.NewEncoder(w).Encode(limitExitInfo)
+		case "POST /fapi/v1/order":
+			r.ParseForm()
+			*posts = append(*posts, map[string]string{
+				"type": r.FormValue("type"), "side": r.FormValue("side"), "price": r.FormValue("price"),
+				"quantity": r.FormValue("quantity"), "reduceOnly": r.FormValue("reduceOnly"),
+				"timeInForce": r.FormValue("timeInForce"),
+			})
+			json.NewEncoder(w).Encode(map[string]any{"orderId": len(*posts)})
+		case "GET /fapi/v1/order":
+			json.NewEncoder(w).Encode(map[string]any{"orderId": 1, "status": status})
+		case "DELETE /fapi/v1/order":
+			*cancels++
+			json.NewEncoder(w).Encode(map[string]any{"orderId": 1})
+		default:
+			json.NewEncoder(w).Encode(map[string]any{})
+		}
+	}
+}
+
+func TestExitAtLimit_PlacesReduceOnlyLimit(t *testing.T) {
+	var posts []map[string]string
+	cancels := 0
+	e := newTestExecutor(t, limitExitHandler([]string{"0.5"}, "FILLED", &posts, &cancels))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	filled, err := e.ExitAtLimit(context.Background(), 2100.004, time.Minute)
+
+	assert.NoError(t, err)
+	assert.True(t, filled)
+	assert.Equal(t, []map[string]string{{
+		"type": "LIMIT", "side": "SELL", "price": "2100.00", "quantity": "0.500",
+		"reduceOnly": "true", "timeInForce": "GTC",
+	}}, posts)
+	assert.Zero(t, cancels)
+}
+
+func TestExitAtLimit_TimeoutFallsBackToMarket(t *testing.T) {
+	defer func(d time.Duration) { closeVerifyDelay = d }(closeVerifyDelay)
+	closeVerifyDelay = 0
+	var posts []map[string]string
+	cancels := 0
+	// entry read, ClosePosition read, post-close verify read
+	e := newTestExecutor(t, limitExitHandler([]string{"-0.5", "-0.5", "0"}, "NEW", &posts, &cancels))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	filled, err := e.ExitAtLimit(context.Background(), 1900, 0)
+
+	assert.NoError(t, err)
+	assert.False(t, filled)
+	assert.Equal(t, 1, cancels)
+	if assert.Len(t, posts, 2) {
+		assert.Equal(t, "LIMIT", posts[0]["type"])
+		assert.Equal(t, "BUY", posts[0]["side"])
+		assert.Equal(t, "MARKET", posts[1]["type"])
+		assert.Equal(t, "true", posts[1]["reduceOnly"])
+	}
+}
+
+func TestExitAtLimit_NoPositionIsNoop(t *testing.T) {
+	var posts []map[string]string
+	cancels := 0
+	e := newTestExecutor(t, limitExitHandler([]string{"0"}, "NEW", &posts, &cancels))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	filled, err := e.ExitAtLimit(context.Background(), 1900, time.Minute)
+
+	assert.NoError(t, err)
+	assert.False(t, filled)
+	assert.Empty(t, posts)
+}
@@ -0,0 +1,79 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// PositionEntryTime returns when the open position was entered: the newest
+// filled, non-reduce-only order on the opening side (BUY for LONG, SELL for
+// SHORT). Zero time means no entry fill was found in recent history.
+func (e *Executor) PositionEntryTime(ctx context.Context, side string) (time.Time, error) {
+	openSide := futures.SideTypeBuy
+	if side == "SHORT" {
+		openSide = futures.SideTypeSell
+	}
+
+	orders, err := e.Client.NewListOrdersService().Symbol(e.Symbol).Limit(20).Do(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("list orders: %w", err)
+	}
+
+	var entry int64
+	for _, o := range orders {
+		if o.Symbol != e.Symbol || o.Status != futures.OrderStatusTypeFilled || o.ReduceOnly || o.Side != openSide {
+			continue
+		}
+		if o.UpdateTime > entry {
+			entry = o.UpdateTime
+		}
+	}
+	if entry == 0 {
+		return time.Time{}, nil
+	}
+	return time.UnixMilli(entry), nil
+}
+
+// EnforceMaxHold closes the position once it has been open longer than
+// maxHold, then clears its SL/TP. With LimitExitTimeout set the close rests
+// as a reduce-only limit at price first (see ExitAtLimit); otherwise, or with
+// price <= 0, it goes straight to market. maxHold <= 0 disables the check.
+// Reports whether a close was sent.
+func (e *Executor) EnforceMaxHold(ctx context.Context, maxHold time.Duration, now time.Time, price float64) (bool, error) {
+	if maxHold <= 0 {
+		return false, nil
+	}
+	hasPos, side, _, err := e.HasOpenPosition(ctx)
+	if err != nil || !hasPos {
+		return false, err
+	}
+
+	entry, err := e.PositionEntryTime(ctx, side)
+	if err != nil {
+		return false, err
+	}
+	if entry.IsZero() {
+		e.Log.Warn("[Executor] max hold: entry time unknown, leaving position open", "side", side)
+		return false, nil
+	}
+	held := now.Sub(entry)
+	if held < maxHold {
+		return false, nil
+	}
+
+	e.Log.Info(fmt.Sprintf("[Executor] ⏰ %s held %s (max %s), force closing", side, held.Round(time.Second), maxHold))
+	if e.LimitExitTimeout > 0 && price > 0 {
+		if _, err := e.ExitAtLimit(ctx, price, e.LimitExitTimeout); err != nil {
+			return false, fmt.Errorf("max hold limit exit: %w", err)
+		}
+	} else if err := e.ClosePosition(ctx); err != nil {
+		return false, fmt.Errorf("max hold close: %w", err)
+	}
+	if err := e.CancelAllAlgoOrders(ctx); err != nil {
+		e.Log.Warn(fmt.Sprintf("[Executor] max hold: cancel SL/TP: %v", err))
+	}
+	return true, nil
+}
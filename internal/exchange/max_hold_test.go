@@ -0,0 +1,104 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// maxHoldRoutes serves a LONG whose entry BUY filled at entryMs and goes flat
+// once a reduce-only close has been posted; posted order types go to types
+// when it is set.
+func maxHoldRoutes(entryMs int64, closes *int, types ...*[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /fapi/v2/positionRisk":
+			amt := "0.5"
+			if *closes > 0 {
+				amt = "0"
+			}
+			json.NewEncoder(w).Encode([]map[string]any{{"symbol": "ETHUSDT", "positionAmt": amt}})
+		case "GET /fapi/v1/allOrders":
+			json.NewEncoder(w).Encode([]map[string]any{
+				{"symbol": "ETHUSDT", "status": "FILLED", "side": "BUY", "reduceOnly": false, "updateTime": entryMs},
+				{"symbol": "ETHUSDT", "status": "CANCELED", "side": "BUY", "reduceOnly": false, "updateTime": entryMs + 60_000},
+				{"symbol": "ETHUSDT", "status": "FILLED", "side": "SELL", "reduceOnly": true, "updateTime": entryMs - 60_000},
+			})
+		case "POST /fapi/v1/order":
+			*closes++
+			if len(types) > 0 {
+				r.ParseForm()
+				*types[0] = append(*types[0], r.FormValue("type")+" "+r.FormValue("price"))
+			}
+			json.NewEncoder(w).Encode(map[string]any{"orderId": 9})
+		case "GET /fapi/v1/order":
+			json.NewEncoder(w).Encode(map[string]any{"orderId": 9, "status": "FILLED"})
+		case "GET /fapi/v1/exchangeInfo":
+			json.NewEncoder(w).Encode(limitExitInfo)
+		case "GET /fapi/v1/openAlgoOrders":
+			json.NewEncoder(w).Encode([]map[string]any{})
+		default:
+			json.NewEncoder(w).Encode(map[string]any{})
+		}
+	}
+}
+
+func TestEnforceMaxHold_ClosesStalePosition(t *testing.T) {
+	defer func(d time.Duration) { closeVerifyDelay = d }(closeVerifyDelay)
+	closeVerifyDelay = 0
+	entry := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	closes := 0
+	e := newTestExecutor(t, maxHoldRoutes(entry.UnixMilli(), &closes))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	closed, err := e.EnforceMaxHold(context.Background(), 4*time.Hour, entry.Add(4*time.Hour+time.Minute), 2100)
+
+	assert.NoError(t, err)
+	assert.True(t, closed)
+	assert.Equal(t, 1, closes)
+}
+
+func TestEnforceMaxHold_LimitExitFirst(t *testing.T) {
+	entry := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	closes := 0
+	var posted []string
+	e := newTestExecutor(t, maxHoldRoutes(entry.UnixMilli(), &closes, &posted))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+	e.LimitExitTimeout = time.Minute
+
+	closed, err := e.EnforceMaxHold(context.Background(), 4*time.Hour, entry.Add(5*time.Hour), 2100)
+
+	assert.NoError(t, err)
+	assert.True(t, closed)
+	assert.Equal(t, []string{"LIMIT 2100.00"}, posted, "filled at the limit, no market order")
+}
+
+func TestEnforceMaxHold_KeepsFreshPosition(t *testing.T) {
+	entry := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	closes := 0
+	e := newTestExecutor(t, maxHoldRoutes(entry.UnixMilli(), &closes))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	closed, err := e.EnforceMaxHold(context.Background(), 4*time.Hour, entry.Add(3*time.Hour), 2100)
+
+	assert.NoError(t, err)
+	assert.False(t, closed)
+	assert.Zero(t, closes)
+}
+
+func TestPositionEntryTime_UsesOpeningFill(t *testing.T) {
+	entry := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := newTestExecutor(t, maxHoldRoutes(entry.UnixMilli(), new(int)))
+
+	got, err := e.PositionEntryTime(context.Background(), "LONG")
+
+	assert.NoError(t, err)
+	assert.True(t, got.Equal(entry))
+}
@@ -26,3 +26,25 @@ type WsRestCandle struct {
 	Close  float64
 	Volume float64
 }
+
+// The three candle types share one field set, so these are plain conversions;
+// if a field is ever added to only one of them, they stop compiling instead of
+// silently dropping it (volume used to be the one left behind).
+
+// ToWs is the closed candle a trigger hands to its handler.
+func (c RestCandle) ToWs() WsCandle { return WsCandle(c) }
+
+// ToWsRest is c in the merged form the feature, label and prefilter code reads.
+func (c RestCandle) ToWsRest() WsRestCandle { return WsRestCandle(c) }
+
+// ToWsRest is c in the merged form the feature, label and prefilter code reads.
+func (c WsCandle) ToWsRest() WsRestCandle { return WsRestCandle(c) }
+
+// RestToWsRest converts a fetched history in order.
+func RestToWsRest(rest []RestCandle) []WsRestCandle {
+	out := make([]WsRestCandle, len(rest))
+	for i, c := range rest {
+		out[i] = c.ToWsRest()
+	}
+	return out
+}
@@ -0,0 +1,21 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCandleConversions_CarryEveryField(t *testing.T) {
+	rest := RestCandle{Time: 1700000000, Open: 2000.5, High: 2010.25, Low: 1995, Close: 2005.75, Volume: 1234.5}
+	want := WsRestCandle{Time: 1700000000, Open: 2000.5, High: 2010.25, Low: 1995, Close: 2005.75, Volume: 1234.5}
+
+	ws := rest.ToWs()
+	assert.Equal(t, WsCandle{Time: 1700000000, Open: 2000.5, High: 2010.25, Low: 1995, Close: 2005.75, Volume: 1234.5}, ws)
+	assert.Equal(t, want, ws.ToWsRest())
+	assert.Equal(t, want, rest.ToWsRest())
+
+	got := RestToWsRest([]RestCandle{rest, {Time: 1700000900, Volume: 7}})
+	assert.Equal(t, []WsRestCandle{want, {Time: 1700000900, Volume: 7}}, got)
+	assert.Empty(t, RestToWsRest(nil))
+}
@@ -0,0 +1,52 @@
+package exchange
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// ParseNumber parses a Binance numeric string field (prices, quantities, PnL).
+// Unlike a bare strconv.ParseFloat with the error discarded, empty, malformed,
+// NaN and infinite values are reported instead of silently becoming 0.
+func ParseNumber(field, raw string) (float64, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return 0, fmt.Errorf("%s: empty value", field)
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid number %q", field, raw)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%s: non-finite number %q", field, raw)
+	}
+	return v, nil
+}
+
+// parseKline converts one kline, rejecting it if any OHLCV field is unparseable.
+func parseKline(k *futures.Kline) (RestCandle, error) {
+	var (
+		c   = RestCandle{Time: k.OpenTime / 1000}
+		err error
+	)
+	if c.Open, err = ParseNumber("open", k.Open); err != nil {
+		return RestCandle{}, err
+	}
+	if c.High, err = ParseNumber("high", k.High); err != nil {
+		return RestCandle{}, err
+	}
+	if c.Low, err = ParseNumber("low", k.Low); err != nil {
+		return RestCandle{}, err
+	}
+	if c.Close, err = ParseNumber("close", k.Close); err != nil {
+		return RestCandle{}, err
+	}
+	if c.Volume, err = ParseNumber("volume", k.Volume); err != nil {
+		return RestCandle{}, err
+	}
+	return c, nil
+}
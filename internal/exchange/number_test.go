@@ -0,0 +1,44 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNumber_Valid(t *testing.T) {
+	v, err := ParseNumber("close", " 2315.47 ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2315.47, v)
+}
+
+func TestParseNumber_Malformed(t *testing.T) {
+	for _, raw := range []string{"", "  ", "abc", "1.2.3", "NaN", "Inf", "-Infinity", "1e999"} {
+		_, err := ParseNumber("close", raw)
+		assert.Error(t, err, "input %q", raw)
+	}
+}
+
+func TestParseKLinesToRestCandle_RejectsMalformedField(t *testing.T) {
+	klines := []*futures.Kline{
+		{OpenTime: 1700000000000, Open: "100", High: "101", Low: "99", Close: "100.5", Volume: "10"},
+		{OpenTime: 1700000900000, Open: "100.5", High: "102", Low: "100", Close: "101.5", Volume: ""},
+	}
+
+	_, err := parseKLinesToRestCandle(klines)
+
+	assert.ErrorContains(t, err, "volume")
+}
+
+func TestFetchLatestCandles_MalformedKline_ReturnsError(t *testing.T) {
+	svc := &MockKlineService{ReturnData: []*futures.Kline{
+		{OpenTime: 1700000000000, Open: "100", High: "x", Low: "99", Close: "100.5", Volume: "10"},
+	}}
+
+	_, err := FetchLatestCandles(context.Background(), svc, "ETHUSDT", "15m", 2)
+
+	assert.ErrorContains(t, err, "high")
+}
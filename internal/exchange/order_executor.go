@@ -20,8 +20,70 @@ type Executor struct {
 	SLPercentage      float64
 	TPPercentage      float64
 	Log               slog.Logger
+
+	// CloseVerifyRetries is how many extra reduce-only closes ClosePosition
+	// sends when a re-read still shows residual quantity.
+	CloseVerifyRetries int
+
+	// FiltersTTL is how long cached step/tick sizes are reused before
+	// exchange info is fetched again; 0 = defaultFiltersTTL.
+	FiltersTTL  time.Duration
+	filterCache filterCache
+
+	// TrailingStop swaps the fixed TAKE_PROFIT_MARKET for a TRAILING_STOP_MARKET
+	// that activates at the TP price and trails by CallbackRate percent
+	// (Binance accepts 0.1-10). The static STOP_MARKET is still placed.
+	// Both are algo orders, so CancelAllAlgoOrders (run before every entry
+	// and by CancelTrade) removes a live trailing stop as well.
+	TrailingStop bool
+	CallbackRate float64
+
+	// TPLevels scales out across several reduce-only TAKE_PROFIT_MARKET
+	// orders instead of one for the full quantity. Empty keeps the single TP;
+	// ignored when TrailingStop is on.
+	TPLevels []TPLevel
+
+	// FeeRate (taker fee, e.g. 0.0005) and MarginBuffer (fraction of the
+	// tradeable balance kept free, e.g. 0.01) shrink the order so margin plus
+	// the opening fee never exceed what is available. Zero = no buffer.
+	FeeRate      float64
+	MarginBuffer float64
+
+	// EntryType selects a GTC limit (default) or a market entry order.
+	EntryType EntryType
+
+	// Sizing picks balance*ratio*leverage (default) or fixed-fractional risk
+	// sizing, which loses RiskPct (a fraction, e.g. 0.01) of equity at the SL.
+	Sizing  SizingMode
+	RiskPct float64
+
+	// BreakevenR moves the stop to entry once price is this many multiples
+	// of the initial risk in profit (0 = off); BreakevenBuffer is the
+	// fraction of entry added past it to cover fees.
+	BreakevenR      float64
+	BreakevenBuffer float64
+
+	// StopMode picks the percentage stop (default) or one ATRMultiplier
+	// times ATR(ATRPeriod) from entry, computed from the candles passed to
+	// PlaceTrade. ATRPeriod <= 0 = 14.
+	StopMode      StopMode
+	ATRPeriod     int
+	ATRMultiplier float64
+
+	// LimitExitTimeout makes EnforceMaxHold exit with ExitAtLimit at the
+	// given price, falling back to market after this long (0 = market).
+	LimitExitTimeout time.Duration
 }
 
+// Binance futures callbackRate bounds, in percent.
+const (
+	minCallbackRate = 0.1
+	maxCallbackRate = 10.0
+)
+
+// closeVerifyDelay gives the matching engine time to settle before re-reading.
+var closeVerifyDelay = 500 * time.Millisecond
+
 func NewExecutor(
 	Client *futures.Client,
 	Symbol string,
@@ -52,7 +114,10 @@ func (e *Executor) HasOpenPosition(ctx context.Context) (bool, string, float64,
 
 	for _, p := range positions {
 		if p.Symbol == e.Symbol {
-			amt, _ := strconv.ParseFloat(p.PositionAmt, 64)
+			amt, err := ParseNumber("positionAmt", p.PositionAmt)
+			if err != nil {
+				return false, "", 0, err
+			}
 
 			if amt > 0 {
 				return true, "LONG", amt, nil
@@ -105,8 +170,10 @@ func (e *Executor) CancelTrade(ctx context.Context) error {
 	return nil
 }
 
-// PlaceTrade executes the Main Order (Standard) + SL/TP (Algo)
-func (e *Executor) PlaceTrade(ctx context.Context, side string, priceToPlace float64) error {
+// PlaceTrade executes the Main Order (Standard) + SL/TP (Algo). candles is
+// the recent window, oldest first, that an ATR stop is computed from; the
+// percentage stop ignores it.
+func (e *Executor) PlaceTrade(ctx context.Context, side string, priceToPlace float64, candles []WsRestCandle) error {
 	// Deterministic client IDs scoped to the current 15-minute bar.
 	// Same ID on retry → Binance rejects the duplicate instead of filling twice.
 	barOpen := time.Now().UTC().Truncate(15 * time.Minute)
@@ -114,6 +181,11 @@ func (e *Executor) PlaceTrade(ctx context.Context, side string, priceToPlace flo
 	slClientID := fmt.Sprintf("S-%d-%s", barOpen.Unix(), side)
 	tpClientID := fmt.Sprintf("T-%d-%s", barOpen.Unix(), side)
 
+	if err := e.EnsureTrading(ctx); err != nil {
+		e.Log.Error(fmt.Sprintf("[Executor] ❌ %v, skipping trade", err))
+		return err
+	}
+
 	e.Log.Info(fmt.Sprintln("[Executor] 🧹 Cleaning up open orders..."))
 	if err := e.CancelAllOpenOrders(ctx); err != nil {
 		e.Log.Info(fmt.Sprintf("[Executor] Warning: %v\n", err))
@@ -122,8 +194,15 @@ func (e *Executor) PlaceTrade(ctx context.Context, side string, priceToPlace flo
 		e.Log.Info(fmt.Sprintf("[Executor] Warning: %v\n", err))
 	}
 
-	e.Log.Info(fmt.Sprintf("[Executor] Calculate SL with leverage: %d | percentage: %f | price: %f", e.Leverage, e.SLPercentage, priceToPlace))
-	slPrice := e.CalculateSL(priceToPlace, side)
+	e.Log.Info(fmt.Sprintf("[Executor] Calculate SL (%s) with leverage: %d | percentage: %f | price: %f", e.StopMode, e.Leverage, e.SLPercentage, priceToPlace))
+	slPrice, atr := e.StopLoss(priceToPlace, side, candles)
+	if e.StopMode == StopATR {
+		if atr == 0 {
+			e.Log.Warn("[Executor] ATR stop unavailable, using percentage stop", "candles", len(candles), "period", e.ATRPeriod, "multiplier", e.ATRMultiplier)
+		} else {
+			e.Log.Info(fmt.Sprintf("[Executor] ATR %.4f x %.2f from entry", atr, e.ATRMultiplier))
+		}
+	}
 	tpPrice := e.CalculateTP(priceToPlace, side)
 	e.Log.Info(fmt.Sprintf("[Executor] Calculated SL price: %f", slPrice))
 
@@ -131,8 +210,11 @@ func (e *Executor) PlaceTrade(ctx context.Context, side string, priceToPlace flo
 		return fmt.Errorf("balance release timeout, skipping bar: %w", err)
 	}
 
-	quantity, err := e.CalculateQuantity(ctx, priceToPlace)
+	// Abort before any order is sent: without a fresh quantity there is
+	// nothing safe to submit.
+	quantity, err := e.sizeQuantity(ctx, priceToPlace, slPrice)
 	if err != nil {
+		e.Log.Error(fmt.Sprintf("[Executor] ❌ Quantity calculation failed, aborting trade: %v", err))
 		return fmt.Errorf("failed to calculate quantity: %w", err)
 	}
 
@@ -148,6 +230,13 @@ func (e *Executor) PlaceTrade(ctx context.Context, side string, priceToPlace flo
 		return fmt.Errorf("failed to format TP price: %v", err)
 	}
 
+	var callbackRateStr string
+	if e.TrailingStop {
+		if callbackRateStr, err = e.formatCallbackRate(); err != nil {
+			return err
+		}
+	}
+
 	// 1. Determine Sides
 	var mainSide, closeSide futures.SideType
 	if side == "LONG" {
@@ -161,21 +250,34 @@ func (e *Executor) PlaceTrade(ctx context.Context, side string, priceToPlace flo
 	// -------------------------------------------------------------
 	// 2. MAIN ENTRY (Standard Order API)
 	// -------------------------------------------------------------
-	priceToPlaceStr := strconv.FormatFloat(priceToPlace, 'f', -1, 64)
-	mainOrder, err := e.Client.NewCreateOrderService().
+	// Typical and mid entry prices fall between ticks; Binance rejects those.
+	priceToPlaceStr, err := e.FormatPrice(ctx, priceToPlace)
+	if err != nil {
+		return fmt.Errorf("failed to format entry price: %w", err)
+	}
+	market := e.EntryType == EntryMarket
+	entry := e.Client.NewCreateOrderService().
 		Symbol(e.Symbol).
 		Side(mainSide).
-		Type(futures.OrderTypeLimit).
-		TimeInForce(futures.TimeInForceTypeGTC).
-		Price(priceToPlaceStr).
 		Quantity(quantity).
-		NewClientOrderID(mainClientID).
-		Do(ctx)
+		NewClientOrderID(mainClientID)
+	if market {
+		entry = entry.Type(futures.OrderTypeMarket)
+	} else {
+		entry = entry.Type(futures.OrderTypeLimit).
+			TimeInForce(futures.TimeInForceTypeGTC).
+			Price(priceToPlaceStr)
+	}
+	mainOrder, err := entry.Do(ctx)
 
 	if err != nil {
-		return fmt.Errorf("limit order failed: %v", err)
+		return fmt.Errorf("%s entry order failed: %v", e.entryType(), err)
+	}
+	if market {
+		e.Log.Info(fmt.Sprintf("[Executor] ✅ Market Order Placed: %d (clientID: %s) ~ %s\n", mainOrder.OrderID, mainClientID, priceToPlaceStr))
+	} else {
+		e.Log.Info(fmt.Sprintf("[Executor] ✅ Limit Order Placed: %d (clientID: %s) @ %s\n", mainOrder.OrderID, mainClientID, priceToPlaceStr))
 	}
-	e.Log.Info(fmt.Sprintf("[Executor] ✅ Limit Order Placed: %d (clientID: %s) @ %s\n", mainOrder.OrderID, mainClientID, priceToPlaceStr))
 
 	// -------------------------------------------------------------
 	// 3. STOP LOSS (Algo Order API)
@@ -193,6 +295,14 @@ func (e *Executor) PlaceTrade(ctx context.Context, side string, priceToPlace flo
 		Do(ctx)
 
 	if err != nil {
+		if market {
+			// A market entry has already filled: flatten instead of cancelling.
+			e.Log.Error(fmt.Sprintf("[Executor] CRITICAL: Stop Loss Failed — closing market entry %d: %v\n", mainOrder.OrderID, err))
+			if closeErr := e.ClosePosition(ctx); closeErr != nil {
+				e.Log.Error(fmt.Sprintf("[Executor] CRITICAL: Failed to close position after SL failure: %v\n", closeErr))
+			}
+			return fmt.Errorf("stop loss placement failed (position closed): %w", err)
+		}
 		e.Log.Error(fmt.Sprintf("[Executor] CRITICAL: Stop Loss Failed — cancelling main order %d: %v\n", mainOrder.OrderID, err))
 		if _, cancelErr := e.Client.NewCancelOrderService().Symbol(e.Symbol).OrderID(mainOrder.OrderID).Do(ctx); cancelErr != nil {
 			e.Log.Error(fmt.Sprintf("[Executor] CRITICAL: Failed to cancel main order after SL failure: %v\n", cancelErr))
@@ -205,6 +315,35 @@ func (e *Executor) PlaceTrade(ctx context.Context, side string, priceToPlace flo
 	// 4. TAKE PROFIT (Algo Order API)
 	// SL is already armed; TP failure is non-fatal but logged at Error.
 	// -------------------------------------------------------------
+	if e.TrailingStop {
+		// Activates at the TP price, then trails instead of closing there.
+		_, err = e.Client.NewCreateAlgoOrderService().
+			Symbol(e.Symbol).
+			Side(closeSide).
+			AlgoType("CONDITIONAL").
+			Type(futures.AlgoOrderTypeTrailingStopMarket).
+			Quantity(quantity).
+			ReduceOnly(true).
+			ActivatePrice(tpPriceStr).
+			CallbackRate(callbackRateStr).
+			ClientAlgoId(tpClientID).
+			Do(ctx)
+
+		if err != nil {
+			e.Log.Error(fmt.Sprintf("[Executor] ⚠️ Trailing Stop Failed (SL is armed): %v\n", err))
+		} else {
+			e.Log.Info(fmt.Sprintf("[Executor] 🪝 Trailing Stop Set (Algo): activate %s | callback %s%%\n", tpPriceStr, callbackRateStr))
+		}
+		return nil
+	}
+
+	if len(e.TPLevels) > 0 {
+		if err := e.placeScaledTP(ctx, side, priceToPlace, quantity, closeSide, tpClientID); err != nil {
+			e.Log.Error(fmt.Sprintf("[Executor] ⚠️ Scaled Take Profit Failed (SL is armed): %v\n", err))
+		}
+		return nil
+	}
+
 	_, err = e.Client.NewCreateAlgoOrderService().
 		Symbol(e.Symbol).
 		Side(closeSide).
@@ -275,6 +414,131 @@ func (e *Executor) CancelAllAlgoOrders(ctx context.Context) error {
 	return nil
 }
 
+// ClosePosition flattens the position with a reduce-only MARKET order, then
+// re-reads it and retries up to CloseVerifyRetries times if a partial fill
+// left residual exposure.
+func (e *Executor) ClosePosition(ctx context.Context) error {
+	for attempt := 0; attempt <= e.CloseVerifyRetries; attempt++ {
+		hasPos, side, amt, err := e.HasOpenPosition(ctx)
+		if err != nil {
+			return fmt.Errorf("check position: %w", err)
+		}
+		if !hasPos {
+			return nil
+		}
+		if attempt > 0 {
+			e.Log.Warn(fmt.Sprintf("[Executor] ⚠️ Residual %s %.6f after close, retry %d/%d", side, amt, attempt, e.CloseVerifyRetries))
+		}
+
+		closeSide := futures.SideTypeSell
+		if side == "SHORT" {
+			closeSide = futures.SideTypeBuy
+		}
+		qty, err := e.adjustQuantity(ctx, math.Abs(amt))
+		if err != nil {
+			return fmt.Errorf("failed to adjust quantity: %w", err)
+		}
+
+		if _, err := e.Client.NewCreateOrderService().
+			Symbol(e.Symbol).
+			Side(closeSide).
+			Type(futures.OrderTypeMarket).
+			Quantity(qty).
+			ReduceOnly(true).
+			Do(ctx); err != nil {
+			return fmt.Errorf("close order failed: %w", err)
+		}
+		e.Log.Info(fmt.Sprintf("[Executor] 🔒 Close order sent: %s %s", closeSide, qty))
+
+		select {
+		case <-time.After(closeVerifyDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	hasPos, side, amt, err := e.HasOpenPosition(ctx)
+	if err != nil {
+		return fmt.Errorf("check position: %w", err)
+	}
+	if hasPos {
+		return fmt.Errorf("position still open after %d close attempts: %s %.6f", e.CloseVerifyRetries+1, side, amt)
+	}
+	return nil
+}
+
+// CancelOrphanedReduceOnlyOrders cancels reduce-only orders (standard and algo)
+// left behind when there is no open position, e.g. a TP still resting after the
+// SL closed the trade. Returns the number of orders cancelled.
+func (e *Executor) CancelOrphanedReduceOnlyOrders(ctx context.Context) (int, error) {
+	hasPos, _, _, err := e.HasOpenPosition(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("check position: %w", err)
+	}
+	if hasPos {
+		return 0, nil // SL/TP still protect a live position
+	}
+
+	cancelled := 0
+
+	orders, err := e.Client.NewListOpenOrdersService().Symbol(e.Symbol).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list open orders: %w", err)
+	}
+	for _, o := range orders {
+		if !o.ReduceOnly && !o.ClosePosition {
+			continue
+		}
+		if _, err := e.Client.NewCancelOrderService().Symbol(e.Symbol).OrderID(o.OrderID).Do(ctx); err != nil {
+			e.Log.Warn(fmt.Sprintf("[Executor] ⚠️ Failed to cancel orphaned order %d: %v", o.OrderID, err))
+			continue
+		}
+		e.Log.Info(fmt.Sprintf("[Executor] 🗑️ Cancelled orphaned reduce-only order %d", o.OrderID))
+		cancelled++
+	}
+
+	algos, err := e.Client.NewListOpenAlgoOrdersService().Symbol(e.Symbol).Do(ctx)
+	if err != nil {
+		return cancelled, fmt.Errorf("list open algo orders: %w", err)
+	}
+	for _, a := range algos {
+		if !a.ReduceOnly && !a.ClosePosition {
+			continue
+		}
+		if _, err := e.Client.NewCancelAlgoOrderService().AlgoID(a.AlgoId).Do(ctx); err != nil {
+			e.Log.Warn(fmt.Sprintf("[Executor] ⚠️ Failed to cancel orphaned algo %d: %v", a.AlgoId, err))
+			continue
+		}
+		e.Log.Info(fmt.Sprintf("[Executor] 🗑️ Cancelled orphaned reduce-only algo %d", a.AlgoId))
+		cancelled++
+	}
+
+	return cancelled, nil
+}
+
+// Reconcile is the startup sweep: with no open position, every resting order
+// on the symbol, standard or algo, is stale (a TP's twin SL, an unfilled entry
+// from before a restart) and could fire against the next position, so all of
+// them are cancelled. With a position open nothing is touched. Reports
+// whether a sweep ran.
+func (e *Executor) Reconcile(ctx context.Context) (bool, error) {
+	hasPos, side, _, err := e.HasOpenPosition(ctx)
+	if err != nil {
+		return false, fmt.Errorf("reconcile %s: check position: %w", e.Symbol, err)
+	}
+	if hasPos {
+		e.Log.Info(fmt.Sprintf("[Executor] Reconcile %s: %s position open, keeping its orders", e.Symbol, side))
+		return false, nil
+	}
+	if err := e.CancelAllOpenOrders(ctx); err != nil {
+		return false, fmt.Errorf("reconcile %s: %w", e.Symbol, err)
+	}
+	if err := e.CancelAllAlgoOrders(ctx); err != nil {
+		return false, fmt.Errorf("reconcile %s: %w", e.Symbol, err)
+	}
+	return true, nil
+}
+
 func (e *Executor) CalculateQuantity(ctx context.Context, currentPrice float64) (string, error) {
 	// 1. Get Available USDT in Port
 	// We use a helper function to loop through assets and find "USDT"
@@ -284,9 +548,9 @@ func (e *Executor) CalculateQuantity(ctx context.Context, currentPrice float64)
 	}
 
 	// 2. Calculate Buying Power (USDT to Trade)
-	// Formula: Balance * Ratio * Leverage
+	// Formula: Balance * Ratio * Leverage, less fee/margin buffer
 	// Example: 100 USDT * 0.90 * 5 = 450 USDT
-	usdtToTrade := aviableUsdtInPort * e.AviableTradeRatio * float64(e.Leverage)
+	usdtToTrade := maxNotional(aviableUsdtInPort, e.AviableTradeRatio, e.Leverage, e.FeeRate, e.MarginBuffer)
 
 	// 3. Calculate Raw Quantity
 	// Example: 450 USDT / 2000 Price = 0.225
@@ -343,6 +607,34 @@ func (e *Executor) CalculateTP(price float64, side string) float64 {
 	return 0.0
 }
 
+// maxNotional is the largest position value whose initial margin plus opening
+// fee fits inside balance*ratio after reserving the buffer:
+//
+//	notional/leverage + notional*feeRate <= balance*ratio*(1-buffer)
+func maxNotional(balance, ratio float64, leverage int, feeRate, buffer float64) float64 {
+	usable := balance * ratio * (1 - buffer)
+	if usable <= 0 || leverage <= 0 {
+		return 0
+	}
+	return usable / (1/float64(leverage) + feeRate)
+}
+
+// entryType is EntryType with the empty default resolved.
+func (e *Executor) entryType() EntryType {
+	if e.EntryType == "" {
+		return EntryLimit
+	}
+	return e.EntryType
+}
+
+// formatCallbackRate validates CallbackRate against Binance's accepted range.
+func (e *Executor) formatCallbackRate() (string, error) {
+	if e.CallbackRate < minCallbackRate || e.CallbackRate > maxCallbackRate {
+		return "", fmt.Errorf("trailing stop callback rate %.2f%% outside %.1f-%.1f%%", e.CallbackRate, minCallbackRate, maxCallbackRate)
+	}
+	return strconv.FormatFloat(e.CallbackRate, 'f', 1, 64), nil
+}
+
 // Helper functions
 func (e *Executor) getUSDTAvailableBalance(ctx context.Context) (float64, error) {
 	balances, err := e.Client.NewGetBalanceService().Do(ctx)
@@ -352,33 +644,18 @@ func (e *Executor) getUSDTAvailableBalance(ctx context.Context) (float64, error)
 	for _, b := range balances {
 		if b.Asset == "USDT" {
 			// "AvailableBalance" is the field for tradeable funds
-			return strconv.ParseFloat(b.AvailableBalance, 64)
+			return ParseNumber("availableBalance", b.AvailableBalance)
 		}
 	}
 	return 0, fmt.Errorf("USDT wallet not found")
 }
 
 func (e *Executor) adjustQuantity(ctx context.Context, rawQty float64) (string, error) {
-	info, err := e.Client.NewExchangeInfoService().Do(ctx)
+	f, err := e.symbolFilters(ctx)
 	if err != nil {
 		return "", err
 	}
-
-	var stepSize float64 = 0.001 // Default Fallback
-	var precision int = 3        // Default Fallback
-
-	// Find our specific symbol's rules
-	for _, s := range info.Symbols {
-		if s.Symbol == e.Symbol {
-			precision = s.QuantityPrecision
-			for _, f := range s.Filters {
-				if f["filterType"] == "LOT_SIZE" {
-					stepSize, _ = strconv.ParseFloat(f["stepSize"].(string), 64)
-				}
-			}
-			break
-		}
-	}
+	stepSize, precision := f.StepSize, f.QuantityPrecision
 
 	// Math: Round down to nearest step (e.g. 10.5678 -> 10.5 if step is 0.1)
 	qty := math.Floor(rawQty/stepSize) * stepSize
@@ -419,33 +696,18 @@ func (e *Executor) WaitForBalanceRelease(ctx context.Context, minExpectedBalance
 
 // FormatPrice adjusts a float price to the symbol's specific Tick Size
 func (e *Executor) FormatPrice(ctx context.Context, price float64) (string, error) {
-	// 1. Fetch Exchange Info (Cached in a real app, but fetched here for safety)
-	info, err := e.Client.NewExchangeInfoService().Do(ctx)
+	// 1. Symbol rules come from the executor's exchange info cache
+	f, err := e.symbolFilters(ctx)
 	if err != nil {
 		return "", err
 	}
+	tickSize, precision := f.TickSize, f.PricePrecision
 
-	var tickSize float64 = 0.01 // Default fallback
-	var precision int = 2       // Default fallback
-
-	// 2. Find the Symbol & PRICE_FILTER
-	for _, s := range info.Symbols {
-		if s.Symbol == e.Symbol {
-			precision = s.PricePrecision
-			for _, f := range s.Filters {
-				if f["filterType"] == "PRICE_FILTER" {
-					tickSize, _ = strconv.ParseFloat(f["tickSize"].(string), 64)
-				}
-			}
-			break
-		}
-	}
-
-	// 3. Math: Round to nearest Tick Size
+	// 2. Math: Round to nearest Tick Size
 	// e.g. Price 3000.1234, Tick 0.1 -> 3000.1
 	roundedPrice := math.Round(price/tickSize) * tickSize
 
-	// 4. Format string with correct decimal places
+	// 3. Format string with correct decimal places
 	// If TickSize is 1.00 (0 decimals), this ensures we don't send "3000.0" if API wants "3000"
 	// However, usually PricePrecision covers the decimal count.
 	format := "%." + strconv.Itoa(precision) + "f"
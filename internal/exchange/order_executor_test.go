@@ -0,0 +1,215 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockOrderRoutes serves canned JSON per "METHOD path" and records every hit.
+func mockOrderRoutes(routes map[string]any, hits map[string]int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		hits[key]++
+		w.Header().Set("Content-Type", "application/json")
+		body, ok := routes[key]
+		if !ok {
+			body = map[string]any{}
+		}
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+func TestCancelOrphanedReduceOnlyOrders_NoPosition_CancelsStray(t *testing.T) {
+	hits := map[string]int{}
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+		"GET /fapi/v2/positionRisk": []map[string]any{
+			{"symbol": "ETHUSDT", "positionAmt": "0"},
+		},
+		"GET /fapi/v1/openOrders": []map[string]any{
+			{"symbol": "ETHUSDT", "orderId": 11, "type": "LIMIT", "reduceOnly": false},
+			{"symbol": "ETHUSDT", "orderId": 12, "type": "TAKE_PROFIT_MARKET", "reduceOnly": true},
+		},
+		"GET /fapi/v1/openAlgoOrders": []map[string]any{
+			{"algoId": 21, "symbol": "ETHUSDT", "orderType": "STOP_MARKET", "reduceOnly": true},
+		},
+	}, hits))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	n, err := e.CancelOrphanedReduceOnlyOrders(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 1, hits["DELETE /fapi/v1/order"])
+	assert.Equal(t, 1, hits["DELETE /fapi/v1/algoOrder"])
+}
+
+func TestCancelOrphanedReduceOnlyOrders_InPosition_KeepsOrders(t *testing.T) {
+	hits := map[string]int{}
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+		"GET /fapi/v2/positionRisk": []map[string]any{
+			{"symbol": "ETHUSDT", "positionAmt": "0.5"},
+		},
+	}, hits))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	n, err := e.CancelOrphanedReduceOnlyOrders(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Zero(t, hits["GET /fapi/v1/openOrders"])
+	assert.Zero(t, hits["DELETE /fapi/v1/order"])
+}
+
+func TestReconcile_NoPosition_CancelsDanglingOrders(t *testing.T) {
+	hits := map[string]int{}
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+		"GET /fapi/v2/positionRisk": []map[string]any{
+			{"symbol": "ETHUSDT", "positionAmt": "0"},
+		},
+		"GET /fapi/v1/openOrders": []map[string]any{
+			{"symbol": "ETHUSDT", "orderId": 11, "type": "LIMIT", "reduceOnly": false},
+		},
+		"GET /fapi/v1/openAlgoOrders": []map[string]any{
+			{"algoId": 21, "symbol": "ETHUSDT", "orderType": "STOP_MARKET", "reduceOnly": true},
+			{"algoId": 22, "symbol": "ETHUSDT", "orderType": "TAKE_PROFIT_MARKET", "reduceOnly": true},
+		},
+	}, hits))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	swept, err := e.Reconcile(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, swept)
+	assert.Equal(t, 1, hits["DELETE /fapi/v1/allOpenOrders"])
+	assert.Equal(t, 2, hits["DELETE /fapi/v1/algoOrder"])
+}
+
+func TestReconcile_InPosition_KeepsOrders(t *testing.T) {
+	hits := map[string]int{}
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+		"GET /fapi/v2/positionRisk": []map[string]any{
+			{"symbol": "ETHUSDT", "positionAmt": "-0.5"},
+		},
+	}, hits))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	swept, err := e.Reconcile(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, swept)
+	assert.Zero(t, hits["DELETE /fapi/v1/allOpenOrders"])
+	assert.Zero(t, hits["GET /fapi/v1/openAlgoOrders"])
+}
+
+func TestClosePosition_RetriesResidual(t *testing.T) {
+	defer func(d time.Duration) { closeVerifyDelay = d }(closeVerifyDelay)
+	closeVerifyDelay = 0
+	positions := []string{"0.5", "0.1", "0"} // full → residual after 1st close → flat
+	reads, closes := 0, 0
+	e := newTestExecutor(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /fapi/v2/positionRisk":
+			amt := positions[min(reads, len(positions)-1)]
+			reads++
+			json.NewEncoder(w).Encode([]map[string]any{{"symbol": "ETHUSDT", "positionAmt": amt}})
+		case "POST /fapi/v1/order":
+			closes++
+			assert.Equal(t, "true", r.FormValue("reduceOnly"))
+			assert.Equal(t, "SELL", r.FormValue("side"))
+			json.NewEncoder(w).Encode(map[string]any{"orderId": closes})
+		default:
+			json.NewEncoder(w).Encode(map[string]any{})
+		}
+	})
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+	e.CloseVerifyRetries = 2
+
+	err := e.ClosePosition(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, closes)
+}
+
+func TestClosePosition_GivesUpAfterRetries(t *testing.T) {
+	defer func(d time.Duration) { closeVerifyDelay = d }(closeVerifyDelay)
+	closeVerifyDelay = 0
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+		"GET /fapi/v2/positionRisk": []map[string]any{{"symbol": "ETHUSDT", "positionAmt": "-0.2"}},
+		"POST /fapi/v1/order":       map[string]any{"orderId": 1},
+	}, map[string]int{}))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+	e.CloseVerifyRetries = 1
+
+	err := e.ClosePosition(context.Background())
+
+	assert.Error(t, err)
+}
+
+func TestPlaceTrade_QuantityErrorSubmitsNoOrder(t *testing.T) {
+	balanceCalls := 0
+	hits := map[string]int{}
+	e := newTestExecutor(t, func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		hits[key]++
+		w.Header().Set("Content-Type", "application/json")
+		if key == "GET /fapi/v3/balance" {
+			balanceCalls++
+			if balanceCalls > 1 { // WaitForBalanceRelease passes, CalculateQuantity fails
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]any{"code": -1001, "msg": "internal error"})
+				return
+			}
+			json.NewEncoder(w).Encode([]map[string]any{{"asset": "USDT", "availableBalance": "100"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{})
+	})
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+	e.AviableTradeRatio = 1
+	e.Leverage = 5
+
+	err := e.PlaceTrade(context.Background(), "LONG", 2000, nil)
+
+	assert.ErrorContains(t, err, "failed to calculate quantity")
+	assert.Zero(t, hits["POST /fapi/v1/order"])
+	assert.Zero(t, hits["POST /fapi/v1/algoOrder"])
+}
+
+func TestMaxNotional_LeavesBuffer(t *testing.T) {
+	const balance, ratio, lev, fee, buffer = 100.0, 0.9, 5, 0.0005, 0.02
+
+	notional := maxNotional(balance, ratio, lev, fee, buffer)
+
+	used := notional/lev + notional*fee
+	assert.InDelta(t, balance*ratio*buffer, balance*ratio-used, 1e-9)
+	assert.Less(t, notional, balance*ratio*lev)
+	assert.Equal(t, balance*ratio*lev, maxNotional(balance, ratio, lev, 0, 0), "zero fee/buffer keeps the old sizing")
+}
+
+func TestCalculateQuantity_AppliesFeeBuffer(t *testing.T) {
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+		"GET /fapi/v3/balance": []map[string]any{{"asset": "USDT", "availableBalance": "100"}},
+		"GET /fapi/v1/exchangeInfo": map[string]any{"symbols": []map[string]any{{
+			"symbol": "ETHUSDT", "quantityPrecision": 3,
+			"filters": []map[string]any{{"filterType": "LOT_SIZE", "stepSize": "0.001"}},
+		}}},
+	}, map[string]int{}))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+	e.AviableTradeRatio = 1
+	e.Leverage = 5
+	e.FeeRate = 0.0005
+	e.MarginBuffer = 0.02
+
+	qty, err := e.CalculateQuantity(context.Background(), 2000)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0.244", qty) // 98 / (0.2 + 0.0005) = 488.78 USDT → 0.2443 ETH
+}
@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SizingMode is how PlaceTrade turns the account balance into a quantity.
+type SizingMode string
+
+const (
+	SizingBalance SizingMode = "balance" // balance * ratio * leverage / price (default)
+	SizingRisk    SizingMode = "risk"    // lose RiskPct of equity if the stop fills
+)
+
+func ParseSizingMode(s string) (SizingMode, error) {
+	switch m := SizingMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return SizingBalance, nil
+	case SizingBalance, SizingRisk:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown sizing mode %q (want balance or risk)", s)
+	}
+}
+
+// riskQuantity is fixed-fractional sizing: the quantity whose loss from entry
+// to stop is riskPct of equity. The notional is capped at maxNotional, so a
+// tight stop cannot ask for more than the margin allows.
+func riskQuantity(equity, riskPct, entry, stop, maxNotional float64) (float64, error) {
+	dist := math.Abs(entry - stop)
+	if dist == 0 || entry <= 0 {
+		return 0, fmt.Errorf("risk sizing needs a stop away from entry (entry %.8f, stop %.8f)", entry, stop)
+	}
+	if riskPct <= 0 {
+		return 0, fmt.Errorf("risk sizing needs a positive risk percentage, got %f", riskPct)
+	}
+	qty := equity * riskPct / dist
+	return min(qty, maxNotional/entry), nil
+}
+
+// CalculateRiskQuantity sizes by RiskPct of the available USDT, which is the
+// whole equity here since PlaceTrade only enters from flat, and rounds to the
+// symbol's step size.
+func (e *Executor) CalculateRiskQuantity(ctx context.Context, entry, stop float64) (string, error) {
+	equity, err := e.getUSDTAvailableBalance(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch balance: %v", err)
+	}
+	capNotional := maxNotional(equity, e.AviableTradeRatio, e.Leverage, e.FeeRate, e.MarginBuffer)
+	rawQty, err := riskQuantity(equity, e.RiskPct, entry, stop, capNotional)
+	if err != nil {
+		return "", err
+	}
+	qtyString, err := e.adjustQuantity(ctx, rawQty)
+	if err != nil {
+		return "", fmt.Errorf("failed to adjust quantity: %v", err)
+	}
+	e.Log.Info(fmt.Sprintf("[Executor] risk sizing: equity %.2f | risk %.2f%% | stop distance %.8f | qty %s", equity, e.RiskPct*100, math.Abs(entry-stop), qtyString))
+	return qtyString, nil
+}
+
+// sizeQuantity dispatches on Sizing.
+func (e *Executor) sizeQuantity(ctx context.Context, entry, stop float64) (string, error) {
+	if e.Sizing == SizingRisk {
+		return e.CalculateRiskQuantity(ctx, entry, stop)
+	}
+	return e.CalculateQuantity(ctx, entry)
+}
@@ -0,0 +1,98 @@
+package exchange
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRiskQuantity(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry, stop float64
+		maxNotional float64
+		want        float64
+	}{
+		{"long stop below", 2000, 1980, math.Inf(1), 0.5},   // 1000 * 1% / 20
+		{"short stop above", 2000, 2040, math.Inf(1), 0.25}, // 1000 * 1% / 40
+		{"tight stop capped", 2000, 1999, 500, 0.25},        // 10 / 1 = 10 ETH, capped at 500 USDT
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := riskQuantity(1000, 0.01, tt.entry, tt.stop, tt.maxNotional)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+
+	_, err := riskQuantity(1000, 0.01, 2000, 2000, math.Inf(1))
+	assert.Error(t, err, "stop at entry")
+	_, err = riskQuantity(1000, 0, 2000, 1980, math.Inf(1))
+	assert.Error(t, err, "zero risk")
+}
+
+func TestParseSizingMode(t *testing.T) {
+	m, err := ParseSizingMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, SizingBalance, m)
+	m, err = ParseSizingMode(" Risk ")
+	assert.NoError(t, err)
+	assert.Equal(t, SizingRisk, m)
+	_, err = ParseSizingMode("kelly")
+	assert.Error(t, err)
+}
+
+func TestSizeQuantity_RiskModeUsesStopDistance(t *testing.T) {
+	tests := []struct {
+		side string
+		want string
+	}{
+		// SL 5% / 5x = 1% away: 100 * 2% / 20 = 0.1 ETH either side
+		{"LONG", "0.100"},
+		{"SHORT", "0.100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.side, func(t *testing.T) {
+			e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+				"GET /fapi/v3/balance": []map[string]any{{"asset": "USDT", "availableBalance": "100"}},
+				"GET /fapi/v1/exchangeInfo": map[string]any{"symbols": []map[string]any{{
+					"symbol": "ETHUSDT", "quantityPrecision": 3,
+					"filters": []map[string]any{{"filterType": "LOT_SIZE", "stepSize": "0.001"}},
+				}}},
+			}, map[string]int{}))
+			e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+			e.AviableTradeRatio = 1
+			e.Leverage = 5
+			e.SLPercentage = 0.05
+			e.Sizing = SizingRisk
+			e.RiskPct = 0.02
+
+			qty, err := e.sizeQuantity(context.Background(), 2000, e.CalculateSL(2000, tt.side))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, qty)
+		})
+	}
+}
+
+func TestSizeQuantity_DefaultKeepsBalanceSizing(t *testing.T) {
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+		"GET /fapi/v3/balance": []map[string]any{{"asset": "USDT", "availableBalance": "100"}},
+		"GET /fapi/v1/exchangeInfo": map[string]any{"symbols": []map[string]any{{
+			"symbol": "ETHUSDT", "quantityPrecision": 3,
+			"filters": []map[string]any{{"filterType": "LOT_SIZE", "stepSize": "0.001"}},
+		}}},
+	}, map[string]int{}))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+	e.AviableTradeRatio = 1
+	e.Leverage = 5
+
+	qty, err := e.sizeQuantity(context.Background(), 2000, 1980)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0.250", qty) // 100 * 5 / 2000, stop ignored
+}
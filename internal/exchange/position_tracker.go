@@ -5,7 +5,6 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"strconv"
 	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
@@ -72,8 +71,7 @@ func (e *Executor) getLastRealizedPnL(ctx context.Context) (float64, error) {
 	if len(incomes) == 0 {
 		return 0, nil
 	}
-	pnl, _ := strconv.ParseFloat(incomes[0].Income, 64)
-	return pnl, nil
+	return ParseNumber("income", incomes[0].Income)
 }
 
 // CheckIfClosed เรียกตอน bar ใหม่มา ถ้า position ยังเปิดอยู่ return nil
@@ -3,7 +3,6 @@ package exchange
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
@@ -30,15 +29,53 @@ func FetchLatestCandles(ctx context.Context, klineService KlineService, symbol s
 	return data, nil
 }
 
+// FetchFormingCandles is FetchLatestCandles without dropping the last, still
+// open candle. Use only for monitoring — the last bar is not final.
+func FetchFormingCandles(ctx context.Context, klineService KlineService, symbol string, interval string, limit int) ([]RestCandle, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	klines, err := klineService.FetchKlines(ctx, symbol, interval, limit)
+	if err != nil {
+		return nil, err
+	}
+	return parseKLinesToRestCandle(klines)
+}
+
+// ProgressFunc receives percent-complete (0-100) and estimated time remaining
+// after each page of a ranged history fetch.
+type ProgressFunc func(pct float64, eta time.Duration)
+
+// ComputeProgress returns how much of [start, end] has been covered by covered
+// and, given elapsed wall time so far, the ETA for the remainder.
+func ComputeProgress(start, end, covered time.Time, elapsed time.Duration) (float64, time.Duration) {
+	total := end.Sub(start)
+	if total <= 0 {
+		return 100, 0
+	}
+	done := covered.Sub(start)
+	if done <= 0 {
+		return 0, 0
+	}
+	if done >= total {
+		return 100, 0
+	}
+	frac := float64(done) / float64(total)
+	eta := time.Duration(float64(elapsed) * (1 - frac) / frac)
+	return frac * 100, eta
+}
+
 func FetchHistoryByTime(
 	client *futures.Client,
 	symbol string,
 	interval string,
 	startTime time.Time,
 	endTime time.Time,
+	onProgress ...ProgressFunc,
 ) ([]RestCandle, error) {
 
 	var allData []RestCandle
+	began := time.Now()
 	limit := 1000
 	currentStart := startTime.UnixMilli()
 	endMs := endTime.UnixMilli()
@@ -63,20 +100,17 @@ func FetchHistoryByTime(
 		}
 
 		for _, k := range klines {
-			op, _ := strconv.ParseFloat(k.Open, 64)
-			hi, _ := strconv.ParseFloat(k.High, 64)
-			lo, _ := strconv.ParseFloat(k.Low, 64)
-			cl, _ := strconv.ParseFloat(k.Close, 64)
-			vl, _ := strconv.ParseFloat(k.Volume, 64)
-
-			allData = append(allData, RestCandle{
-				Time:   k.OpenTime / 1000,
-				Open:   op,
-				High:   hi,
-				Low:    lo,
-				Close:  cl,
-				Volume: vl,
-			})
+			c, err := parseKline(k)
+			if err != nil {
+				return nil, fmt.Errorf("kline at %d: %w", k.OpenTime, err)
+			}
+			allData = append(allData, c)
+		}
+
+		if len(onProgress) > 0 {
+			covered := time.UnixMilli(klines[len(klines)-1].OpenTime)
+			pct, eta := ComputeProgress(startTime, endTime, covered, time.Since(began))
+			onProgress[0](pct, eta)
 		}
 
 		// ถ้าได้น้อยกว่า limit = หมดแล้ว
@@ -102,35 +136,11 @@ func FetchHistoryByTime(
 func parseKLinesToRestCandle(klines []*futures.Kline) ([]RestCandle, error) {
 	data := make([]RestCandle, len(klines))
 	for i, k := range klines {
-		op, err := strconv.ParseFloat(k.Open, 64)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse Open price: %w", err)
-		}
-		hi, err := strconv.ParseFloat(k.High, 64)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse High price: %w", err)
-		}
-		lo, err := strconv.ParseFloat(k.Low, 64)
+		c, err := parseKline(k)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse Low price: %w", err)
-		}
-		cl, err := strconv.ParseFloat(k.Close, 64)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse Close price: %w", err)
-		}
-		vl, err := strconv.ParseFloat(k.Volume, 64)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse Volume: %w", err)
-		}
-
-		data[i] = RestCandle{
-			Time:   k.OpenTime / 1000,
-			Open:   op,
-			High:   hi,
-			Low:    lo,
-			Close:  cl,
-			Volume: vl,
+			return nil, fmt.Errorf("kline at %d: %w", k.OpenTime, err)
 		}
+		data[i] = c
 	}
 	return data, nil
 }
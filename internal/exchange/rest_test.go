@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
 	"github.com/stretchr/testify/assert"
@@ -72,3 +73,27 @@ func TestFetchLatestCandles_ParseError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, candles)
 }
+
+func TestComputeProgress_PartialRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 20)
+	covered := start.AddDate(0, 0, 5) // 25% of 20 days
+
+	pct, eta := ComputeProgress(start, end, covered, 30*time.Second)
+
+	assert.InDelta(t, 25.0, pct, 1e-9)
+	assert.Equal(t, 90*time.Second, eta) // 30s for 25% → 90s for the rest
+}
+
+func TestComputeProgress_Bounds(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	pct, eta := ComputeProgress(start, end, start, time.Second)
+	assert.Equal(t, 0.0, pct)
+	assert.Zero(t, eta)
+
+	pct, eta = ComputeProgress(start, end, end.Add(time.Minute), time.Second)
+	assert.Equal(t, 100.0, pct)
+	assert.Zero(t, eta)
+}
@@ -0,0 +1,134 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// MarketStreamer delivers closed candles for a set of symbols on one interval,
+// whatever venue they come from. Klines exposes the same venue's REST history.
+type MarketStreamer interface {
+	Stream(ctx context.Context, symbols []string, interval string, handler MultiSymbolCandleHandler)
+	Klines() KlineService
+}
+
+// NewMarketStreamer picks the market data source by name ("binance" or "bybit").
+func NewMarketStreamer(name string, binanceClient *futures.Client, logger *slog.Logger) (MarketStreamer, error) {
+	switch name {
+	case "", "binance":
+		return &BinanceStreamer{adapter: NewBinanceAdapter(binanceClient), logger: logger}, nil
+	case "bybit":
+		return &PollingStreamer{adapter: NewBybitAdapter(), logger: logger, PollEvery: time.Second}, nil
+	}
+	return nil, fmt.Errorf("unknown market exchange %q (want binance or bybit)", name)
+}
+
+// BinanceStreamer uses the book-ticker heartbeat in StartMultiSymbolKlineWebsocket.
+type BinanceStreamer struct {
+	adapter KlineService
+	logger  *slog.Logger
+}
+
+func (b *BinanceStreamer) Stream(ctx context.Context, symbols []string, interval string, handler MultiSymbolCandleHandler) {
+	StartMultiSymbolKlineWebsocket(ctx, b.adapter, symbols, interval, b.logger, handler)
+}
+
+func (b *BinanceStreamer) Klines() KlineService { return b.adapter }
+
+// PollingStreamer detects closed candles by polling REST every PollEvery.
+// It works for any KlineService, so a new venue only needs an adapter.
+type PollingStreamer struct {
+	adapter   KlineService
+	logger    *slog.Logger
+	PollEvery time.Duration
+}
+
+func NewPollingStreamer(adapter KlineService, logger *slog.Logger, pollEvery time.Duration) *PollingStreamer {
+	return &PollingStreamer{adapter: adapter, logger: logger, PollEvery: pollEvery}
+}
+
+func (p *PollingStreamer) Klines() KlineService { return p.adapter }
+
+func (p *PollingStreamer) Stream(ctx context.Context, symbols []string, interval string, handler MultiSymbolCandleHandler) {
+	if len(symbols) == 0 {
+		return
+	}
+	duration, err := parseIntervalDuration(interval)
+	if err != nil {
+		p.logger.Error("[PollTrigger] unsupported interval", "interval", interval, "err", err)
+		return
+	}
+	intervalSecs := int64(duration.Seconds())
+
+	// Seed so the most recent closed candle isn't re-fired on startup.
+	var lastCandleTime int64
+	p.poll(ctx, symbols, interval, &lastCandleTime)
+
+	pollEvery := p.PollEvery
+	if pollEvery <= 0 {
+		pollEvery = time.Second
+	}
+	ticker := time.NewTicker(pollEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if candles := p.tick(ctx, now, symbols, interval, intervalSecs, &lastCandleTime); len(candles) > 0 {
+				handler(candles)
+			}
+		}
+	}
+}
+
+// tick polls only once now has crossed a bar boundary that the last closed
+// candle (open time *last) does not cover yet. Ticks inside the forming bar
+// make no REST call; after a boundary it keeps polling each tick until the
+// venue publishes the closed candle.
+func (p *PollingStreamer) tick(ctx context.Context, now time.Time, symbols []string, interval string, intervalSecs int64, last *int64) map[string]WsCandle {
+	boundary := (now.Unix() / intervalSecs) * intervalSecs
+	if boundary <= *last+intervalSecs {
+		return nil
+	}
+	return p.poll(ctx, symbols, interval, last)
+}
+
+// poll returns the latest closed candle per symbol when the heartbeat symbol
+// (symbols[0]) has closed a bar newer than *last, advancing *last.
+func (p *PollingStreamer) poll(ctx context.Context, symbols []string, interval string, last *int64) map[string]WsCandle {
+	seed, err := FetchLatestCandles(ctx, p.adapter, symbols[0], interval, 2)
+	if err != nil || len(seed) == 0 {
+		return nil
+	}
+	latest := seed[len(seed)-1]
+	if latest.Time <= *last {
+		return nil
+	}
+	*last = latest.Time
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	candles := make(map[string]WsCandle, len(symbols))
+	for _, sym := range symbols {
+		wg.Add(1)
+		go func(sym string) {
+			defer wg.Done()
+			rest, err := FetchLatestCandles(ctx, p.adapter, sym, interval, 2)
+			if err != nil || len(rest) == 0 {
+				p.logger.Warn("[PollTrigger] fetch failed", "symbol", sym, "err", err)
+				return
+			}
+			mu.Lock()
+			candles[sym] = rest[len(rest)-1].ToWs()
+			mu.Unlock()
+		}(sym)
+	}
+	wg.Wait()
+	return candles
+}
@@ -0,0 +1,124 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/stretchr/testify/assert"
+)
+
+// Same three 15m bars, as Binance and as Bybit would serve them.
+var (
+	binanceBars = []*futures.Kline{
+		{OpenTime: 1700000000000, Open: "100", High: "101", Low: "99", Close: "100.5", Volume: "10"},
+		{OpenTime: 1700000900000, Open: "100.5", High: "102", Low: "100", Close: "101.5", Volume: "12"},
+		{OpenTime: 1700001800000, Open: "101.5", High: "103", Low: "101", Close: "102", Volume: "8"},
+	}
+	bybitBars = [][]string{ // newest first
+		{"1700001800000", "101.5", "103", "101", "102", "8", "0"},
+		{"1700000900000", "100.5", "102", "100", "101.5", "12", "0"},
+		{"1700000000000", "100", "101", "99", "100.5", "10", "0"},
+	}
+)
+
+func newTestBybitAdapter(t *testing.T) *BybitAdapter {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v5/market/kline", r.URL.Path)
+		assert.Equal(t, "15", r.URL.Query().Get("interval"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"retCode": 0,
+			"result":  map[string]any{"list": bybitBars},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	a := NewBybitAdapter()
+	a.BaseURL = srv.URL
+	return a
+}
+
+func TestFetchLatestCandles_SameCandlesRegardlessOfSource(t *testing.T) {
+	ctx := context.Background()
+
+	fromBinance, err := FetchLatestCandles(ctx, &MockKlineService{ReturnData: binanceBars}, "ETHUSDT", "15m", 3)
+	assert.NoError(t, err)
+	fromBybit, err := FetchLatestCandles(ctx, newTestBybitAdapter(t), "ETHUSDT", "15m", 3)
+	assert.NoError(t, err)
+
+	assert.Len(t, fromBybit, 2)
+	assert.Equal(t, fromBinance, fromBybit)
+}
+
+func TestPollingStreamer_Poll_FiresOncePerNewBar(t *testing.T) {
+	p := NewPollingStreamer(newTestBybitAdapter(t), slog.New(slog.NewTextHandler(io.Discard, nil)), 0)
+	var last int64
+
+	first := p.poll(context.Background(), []string{"ETHUSDT", "BTCUSDT"}, "15m", &last)
+	again := p.poll(context.Background(), []string{"ETHUSDT", "BTCUSDT"}, "15m", &last)
+
+	assert.Len(t, first, 2)
+	assert.Equal(t, 101.5, first["ETHUSDT"].Close)
+	assert.Equal(t, int64(1700000900), last)
+	assert.Empty(t, again)
+}
+
+// countingKlines counts FetchKlines calls; poll fetches concurrently.
+type countingKlines struct {
+	MockKlineService
+	calls atomic.Int32
+}
+
+func (c *countingKlines) FetchKlines(ctx context.Context, symbol, interval string, limit int) ([]*futures.Kline, error) {
+	c.calls.Add(1)
+	return c.MockKlineService.FetchKlines(ctx, symbol, interval, limit)
+}
+
+func TestPollingStreamer_Tick_NoPollInsideBar(t *testing.T) {
+	const bar = int64(1700000100) // a 15m boundary
+	klines := &countingKlines{MockKlineService: MockKlineService{ReturnData: []*futures.Kline{
+		{OpenTime: bar * 1000, Open: "100", High: "101", Low: "99", Close: "100.5", Volume: "10"},
+		{OpenTime: (bar + 900) * 1000, Open: "100.5", High: "102", Low: "100", Close: "101.5", Volume: "12"},
+	}}}
+	p := NewPollingStreamer(klines, slog.New(slog.NewTextHandler(io.Discard, nil)), 0)
+	symbols := []string{"ETHUSDT"}
+	var last int64
+	p.poll(context.Background(), symbols, "15m", &last)
+	assert.Equal(t, bar, last)
+	klines.calls.Store(0)
+
+	// The bar after last is still forming.
+	for _, sec := range []int64{bar + 900, bar + 901, bar + 1300, bar + 1799} {
+		assert.Empty(t, p.tick(context.Background(), time.Unix(sec, 0), symbols, "15m", 900, &last))
+	}
+	assert.Zero(t, klines.calls.Load(), "ticks inside one bar must not poll")
+
+	// It closed at bar+1800: poll, even though this mock never publishes it.
+	p.tick(context.Background(), time.Unix(bar+1800, 0), symbols, "15m", 900, &last)
+	assert.Equal(t, int32(1), klines.calls.Load())
+}
+
+func TestNewMarketStreamer_UnknownExchange(t *testing.T) {
+	_, err := NewMarketStreamer("kraken", nil, slog.Default())
+
+	assert.Error(t, err)
+}
+
+func TestToBybitInterval(t *testing.T) {
+	for in, want := range map[string]string{"15m": "15", "1h": "60", "4h": "240", "1d": "D"} {
+		got, err := toBybitInterval(in)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := toBybitInterval("7m")
+	assert.Error(t, err)
+}
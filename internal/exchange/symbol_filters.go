@@ -0,0 +1,125 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// defaultFiltersTTL is how long cached symbol rules are trusted when
+// Executor.FiltersTTL is unset. Binance changes tick/step sizes rarely.
+const defaultFiltersTTL = time.Hour
+
+// symbolFilters holds the exchange rules adjustQuantity and FormatPrice need
+// for the executor's symbol.
+type symbolFilters struct {
+	StepSize          float64
+	TickSize          float64
+	QuantityPrecision int
+	PricePrecision    int
+	Status            string // e.g. TRADING, BREAK, SETTLING; empty if the symbol was not listed
+	fetchedAt         time.Time
+}
+
+// exchangeInfoFunc fetches the full futures exchange info; stubbed in tests.
+type exchangeInfoFunc func(ctx context.Context) (*futures.ExchangeInfo, error)
+
+// filterCache lazily loads symbolFilters and refreshes them after a TTL.
+type filterCache struct {
+	mu      sync.Mutex
+	filters *symbolFilters
+	fetch   exchangeInfoFunc
+}
+
+// symbolFilters returns the cached rules for e.Symbol, refetching exchange
+// info only when the cache is empty or older than FiltersTTL. If a refresh
+// fails, stale rules are kept rather than failing the order.
+func (e *Executor) symbolFilters(ctx context.Context) (symbolFilters, error) {
+	ttl := e.FiltersTTL
+	if ttl <= 0 {
+		ttl = defaultFiltersTTL
+	}
+	return e.loadSymbolFilters(ctx, ttl)
+}
+
+// loadSymbolFilters serves the cache while it is younger than maxAge;
+// maxAge 0 always refetches.
+func (e *Executor) loadSymbolFilters(ctx context.Context, maxAge time.Duration) (symbolFilters, error) {
+	e.filterCache.mu.Lock()
+	defer e.filterCache.mu.Unlock()
+
+	cached := e.filterCache.filters
+	if cached != nil && time.Since(cached.fetchedAt) < maxAge {
+		return *cached, nil
+	}
+
+	fetch := e.filterCache.fetch
+	if fetch == nil {
+		fetch = func(ctx context.Context) (*futures.ExchangeInfo, error) {
+			return e.Client.NewExchangeInfoService().Do(ctx)
+		}
+	}
+	info, err := fetch(ctx)
+	if err != nil {
+		if cached != nil {
+			e.Log.Warn("[Executor] exchange info refresh failed, using cached filters", "err", err)
+			return *cached, nil
+		}
+		return symbolFilters{}, err
+	}
+
+	f := parseSymbolFilters(info, e.Symbol)
+	f.fetchedAt = time.Now()
+	e.filterCache.filters = &f
+	return f, nil
+}
+
+// parseSymbolFilters extracts LOT_SIZE / PRICE_FILTER for symbol, keeping the
+// old per-call defaults when the symbol or a filter is missing.
+func parseSymbolFilters(info *futures.ExchangeInfo, symbol string) symbolFilters {
+	f := symbolFilters{StepSize: 0.001, TickSize: 0.01, QuantityPrecision: 3, PricePrecision: 2}
+	for _, s := range info.Symbols {
+		if s.Symbol != symbol {
+			continue
+		}
+		f.QuantityPrecision = s.QuantityPrecision
+		f.PricePrecision = s.PricePrecision
+		f.Status = s.Status
+		for _, filter := range s.Filters {
+			switch filter["filterType"] {
+			case "LOT_SIZE":
+				if raw, ok := filter["stepSize"].(string); ok {
+					if v, err := ParseNumber("stepSize", raw); err == nil && v > 0 {
+						f.StepSize = v
+					}
+				}
+			case "PRICE_FILTER":
+				if raw, ok := filter["tickSize"].(string); ok {
+					if v, err := ParseNumber("tickSize", raw); err == nil && v > 0 {
+						f.TickSize = v
+					}
+				}
+			}
+		}
+		break
+	}
+	return f
+}
+
+// EnsureTrading refetches exchange info and fails when the symbol is listed
+// with a status other than TRADING (halted, BREAK, settling, ...), so the
+// caller gets a clear reason instead of an opaque order rejection. A symbol
+// missing from exchange info, or reported without a status, is let through.
+func (e *Executor) EnsureTrading(ctx context.Context) error {
+	f, err := e.loadSymbolFilters(ctx, 0)
+	if err != nil {
+		return fmt.Errorf("symbol status: %w", err)
+	}
+	if f.Status != "" && f.Status != string(futures.SymbolStatusTypeTrading) {
+		return fmt.Errorf("%s is not trading (status %s)", e.Symbol, f.Status)
+	}
+	return nil
+}
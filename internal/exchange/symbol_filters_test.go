@@ -0,0 +1,128 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubInfo returns an exchangeInfoFunc serving one ETHUSDT symbol and
+// counting calls; err, when set, is returned instead.
+func stubInfo(calls *int, err *error) exchangeInfoFunc {
+	return func(ctx context.Context) (*futures.ExchangeInfo, error) {
+		*calls++
+		if *err != nil {
+			return nil, *err
+		}
+		return &futures.ExchangeInfo{Symbols: []futures.Symbol{{
+			Symbol: "ETHUSDT", QuantityPrecision: 3, PricePrecision: 2,
+			Filters: []map[string]interface{}{
+				{"filterType": "LOT_SIZE", "stepSize": "0.001"},
+				{"filterType": "PRICE_FILTER", "tickSize": "0.05"},
+			},
+		}}}, nil
+	}
+}
+
+func TestSymbolFilters_CachedAcrossHelpers(t *testing.T) {
+	calls := 0
+	var fetchErr error
+	e := &Executor{Symbol: "ETHUSDT", Log: *slog.New(slog.NewTextHandler(io.Discard, nil))}
+	e.filterCache.fetch = stubInfo(&calls, &fetchErr)
+
+	qty, err := e.adjustQuantity(context.Background(), 0.24437)
+	assert.NoError(t, err)
+	price, err := e.FormatPrice(context.Background(), 2000.12)
+	assert.NoError(t, err)
+	_, err = e.FormatPrice(context.Background(), 2000.12)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "0.244", qty)
+	assert.Equal(t, "2000.10", price)
+	assert.Equal(t, 1, calls, "exchange info should be fetched once")
+}
+
+func TestSymbolFilters_RefreshAfterTTL(t *testing.T) {
+	calls := 0
+	var fetchErr error
+	e := &Executor{Symbol: "ETHUSDT", FiltersTTL: time.Minute, Log: *slog.New(slog.NewTextHandler(io.Discard, nil))}
+	e.filterCache.fetch = stubInfo(&calls, &fetchErr)
+
+	_, err := e.symbolFilters(context.Background())
+	assert.NoError(t, err)
+	e.filterCache.filters.fetchedAt = time.Now().Add(-2 * time.Minute)
+
+	fetchErr = errors.New("rate limited")
+	f, err := e.symbolFilters(context.Background())
+	assert.NoError(t, err, "stale filters are kept when a refresh fails")
+	assert.Equal(t, 0.05, f.TickSize)
+
+	fetchErr = nil
+	_, err = e.symbolFilters(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestSymbolFilters_FirstFetchErrorPropagates(t *testing.T) {
+	calls := 0
+	fetchErr := errors.New("down")
+	e := &Executor{Symbol: "ETHUSDT"}
+	e.filterCache.fetch = stubInfo(&calls, &fetchErr)
+
+	_, err := e.FormatPrice(context.Background(), 2000)
+
+	assert.Error(t, err)
+}
+
+func TestEnsureTrading_RejectsNonTradingStatus(t *testing.T) {
+	for _, status := range []string{"BREAK", "HALT", "SETTLING"} {
+		t.Run(status, func(t *testing.T) {
+			e := &Executor{Symbol: "ETHUSDT", Log: *slog.New(slog.NewTextHandler(io.Discard, nil))}
+			e.filterCache.fetch = func(ctx context.Context) (*futures.ExchangeInfo, error) {
+				return &futures.ExchangeInfo{Symbols: []futures.Symbol{{Symbol: "ETHUSDT", Status: status}}}, nil
+			}
+
+			err := e.EnsureTrading(context.Background())
+
+			assert.ErrorContains(t, err, "ETHUSDT is not trading (status "+status+")")
+		})
+	}
+}
+
+func TestEnsureTrading_AlwaysRefetches(t *testing.T) {
+	status := "TRADING"
+	calls := 0
+	e := &Executor{Symbol: "ETHUSDT", Log: *slog.New(slog.NewTextHandler(io.Discard, nil))}
+	e.filterCache.fetch = func(ctx context.Context) (*futures.ExchangeInfo, error) {
+		calls++
+		return &futures.ExchangeInfo{Symbols: []futures.Symbol{{Symbol: "ETHUSDT", Status: status}}}, nil
+	}
+
+	assert.NoError(t, e.EnsureTrading(context.Background()))
+	status = "BREAK"
+	assert.Error(t, e.EnsureTrading(context.Background()), "a halt after the last fetch must be seen")
+	assert.Equal(t, 2, calls)
+}
+
+func TestPlaceTrade_HaltedSymbolSubmitsNoOrder(t *testing.T) {
+	hits := map[string]int{}
+	e := newTestExecutor(t, mockOrderRoutes(map[string]any{
+		"GET /fapi/v1/exchangeInfo": map[string]any{"symbols": []map[string]any{{
+			"symbol": "ETHUSDT", "status": "BREAK",
+		}}},
+	}, hits))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	err := e.PlaceTrade(context.Background(), "LONG", 2000, nil)
+
+	assert.ErrorContains(t, err, "not trading (status BREAK)")
+	assert.Zero(t, hits["DELETE /fapi/v1/allOpenOrders"])
+	assert.Zero(t, hits["POST /fapi/v1/order"])
+	assert.Zero(t, hits["POST /fapi/v1/algoOrder"])
+}
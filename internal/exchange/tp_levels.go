@@ -0,0 +1,113 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// TPLevel is one leg of a scaled take-profit.
+type TPLevel struct {
+	Move     float64 // distance as a multiple of the single-TP move; 1 = the CalculateTP price
+	Fraction float64 // share of the position closed by this leg, (0, 1]
+}
+
+// ParseTPLevels reads "move:fraction" pairs, e.g. "0.5:0.5,1:0.3,2:0.2".
+// Fractions must be positive and add up to 1. Empty means a single TP.
+func ParseTPLevels(s string) ([]TPLevel, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var levels []TPLevel
+	sum := 0.0
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("tp level %q: want move:fraction", pair)
+		}
+		move, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil || move <= 0 {
+			return nil, fmt.Errorf("tp level %q: move must be a positive number", pair)
+		}
+		frac, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || frac <= 0 || frac > 1 {
+			return nil, fmt.Errorf("tp level %q: fraction must be in (0, 1]", pair)
+		}
+		levels = append(levels, TPLevel{Move: move, Fraction: frac})
+		sum += frac
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		return nil, fmt.Errorf("tp level fractions sum to %.4f, want 1", sum)
+	}
+	return levels, nil
+}
+
+// tpLeg is a TPLevel resolved to an order quantity.
+type tpLeg struct {
+	Level TPLevel
+	Qty   string
+}
+
+// splitQuantity divides total across levels, flooring each leg to step. The
+// last leg takes the remainder so the legs always add up to the full position;
+// a leg that rounds to zero is dropped and its share rolls into the last one.
+func splitQuantity(total, step float64, precision int, levels []TPLevel) []tpLeg {
+	format := "%." + strconv.Itoa(precision) + "f"
+	legs := make([]tpLeg, 0, len(levels))
+	used := 0.0
+	for i, lvl := range levels {
+		var qty float64
+		if i == len(levels)-1 {
+			qty = math.Round((total-used)/step) * step
+		} else {
+			qty = math.Floor(total*lvl.Fraction/step) * step
+		}
+		if qty <= 0 {
+			continue
+		}
+		used += qty
+		legs = append(legs, tpLeg{Level: lvl, Qty: fmt.Sprintf(format, qty)})
+	}
+	return legs
+}
+
+// placeScaledTP arms one reduce-only TAKE_PROFIT_MARKET per TPLevels leg.
+// Like the single TP, a failed leg is logged and skipped since SL is armed.
+func (e *Executor) placeScaledTP(ctx context.Context, side string, entry float64, quantity string, closeSide futures.SideType, clientID string) error {
+	total, err := ParseNumber("quantity", quantity)
+	if err != nil {
+		return err
+	}
+	f, err := e.symbolFilters(ctx)
+	if err != nil {
+		return err
+	}
+
+	move := e.CalculateTP(entry, side) - entry
+	for i, leg := range splitQuantity(total, f.StepSize, f.QuantityPrecision, e.TPLevels) {
+		priceStr, err := e.FormatPrice(ctx, entry+move*leg.Level.Move)
+		if err != nil {
+			return fmt.Errorf("failed to format TP leg price: %v", err)
+		}
+		_, err = e.Client.NewCreateAlgoOrderService().
+			Symbol(e.Symbol).
+			Side(closeSide).
+			AlgoType("CONDITIONAL").
+			Type("TAKE_PROFIT_MARKET").
+			Quantity(leg.Qty).
+			ReduceOnly(true).
+			TriggerPrice(priceStr).
+			ClientAlgoId(fmt.Sprintf("%s-%d", clientID, i+1)).
+			Do(ctx)
+		if err != nil {
+			e.Log.Error(fmt.Sprintf("[Executor] ⚠️ Take Profit leg %d Failed (SL is armed): %v\n", i+1, err))
+			continue
+		}
+		e.Log.Info(fmt.Sprintf("[Executor] 💰 Take Profit leg %d Set (Algo): %s @ %s\n", i+1, leg.Qty, priceStr))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package exchange
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTPLevels(t *testing.T) {
+	levels, err := ParseTPLevels("0.5:0.5, 1:0.3,2:0.2")
+	require.NoError(t, err)
+	assert.Equal(t, []TPLevel{{0.5, 0.5}, {1, 0.3}, {2, 0.2}}, levels)
+
+	levels, err = ParseTPLevels("")
+	assert.NoError(t, err)
+	assert.Nil(t, levels)
+
+	for _, bad := range []string{"1:0.5", "0.5:0.5,1", "0:1", "1:abc", "1:1.5"} {
+		_, err := ParseTPLevels(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSplitQuantity_SumsToFullAndRoundsToStep(t *testing.T) {
+	const step = 0.001
+	levels := []TPLevel{{0.5, 0.5}, {1, 0.3}, {2, 0.2}}
+	for _, total := range []float64{0.225, 0.244, 1.337, 0.003} {
+		legs := splitQuantity(total, step, 3, levels)
+
+		sum := 0.0
+		for _, leg := range legs {
+			q, err := ParseNumber("quantity", leg.Qty)
+			require.NoError(t, err)
+			assert.InDelta(t, 0, math.Remainder(q, step), 1e-9, "leg %s not on step", leg.Qty)
+			sum += q
+		}
+		assert.InDelta(t, total, sum, 1e-9, "total %v", total)
+	}
+
+	legs := splitQuantity(0.225, step, 3, levels)
+	assert.Equal(t, []string{"0.112", "0.067", "0.046"}, []string{legs[0].Qty, legs[1].Qty, legs[2].Qty})
+}
+
+func TestSplitQuantity_DropsZeroLegs(t *testing.T) {
+	legs := splitQuantity(0.002, 0.001, 3, []TPLevel{{1, 0.3}, {2, 0.7}})
+
+	require.Len(t, legs, 1)
+	assert.Equal(t, "0.002", legs[0].Qty)
+}
+
+func TestPlaceTrade_ScaledTP(t *testing.T) {
+	var algos []map[string]string
+	e := newTrailingExecutor(t, &algos)
+	e.TrailingStop = false
+	e.TPLevels = []TPLevel{{0.5, 0.5}, {1, 0.3}, {2, 0.2}}
+
+	err := e.PlaceTrade(context.Background(), "LONG", 2000, nil)
+
+	require.NoError(t, err)
+	require.Len(t, algos, 4)
+	assert.Equal(t, "STOP_MARKET", algos[0]["type"])
+	assert.Equal(t, "0.225", algos[0]["quantity"], "stop covers the full position")
+	want := []struct{ price, qty string }{{"2020.00", "0.112"}, {"2040.00", "0.067"}, {"2080.00", "0.046"}}
+	for i, w := range want {
+		assert.Equal(t, "TAKE_PROFIT_MARKET", algos[i+1]["type"])
+		assert.Equal(t, w.price, algos[i+1]["triggerPrice"])
+		assert.Equal(t, w.qty, algos[i+1]["quantity"])
+	}
+}
@@ -0,0 +1,112 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// trailingRoutes serves everything PlaceTrade touches and records the algo
+// order params (type, quantity, triggerPrice, activatePrice, callbackRate)
+// per call.
+func trailingRoutes(algoOrders *[]map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /fapi/v1/openAlgoOrders":
+			json.NewEncoder(w).Encode([]map[string]any{})
+		case "GET /fapi/v3/balance":
+			json.NewEncoder(w).Encode([]map[string]any{{"asset": "USDT", "availableBalance": "100"}})
+		case "GET /fapi/v1/exchangeInfo":
+			json.NewEncoder(w).Encode(map[string]any{"symbols": []map[string]any{{
+				"symbol": "ETHUSDT", "pricePrecision": 2, "quantityPrecision": 3,
+				"filters": []map[string]any{
+					{"filterType": "PRICE_FILTER", "tickSize": "0.01"},
+					{"filterType": "LOT_SIZE", "stepSize": "0.001"},
+				},
+			}}})
+		case "POST /fapi/v1/order":
+			json.NewEncoder(w).Encode(map[string]any{"orderId": 1})
+		case "POST /fapi/v1/algoOrder":
+			r.ParseForm()
+			*algoOrders = append(*algoOrders, map[string]string{
+				"type":          r.FormValue("type"),
+				"quantity":      r.FormValue("quantity"),
+				"triggerPrice":  r.FormValue("triggerPrice"),
+				"activatePrice": r.FormValue("activatePrice"),
+				"callbackRate":  r.FormValue("callbackRate"),
+			})
+			json.NewEncoder(w).Encode(map[string]any{"algoId": len(*algoOrders)})
+		default:
+			json.NewEncoder(w).Encode(map[string]any{})
+		}
+	}
+}
+
+func newTrailingExecutor(t *testing.T, algoOrders *[]map[string]string) *Executor {
+	e := newTestExecutor(t, trailingRoutes(algoOrders))
+	e.Log = *slog.New(slog.NewTextHandler(io.Discard, nil))
+	e.AviableTradeRatio = 0.9
+	e.Leverage = 5
+	e.SLPercentage = 0.05 // 1% price move
+	e.TPPercentage = 0.10 // 2% price move
+	e.TrailingStop = true
+	e.CallbackRate = 0.5
+	return e
+}
+
+func TestPlaceTrade_TrailingStop_ActivatesAtTP(t *testing.T) {
+	tests := []struct {
+		side           string
+		wantSL         string
+		wantActivation string
+	}{
+		{"LONG", "1980.00", "2040.00"},
+		{"SHORT", "2020.00", "1960.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.side, func(t *testing.T) {
+			var algos []map[string]string
+			e := newTrailingExecutor(t, &algos)
+
+			err := e.PlaceTrade(context.Background(), tt.side, 2000, nil)
+
+			assert.NoError(t, err)
+			assert.Len(t, algos, 2)
+			assert.Equal(t, "STOP_MARKET", algos[0]["type"], "static stop stays armed")
+			assert.Equal(t, tt.wantSL, algos[0]["triggerPrice"])
+			assert.Equal(t, "TRAILING_STOP_MARKET", algos[1]["type"])
+			assert.Equal(t, tt.wantActivation, algos[1]["activatePrice"])
+			assert.Equal(t, "0.5", algos[1]["callbackRate"])
+		})
+	}
+}
+
+func TestPlaceTrade_TrailingStop_RejectsCallbackRate(t *testing.T) {
+	var algos []map[string]string
+	e := newTrailingExecutor(t, &algos)
+	e.CallbackRate = 12
+
+	err := e.PlaceTrade(context.Background(), "LONG", 2000, nil)
+
+	assert.ErrorContains(t, err, "callback rate")
+	assert.Empty(t, algos)
+}
+
+func TestPlaceTrade_DefaultKeepsFixedTP(t *testing.T) {
+	var algos []map[string]string
+	e := newTrailingExecutor(t, &algos)
+	e.TrailingStop = false
+
+	err := e.PlaceTrade(context.Background(), "LONG", 2000, nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, algos, 2)
+	assert.Equal(t, "TAKE_PROFIT_MARKET", algos[1]["type"])
+	assert.Equal(t, "2040.00", algos[1]["triggerPrice"])
+}
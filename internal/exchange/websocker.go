@@ -67,56 +67,11 @@ func StartKlineWebsocket(ctx context.Context, adapter KlineService, symbol strin
 			}
 			lastCandleTime.Store(latest.Time)
 			logger.Info("[Trigger] new closed candle", "symbol", symbol, "time", latest.Time, "close", latest.Close)
-			handler(WsCandle{
-				Time:   latest.Time,
-				Open:   latest.Open,
-				High:   latest.High,
-				Low:    latest.Low,
-				Close:  latest.Close,
-				Volume: latest.Volume,
-			})
+			handler(latest.ToWs())
 		}()
 	}
 
-	connectBackoff := 3 * time.Second
-	for {
-		if ctx.Err() != nil {
-			return
-		}
-
-		doneCh, _, err := futures.WsBookTickerServe(
-			strings.ToUpper(symbol),
-			func(_ *futures.WsBookTickerEvent) { checkAndFire() },
-			func(err error) { logger.Error("[Trigger] WS error", "err", err) },
-		)
-		if err != nil {
-			logger.Error("[Trigger] connect failed, retrying", "err", err, "backoff", connectBackoff)
-			select {
-			case <-time.After(connectBackoff):
-				if connectBackoff < 60*time.Second {
-					connectBackoff *= 2
-				}
-				continue
-			case <-ctx.Done():
-				return
-			}
-		}
-
-		connectBackoff = 3 * time.Second
-		logger.Info("[Trigger] book-ticker WS connected", "symbol", symbol)
-
-		select {
-		case <-doneCh:
-			logger.Warn("[Trigger] WS dropped, reconnecting in 3s")
-			select {
-			case <-time.After(3 * time.Second):
-			case <-ctx.Done():
-				return
-			}
-		case <-ctx.Done():
-			return
-		}
-	}
+	serveBookTickerHeartbeat(ctx, symbol, logger, "[Trigger]", checkAndFire)
 }
 
 // MultiSymbolCandleHandler receives one closed candle per symbol, keyed by symbol name.
@@ -188,11 +143,7 @@ func StartMultiSymbolKlineWebsocket(ctx context.Context, adapter KlineService, s
 						logger.Warn("[MultiTrigger] fetch failed", "symbol", sym, "err", err)
 						return
 					}
-					c := candles[len(candles)-1]
-					ch <- result{sym, WsCandle{
-						Time: c.Time, Open: c.Open, High: c.High,
-						Low: c.Low, Close: c.Close, Volume: c.Volume,
-					}}
+					ch <- result{sym, candles[len(candles)-1].ToWs()}
 				}(sym)
 			}
 			wg.Wait()
@@ -208,19 +159,41 @@ func StartMultiSymbolKlineWebsocket(ctx context.Context, adapter KlineService, s
 		}()
 	}
 
+	serveBookTickerHeartbeat(ctx, heartbeat, logger, "[MultiTrigger]", checkAndFire)
+}
+
+// heartbeatIdleTimeout is how long the book-ticker stream may stay silent
+// before the connection is treated as dead. Liquid futures symbols tick
+// several times a second, so a quiet minute means a stalled socket, not a
+// quiet market.
+var heartbeatIdleTimeout = time.Minute
+
+// heartbeatReconnectDelay is the pause between a dropped socket and redial.
+var heartbeatReconnectDelay = 3 * time.Second
+
+// serveBookTickerHeartbeat keeps a book-ticker stream for symbol open until
+// ctx ends, calling onTick on every event. It reconnects with exponential
+// backoff when the connect fails or the socket drops. It also reconnects when
+// no event arrives within heartbeatIdleTimeout. That covers the silent
+// disconnect that go-binance's 10-minute ping/pong keepalive is slow to notice.
+func serveBookTickerHeartbeat(ctx context.Context, symbol string, logger *slog.Logger, tag string, onTick func()) {
+	var lastEvent atomic.Int64
 	connectBackoff := 3 * time.Second
 	for {
 		if ctx.Err() != nil {
 			return
 		}
 
-		doneCh, _, err := futures.WsBookTickerServe(
-			strings.ToUpper(heartbeat),
-			func(_ *futures.WsBookTickerEvent) { checkAndFire() },
-			func(err error) { logger.Error("[MultiTrigger] WS error", "err", err) },
+		doneCh, stopCh, err := futures.WsBookTickerServe(
+			strings.ToUpper(symbol),
+			func(_ *futures.WsBookTickerEvent) {
+				lastEvent.Store(time.Now().UnixNano())
+				onTick()
+			},
+			func(err error) { logger.Error(tag+" WS error", "err", err) },
 		)
 		if err != nil {
-			logger.Error("[MultiTrigger] connect failed, retrying", "err", err, "backoff", connectBackoff)
+			logger.Error(tag+" connect failed, retrying", "err", err, "backoff", connectBackoff)
 			select {
 			case <-time.After(connectBackoff):
 				if connectBackoff < 60*time.Second {
@@ -233,16 +206,35 @@ func StartMultiSymbolKlineWebsocket(ctx context.Context, adapter KlineService, s
 		}
 
 		connectBackoff = 3 * time.Second
-		logger.Info("[MultiTrigger] book-ticker WS connected", "heartbeat", heartbeat)
+		lastEvent.Store(time.Now().UnixNano())
+		logger.Info(tag+" book-ticker WS connected", "symbol", symbol)
 
-		select {
-		case <-doneCh:
-			logger.Warn("[MultiTrigger] WS dropped, reconnecting in 3s")
+		watchdog := time.NewTicker(heartbeatIdleTimeout / 4)
+	watch:
+		for {
 			select {
-			case <-time.After(3 * time.Second):
+			case <-doneCh:
+				logger.Warn(tag + " WS dropped, reconnecting in 3s")
+				break watch
+			case <-watchdog.C:
+				idle := time.Since(time.Unix(0, lastEvent.Load()))
+				if idle < heartbeatIdleTimeout {
+					continue
+				}
+				logger.Warn(tag+" WS silent, forcing reconnect", "idle", idle.Round(time.Second))
+				close(stopCh)
+				<-doneCh
+				break watch
 			case <-ctx.Done():
+				watchdog.Stop()
+				close(stopCh)
 				return
 			}
+		}
+		watchdog.Stop()
+
+		select {
+		case <-time.After(heartbeatReconnectDelay):
 		case <-ctx.Done():
 			return
 		}
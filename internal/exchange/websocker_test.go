@@ -0,0 +1,60 @@
+package exchange
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeBookTickerHeartbeat_ReconnectsWhenSilent(t *testing.T) {
+	defer func(idle, delay time.Duration, url string) {
+		heartbeatIdleTimeout, heartbeatReconnectDelay, futures.BaseWsMainUrl = idle, delay, url
+	}(heartbeatIdleTimeout, heartbeatReconnectDelay, futures.BaseWsMainUrl)
+	heartbeatIdleTimeout = 100 * time.Millisecond
+	heartbeatReconnectDelay = 0
+
+	// Each connection sends one frame, then goes quiet without closing.
+	var conns atomic.Int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		conns.Add(1)
+		c.WriteMessage(websocket.TextMessage, []byte(`{"e":"bookTicker","s":"ETHUSDT","b":"2000","a":"2000.1"}`))
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+	futures.BaseWsMainUrl = "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	var ticks atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serveBookTickerHeartbeat(ctx, "ETHUSDT", slog.New(slog.NewTextHandler(io.Discard, nil)), "[Test]", func() { ticks.Add(1) })
+	}()
+
+	assert.Eventually(t, func() bool { return conns.Load() >= 2 }, 2*time.Second, 10*time.Millisecond,
+		"silent stream should trigger a reconnect")
+	cancel()
+	<-done
+	assert.GreaterOrEqual(t, ticks.Load(), int32(1))
+}
@@ -0,0 +1,154 @@
+// Package health serves the live bot's liveness and status endpoints.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Signal is the last decision the pipeline reported.
+type Signal struct {
+	Symbol string    `json:"symbol"`
+	Action string    `json:"action"` // LONG, SHORT, HOLD, CLOSE
+	Price  float64   `json:"price"`
+	Time   time.Time `json:"time"`
+}
+
+// Position is one open position.
+type Position struct {
+	Symbol string  `json:"symbol"`
+	Side   string  `json:"side"` // LONG or SHORT
+	Amount float64 `json:"amount"`
+}
+
+// Status is the /status body.
+type Status struct {
+	StartedAt      time.Time  `json:"started_at"`
+	LastCandleTime time.Time  `json:"last_candle_time"` // open time of the last closed bar seen
+	LastSignal     *Signal    `json:"last_signal"`
+	Positions      []Position `json:"positions"` // empty = flat
+	DailyPnL       float64    `json:"daily_pnl"` // realized USDT today
+	DailyROI       float64    `json:"daily_roi"` // percent
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Tracker holds the live status. Setters are safe from any goroutine.
+type Tracker struct {
+	// StaleAfter fails /healthz when no candle has arrived for this long
+	// (counted from start until the first one); 0 = never stale.
+	StaleAfter time.Duration
+	now        func() time.Time
+
+	mu       sync.RWMutex
+	status   Status
+	candleAt time.Time // when the last candle arrived
+}
+
+func NewTracker(staleAfter time.Duration) *Tracker {
+	t := &Tracker{StaleAfter: staleAfter, now: time.Now}
+	t.status.StartedAt = t.now()
+	return t
+}
+
+func (t *Tracker) update(fn func(s *Status)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.UpdatedAt = t.now()
+	fn(&t.status)
+}
+
+func (t *Tracker) SetCandle(openTime time.Time) {
+	t.update(func(s *Status) {
+		s.LastCandleTime = openTime
+		t.candleAt = s.UpdatedAt
+	})
+}
+
+func (t *Tracker) SetSignal(sig Signal) {
+	t.update(func(s *Status) { s.LastSignal = &sig })
+}
+
+func (t *Tracker) SetPositions(positions []Position) {
+	t.update(func(s *Status) { s.Positions = positions })
+}
+
+func (t *Tracker) SetDailyPnL(pnl, roi float64) {
+	t.update(func(s *Status) { s.DailyPnL, s.DailyROI = pnl, roi })
+}
+
+// Snapshot returns a copy of the current status.
+func (t *Tracker) Snapshot() Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	s := t.status
+	s.Positions = append([]Position{}, s.Positions...) // flat encodes as []
+	if s.LastSignal != nil {
+		sig := *s.LastSignal
+		s.LastSignal = &sig
+	}
+	return s
+}
+
+// stale reports how long the stream has been silent past StaleAfter, or 0.
+func (t *Tracker) stale() time.Duration {
+	if t.StaleAfter <= 0 {
+		return 0
+	}
+	t.mu.RLock()
+	last := t.candleAt
+	if last.IsZero() {
+		last = t.status.StartedAt
+	}
+	t.mu.RUnlock()
+	if silent := t.now().Sub(last); silent > t.StaleAfter {
+		return silent
+	}
+	return 0
+}
+
+// Handler serves GET /healthz (200 "ok", or 503 once the stream is stale),
+// GET /status (the Status as JSON) and, when metrics is non-nil, /metrics.
+func Handler(t *Tracker, metrics http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		if silent := t.stale(); silent > 0 {
+			http.Error(w, fmt.Sprintf("no candle for %s", silent.Round(time.Second)), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(t.Snapshot())
+	})
+	if metrics != nil {
+		mux.Handle("GET /metrics", metrics)
+	}
+	return mux
+}
+
+// Serve listens on port until ctx is cancelled, then shuts down gracefully.
+func Serve(ctx context.Context, port int, t *Tracker, metrics http.Handler, logger *slog.Logger) error {
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           Handler(t, metrics),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	logger.Info("[Health] serving /healthz, /status and /metrics", "port", port)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("health server: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClockTracker(staleAfter time.Duration, now *time.Time) *Tracker {
+	t := NewTracker(staleAfter)
+	t.now = func() time.Time { return *now }
+	t.status.StartedAt = *now
+	return t
+}
+
+func TestStatus_ReportsInjectedState(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	tr := newClockTracker(0, &now)
+	bar := now.Add(-15 * time.Minute)
+	tr.SetCandle(bar)
+	tr.SetSignal(Signal{Symbol: "ETHUSDT", Action: "LONG", Price: 2000, Time: now})
+	tr.SetPositions([]Position{{Symbol: "ETHUSDT", Side: "LONG", Amount: 0.25}})
+	tr.SetDailyPnL(12.5, 1.25)
+
+	rec := httptest.NewRecorder()
+	Handler(tr, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var got Status
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+	assert.True(t, got.LastCandleTime.Equal(bar))
+	require.NotNil(t, got.LastSignal)
+	assert.Equal(t, "LONG", got.LastSignal.Action)
+	assert.Equal(t, []Position{{Symbol: "ETHUSDT", Side: "LONG", Amount: 0.25}}, got.Positions)
+	assert.Equal(t, 12.5, got.DailyPnL)
+	assert.Equal(t, 1.25, got.DailyROI)
+}
+
+func TestStatus_FlatIsEmptyList(t *testing.T) {
+	tr := NewTracker(0)
+	tr.SetPositions(nil)
+
+	rec := httptest.NewRecorder()
+	Handler(tr, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	assert.Contains(t, rec.Body.String(), `"positions":[]`)
+	assert.Contains(t, rec.Body.String(), `"last_signal":null`)
+}
+
+func TestHealthz_FailsWhenStreamStale(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	tr := newClockTracker(45*time.Minute, &now)
+	healthz := func() int {
+		rec := httptest.NewRecorder()
+		Handler(tr, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, healthz(), "just started")
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, http.StatusServiceUnavailable, healthz(), "no candle since start")
+
+	tr.SetCandle(now.Add(-15 * time.Minute))
+	assert.Equal(t, http.StatusOK, healthz(), "candle just arrived")
+
+	now = now.Add(46 * time.Minute)
+	assert.Equal(t, http.StatusServiceUnavailable, healthz(), "stream went quiet")
+}
+
+func TestHandler_RejectsOtherMethods(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(NewTracker(0), nil).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/status", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"math"
+
+	"time-series-rag-agent/internal/embedding"
+)
+
+// ConsensusStrength measures directional agreement across matches:
+// |up - down| / n, so 1.0 = all matches agree and 0.0 = an even split.
+// Slope_3 is used, falling back to slope_5 like the prompt builder.
+func ConsensusStrength(matches []embedding.PatternLabel) float64 {
+	if len(matches) == 0 {
+		return 0
+	}
+	up, down := 0, 0
+	for _, m := range matches {
+		slope := m.NextSlope3
+		if slope == 0 {
+			slope = m.NextSlope5
+		}
+		if slope > 0 {
+			up++
+		} else {
+			down++
+		}
+	}
+	diff := up - down
+	if diff < 0 {
+		diff = -diff
+	}
+	return float64(diff) / float64(len(matches))
+}
+
+// ReturnWeightedConsensus weights each match by its realized next_return:
+// sum(r) / sum(|r|), so -1..+1 where +1 = every match that moved went up.
+// A few large winning matches outweigh many flat ones; unlabeled matches
+// (r == 0) carry no weight. Returns 0 when nothing has a return yet.
+func ReturnWeightedConsensus(matches []embedding.PatternLabel) float64 {
+	var sum, abs float64
+	for _, m := range matches {
+		sum += m.NextReturn
+		abs += math.Abs(m.NextReturn)
+	}
+	if abs == 0 {
+		return 0
+	}
+	return sum / abs
+}
+
+// ConsensusFloor scales the confidence bar with consensus strength.
+type ConsensusFloor struct {
+	Strong float64 // strength at or above this is Tier 1 → bar lowered by Adjust
+	Weak   float64 // strength at or below this is Tier 2 → bar raised by Adjust
+	Adjust int     // confidence points; 0 disables scaling
+}
+
+// Tier classifies strength: 1 = strong, 2 = weak, 0 = in between.
+func (c ConsensusFloor) Tier(strength float64) int {
+	switch {
+	case strength >= c.Strong:
+		return 1
+	case strength <= c.Weak:
+		return 2
+	}
+	return 0
+}
+
+// ConfidenceFloor returns the minimum confidence required to trade given base
+// (the fixed CONFIDENCE_THRESHOLD) and the matches' consensus strength.
+func ConfidenceFloor(base int, strength float64, c ConsensusFloor) int {
+	if c.Adjust == 0 {
+		return base
+	}
+	switch c.Tier(strength) {
+	case 1:
+		return base - c.Adjust
+	case 2:
+		return base + c.Adjust
+	}
+	return base
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"testing"
+
+	"time-series-rag-agent/internal/embedding"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func labels(slopes ...float64) []embedding.PatternLabel {
+	out := make([]embedding.PatternLabel, len(slopes))
+	for i, s := range slopes {
+		out[i] = embedding.PatternLabel{NextSlope3: s}
+	}
+	return out
+}
+
+func TestConsensusStrength(t *testing.T) {
+	assert.Equal(t, 1.0, ConsensusStrength(labels(0.1, 0.2, 0.3, 0.4)))
+	assert.Equal(t, 0.0, ConsensusStrength(labels(0.1, -0.2, 0.3, -0.4)))
+	assert.Equal(t, 0.5, ConsensusStrength(labels(0.1, 0.2, 0.3, -0.4)))
+	assert.Equal(t, 0.0, ConsensusStrength(nil))
+}
+
+func TestConfidenceFloor_StrongTradesWeakDoesNot(t *testing.T) {
+	c := ConsensusFloor{Strong: 0.6, Weak: 0.2, Adjust: 10}
+	base, confidence := 60, 55 // borderline: below the fixed bar
+
+	strong := ConfidenceFloor(base, ConsensusStrength(labels(0.1, 0.2, 0.3, 0.4, 0.5)), c)
+	weak := ConfidenceFloor(base, ConsensusStrength(labels(0.1, -0.2, 0.3, -0.4, 0.5)), c)
+
+	assert.GreaterOrEqual(t, confidence, strong, "strong consensus should trade")
+	assert.Less(t, confidence, weak, "weak consensus should not trade")
+}
+
+func TestConfidenceFloor_DisabledKeepsBase(t *testing.T) {
+	assert.Equal(t, 60, ConfidenceFloor(60, 1.0, ConsensusFloor{}))
+}
+
+func TestReturnWeightedConsensus_ProfitableUpMatchesDominate(t *testing.T) {
+	// 2 up-matches with big gains vs 4 down-matches with tiny losses: the slope
+	// vote says DOWN, the return-weighted lean says UP.
+	matches := []embedding.PatternLabel{
+		{NextSlope3: 0.01, NextReturn: 0.012},
+		{NextSlope3: 0.02, NextReturn: 0.008},
+		{NextSlope3: -0.01, NextReturn: -0.001},
+		{NextSlope3: -0.01, NextReturn: -0.001},
+		{NextSlope3: -0.01, NextReturn: -0.001},
+		{NextSlope3: -0.01, NextReturn: -0.001},
+	}
+
+	lean := ReturnWeightedConsensus(matches)
+
+	assert.InDelta(t, (0.020-0.004)/0.024, lean, 1e-9)
+	assert.Greater(t, lean, 0.15)
+}
+
+func TestReturnWeightedConsensus_Bounds(t *testing.T) {
+	assert.Equal(t, 0.0, ReturnWeightedConsensus(nil))
+	assert.Equal(t, 0.0, ReturnWeightedConsensus(labels(0.1, 0.2))) // unlabeled returns
+	assert.Equal(t, -1.0, ReturnWeightedConsensus([]embedding.PatternLabel{{NextReturn: -0.01}, {NextReturn: -0.02}}))
+}
@@ -1,5 +1,10 @@
 package llm
 
+import (
+	"encoding/json"
+	"math"
+)
+
 type PnLData struct {
 	PositionOpenAt string
 	NetPnL         float64
@@ -20,11 +25,59 @@ type HistoricalDetail struct {
 
 type TradeSignal struct {
 	Signal          string  `json:"signal"`      // LONG, SHORT, HOLD
-	Confidence      int     `json:"confidence"`  // 0-100 or 0.0-1.0 (handled dynamically)
+	Confidence      int     `json:"confidence"`  // 0-100; a 0.0-1.0 reply is scaled by UnmarshalJSON
 	RegimeRead      string  `json:"regime_read"` // RegimeContext
 	PatternRead     string  `json:"pattern_read"`
 	PriceActionRead string  `json:"price_action_read"` // PriceAction
 	Synthesis       string  `json:"synthesis"`         // reason
 	RiskNote        string  `json:"risk_note"`
 	Invalidation    float64 `json:"invalidation"`
+
+	// Audit fields, filled from the API response rather than the model's JSON.
+	RawResponse  string `json:"-"` // untouched text of the first content block
+	FinishReason string `json:"-"` // API stop_reason, e.g. "end_turn" or "max_tokens"
+	Usage        Usage  `json:"-"` // tokens billed for this signal, including a truncation retry
+
+	ConsensusStrength float64 `json:"-"` // directional agreement of the pattern matches (0-1)
+	MatchCount        int     `json:"-"` // pattern matches fed to the prompt for this bar
+}
+
+// Usage is the token count of one or more LLM calls.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add sums two usages.
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		TotalTokens:      u.TotalTokens + o.TotalTokens,
+	}
+}
+
+// UnmarshalJSON reads confidence as any JSON number, so 85, 85.0 and the
+// fraction 0.85 some replies use all land as 85 instead of failing the parse.
+func (t *TradeSignal) UnmarshalJSON(data []byte) error {
+	type plain TradeSignal
+	aux := struct {
+		*plain
+		Confidence float64 `json:"confidence"`
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	t.Confidence = NormalizeConfidence(aux.Confidence)
+	return nil
+}
+
+// NormalizeConfidence maps a model confidence onto 0-100: values at or below
+// 1.0 are read as fractions, the result is rounded and clamped.
+func NormalizeConfidence(c float64) int {
+	if c <= 1.0 {
+		c *= 100
+	}
+	return int(math.Round(math.Max(0, math.Min(100, c))))
 }
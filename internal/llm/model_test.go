@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTradeSignal_UnmarshalConfidenceScales(t *testing.T) {
+	for raw, want := range map[string]int{
+		"0.85": 85,
+		"85":   85,
+		"85.0": 85,
+		"100":  100,
+		"1.0":  100,
+		"0":    0,
+		"0.5":  50,
+		"72.6": 73,
+		"140":  100,
+		"-5":   0,
+	} {
+		var s TradeSignal
+		require.NoError(t, json.Unmarshal([]byte(`{"signal":"LONG","confidence":`+raw+`}`), &s), raw)
+		assert.Equal(t, want, s.Confidence, raw)
+		assert.Equal(t, "LONG", s.Signal, raw)
+	}
+}
+
+func TestTradeSignal_UnmarshalKeepsOtherFields(t *testing.T) {
+	var s TradeSignal
+	err := json.Unmarshal([]byte(`{"signal":"SHORT","confidence":0.6,"synthesis":"x","invalidation":2010.5}`), &s)
+
+	require.NoError(t, err)
+	assert.Equal(t, TradeSignal{Signal: "SHORT", Confidence: 60, Synthesis: "x", Invalidation: 2010.5}, s)
+}
+
+func TestTradeSignal_UnmarshalRejectsNonNumericConfidence(t *testing.T) {
+	var s TradeSignal
+	assert.Error(t, json.Unmarshal([]byte(`{"signal":"LONG","confidence":"high"}`), &s))
+}
@@ -5,24 +5,34 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math/rand"
+	"net"
 	"net/http"
-	"os"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	"time-series-rag-agent/internal/embedding"
 	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/metrics"
+	"time-series-rag-agent/internal/plot"
 	"time-series-rag-agent/internal/trade"
 )
 
 // --- Configuration ---
+// Defaults used when NewLLMService is given an empty model or base URL.
 const (
-	LLM_API_URL = "https://api.anthropic.com/v1/messages"
-	MODEL_NAME  = "claude-sonnet-4-6"
+	LLM_BASE_URL = "https://api.anthropic.com"
+	MODEL_NAME   = "claude-sonnet-4-6"
+
+	defaultMaxTokens = 1000
+
+	defaultMaxAttempts    = 3
+	defaultRetryBaseDelay = time.Second
 )
 
 // --- Structs for JSON Response ---
@@ -31,17 +41,41 @@ const (
 // --- Service ---
 type LLMService struct {
 	ApiKey         string
+	BaseURL        string        // Messages API root, e.g. a proxy or compatible local endpoint
+	Model          string        // model id sent with every request
+	MaxTokens      int           // max_tokens per call; 0 = defaultMaxTokens
+	RetryMaxTokens int           // budget for one retry after a max_tokens stop; 0 = no retry
+	MaxAttempts    int           // tries per request on 429/5xx/timeouts; <= 1 = no retry
+	RetryBaseDelay time.Duration // first backoff; doubles per attempt, plus up to 50% jitter
 	Client         *http.Client
 	MaxDailyTokens int
+	PromptVersion  string     // system prompt template under prompts/; empty = DefaultPromptVersion
+	MAPeriods      []int      // chart MA periods named in the prompt; empty = 7, 25, 99
+	ChartTheme     plot.Theme // chart palette the prompt's MA legend names; zero = binance
 	dailyTokens    atomic.Int64
+	totalTokens    atomic.Int64 // every call since construction; never reset
 	lastResetDay   atomic.Int64 // year*1000+dayOfYear; reset counter when this changes
 }
 
-func NewLLMService(apiKey string, maxDailyTokens int) *LLMService {
+// NewLLMService builds a client; empty model or baseURL fall back to
+// MODEL_NAME and LLM_BASE_URL.
+func NewLLMService(apiKey, model, baseURL string, maxDailyTokens int) *LLMService {
+	if model == "" {
+		model = MODEL_NAME
+	}
+	if baseURL == "" {
+		baseURL = LLM_BASE_URL
+	}
 	return &LLMService{
 		ApiKey:         apiKey,
+		BaseURL:        strings.TrimSuffix(baseURL, "/"),
+		Model:          model,
+		MaxTokens:      defaultMaxTokens,
+		MaxAttempts:    defaultMaxAttempts,
+		RetryBaseDelay: defaultRetryBaseDelay,
 		Client:         &http.Client{Timeout: 60 * time.Second},
 		MaxDailyTokens: maxDailyTokens,
+		PromptVersion:  DefaultPromptVersion,
 	}
 }
 
@@ -57,125 +91,153 @@ func (s *LLMService) resetDailyTokensIfNeeded() {
 //   - Calculates Slope Statistics (Consensus)
 //   - Injects the "Skeptical Risk Manager" System Prompt
 //   - Prepares the Multimodal User Content
+//
+// It is a composition of BuildConsensus, RenderSystemPrompt, BuildUserPrompt
+// and base64 encoding of the chart, each testable on its own. The system prompt
+// comes from the PromptVersion template.
 func (s *LLMService) GenerateTradingPrompt(
 	currentTime string,
 	matches []embedding.PatternLabel,
 	matches1h []embedding.PatternLabel,
-	chartPathCandel string,
+	chartPNG []byte,
 	pnlData []trade.PositionHistory,
 	regimes map[string]exchange.IntervalRegime,
 	dailyPnL float64,
+	rsi *float64,
 	symbol string,
 ) (string, string, string, error) {
+	main, hourly := BuildConsensus(matches), BuildConsensus(matches1h)
+	systemMessage, err := RenderSystemPrompt(s.PromptVersion, SystemPromptData{Symbol: symbol, Main: main, Hourly: hourly, MAPeriods: s.MAPeriods, ChartTheme: s.ChartTheme})
+	if err != nil {
+		return "", "", "", err
+	}
+	userContent := BuildUserPrompt(pnlData, regimes, main, hourly, dailyPnL, rsi)
 
-	var cleanData []HistoricalDetail
-	var cleanData1H []HistoricalDetail
-	var slopes []float64
-	var slopes1H []float64
-
-	for _, m := range matches {
-		slope := m.NextSlope3
-		if slope == 0 {
-			slope = m.NextSlope5
-		}
-		slopes = append(slopes, slope)
+	if len(chartPNG) == 0 {
+		return "", "", "", fmt.Errorf("empty candle chart")
+	}
 
-		trendDir := "DOWN"
-		if slope > 0 {
-			trendDir = "UP"
-		}
+	return systemMessage, userContent, base64.StdEncoding.EncodeToString(chartPNG), nil
+}
 
-		// Calculate basic similarity % (1.0 - Distance)
-		// Distance usually 0.0 to 1.0 (Cosine Distance)
-		// If Distance is > 1.0 (Euclidean), this might need adjustment,
-		// but for Cosine, (1-Dist)*100 is a good proxy.
-		simScore := (1.0 - m.Distance) * 100
-		if simScore < 0 {
-			simScore = 0
-		}
+// 2. GenerateSignal executes the request
+func (s *LLMService) GenerateSignal(ctx context.Context, systemPrompt, userText, imgB_B64 string) (*TradeSignal, error) {
+	start := time.Now()
+	signal, err := s.generateSignal(ctx, systemPrompt, userText, imgB_B64)
+	metrics.ObserveLLMCall(start, err)
+	return signal, err
+}
 
-		cleanData = append(cleanData, HistoricalDetail{
-			Time:            m.Time.Format("2006-01-02 15:04"),
-			TrendSlope:      fmt.Sprintf("%.6f", slope),
-			TrendOutcome:    trendDir,
-			ImmediateReturn: fmt.Sprintf("%.4f%%", m.NextReturn*100),
-			Distance:        fmt.Sprintf("%.4f", m.Distance), // <--- Populated
-			Similarity:      fmt.Sprintf("%.1f%%", simScore), // <--- Populated
-		})
+func (s *LLMService) generateSignal(ctx context.Context, systemPrompt, userText, imgB_B64 string) (*TradeSignal, error) {
+	s.resetDailyTokensIfNeeded()
+	if s.MaxDailyTokens > 0 && s.dailyTokens.Load() >= int64(s.MaxDailyTokens) {
+		return nil, fmt.Errorf("daily token budget exhausted (%d tokens used)", s.dailyTokens.Load())
 	}
 
-	for _, m := range matches1h {
-		slope := m.NextSlope3
-		if slope == 0 {
-			slope = m.NextSlope5
-		}
-		slopes1H = append(slopes1H, slope)
-
-		trendDir := "DOWN"
-		if slope > 0 {
-			trendDir = "UP"
+	maxTokens := s.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+	contentStr, stopReason, usage, err := s.requestWithBackoff(ctx, systemPrompt, userText, imgB_B64, maxTokens)
+	if err != nil {
+		return nil, err
+	}
+	if isTruncated(stopReason) && s.RetryMaxTokens > maxTokens {
+		log.Printf("[LLMService] response truncated at %d tokens, retrying with %d", maxTokens, s.RetryMaxTokens)
+		var retryUsage Usage
+		contentStr, stopReason, retryUsage, err = s.requestWithBackoff(ctx, systemPrompt, userText, imgB_B64, s.RetryMaxTokens)
+		if err != nil {
+			return nil, err
 		}
+		usage = usage.Add(retryUsage)
+	}
+	rawContent := contentStr
 
-		// Calculate basic similarity % (1.0 - Distance)
-		// Distance usually 0.0 to 1.0 (Cosine Distance)
-		// If Distance is > 1.0 (Euclidean), this might need adjustment,
-		// but for Cosine, (1-Dist)*100 is a good proxy.
-		simScore := (1.0 - m.Distance) * 100
-		if simScore < 0 {
-			simScore = 0
-		}
+	// Clean JSON (remove markdown ticks)
+	contentStr = strings.ReplaceAll(contentStr, "```json", "")
+	contentStr = strings.ReplaceAll(contentStr, "```", "")
+	contentStr = strings.TrimSpace(contentStr)
 
-		cleanData1H = append(cleanData1H, HistoricalDetail{
-			Time:            m.Time.Format("2006-01-02 15:04"),
-			TrendSlope:      fmt.Sprintf("%.6f", slope),
-			TrendOutcome:    trendDir,
-			ImmediateReturn: fmt.Sprintf("%.4f%%", m.NextReturn*100),
-			Distance:        fmt.Sprintf("%.4f", m.Distance), // <--- Populated
-			Similarity:      fmt.Sprintf("%.1f%%", simScore), // <--- Populated
-		})
+	// Unmarshal
+	var signal TradeSignal
+	if err := json.Unmarshal([]byte(contentStr), &signal); err != nil {
+		log.Printf("⚠️ JSON Parse Fail (stop_reason=%s). Raw Content: %s", stopReason, contentStr)
+		return nil, err
 	}
+	signal.RawResponse = rawContent
+	signal.FinishReason = stopReason
+	signal.Usage = usage
 
-	// Calculate Consensus
-	avgSlope := 0.0
-	positiveTrends := 0
-	for _, s := range slopes {
-		avgSlope += s
-		if s > 0 {
-			positiveTrends++
-		}
-	}
-	if len(slopes) > 0 {
-		avgSlope /= float64(len(slopes))
-	}
+	return &signal, nil
+}
 
-	// historicalJson, _ := json.MarshalIndent(cleanData, "", "  ")
+// apiStatusError is a non-200 reply from the Messages API.
+type apiStatusError struct {
+	StatusCode int
+	Body       string
+}
 
-	systemMessage := GetBasePrompt(symbol)
-	systemMessage += GetPromptConstraint()
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("API Error %d: %s", e.StatusCode, e.Body)
+}
 
-	regime4h := regimes["4h"].Result
-	regime1d := regimes["1d"].Result
-	userContent := FormatUserPrompt(pnlData, regime4h, regime1d, cleanData, cleanData1H, dailyPnL)
+// requestWithBackoff retries requestMessage on transient failures with
+// jittered exponential backoff. Other 4xx errors return immediately.
+func (s *LLMService) requestWithBackoff(ctx context.Context, systemPrompt, userText, imgB_B64 string, maxTokens int) (string, string, Usage, error) {
+	attempts := max(s.MaxAttempts, 1)
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := backoffDelay(s.RetryBaseDelay, attempt)
+			log.Printf("[LLMService] attempt %d/%d failed (%v), retrying in %s", attempt, attempts, lastErr, delay)
+			select {
+			case <-ctx.Done():
+				return "", "", Usage{}, fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			case <-time.After(delay):
+			}
+		}
 
-	b64Canle, err := encodeImage(chartPathCandel)
-	if err != nil {
-		return "", "", "", err
+		text, stopReason, usage, err := s.requestMessage(ctx, systemPrompt, userText, imgB_B64, maxTokens)
+		if err == nil {
+			return text, stopReason, usage, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil || !isRetryable(err) {
+			return "", "", Usage{}, err
+		}
 	}
+	return "", "", Usage{}, fmt.Errorf("giving up after %d attempts: %w", attempts, lastErr)
+}
 
-	return systemMessage, userContent, b64Canle, nil
+// isRetryable: 429, 5xx, and client-side timeouts.
+func isRetryable(err error) bool {
+	var apiErr *apiStatusError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
 }
 
-// 2. GenerateSignal executes the request
-func (s *LLMService) GenerateSignal(ctx context.Context, systemPrompt, userText, imgB_B64 string) (*TradeSignal, error) {
-	s.resetDailyTokensIfNeeded()
-	if s.MaxDailyTokens > 0 && s.dailyTokens.Load() >= int64(s.MaxDailyTokens) {
-		return nil, fmt.Errorf("daily token budget exhausted (%d tokens used)", s.dailyTokens.Load())
+// backoffDelay returns base*2^(attempt-1) plus up to 50% jitter.
+func backoffDelay(base time.Duration, attempt int) time.Duration {
+	d := base << (attempt - 1)
+	if d <= 0 {
+		return 0
 	}
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
 
+// requestMessage sends one Messages API call and returns the first text block
+// together with the API's stop_reason and the call's token usage.
+func (s *LLMService) requestMessage(ctx context.Context, systemPrompt, userText, imgB_B64 string, maxTokens int) (string, string, Usage, error) {
 	// Construct Payload matching Anthropic Messages API spec
 	payload := map[string]interface{}{
-		"model":      MODEL_NAME,
-		"max_tokens": 1000,
+		"model":      s.Model,
+		"max_tokens": maxTokens,
 		"system": []map[string]interface{}{
 			{
 				"type": "text",
@@ -209,9 +271,9 @@ func (s *LLMService) GenerateSignal(ctx context.Context, systemPrompt, userText,
 	}
 
 	jsonBytes, _ := json.Marshal(payload)
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", s.BaseURL+"/v1/messages", bytes.NewBuffer(jsonBytes))
 	if err != nil {
-		return nil, err
+		return "", "", Usage{}, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -220,61 +282,101 @@ func (s *LLMService) GenerateSignal(ctx context.Context, systemPrompt, userText,
 
 	resp, err := s.Client.Do(req)
 	if err != nil {
-		return nil, err
+		return "", "", Usage{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		body, _ := ioutil.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API Error %d: %s", resp.StatusCode, string(body))
+		return "", "", Usage{}, &apiStatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	// Parse Response
 	var result map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+		return "", "", Usage{}, err
 	}
 
-	// Accumulate token usage for daily budget tracking
-	if usage, ok := result["usage"].(map[string]interface{}); ok {
-		in, _ := usage["input_tokens"].(float64)
-		out, _ := usage["output_tokens"].(float64)
-		used := int64(in + out)
-		total := s.dailyTokens.Add(used)
-		log.Printf("[LLMService] tokens this call: %d | daily total: %d", used, total)
+	// Accumulate token usage for daily budget and running-total tracking
+	usage := parseUsage(result["usage"])
+	if usage.TotalTokens > 0 {
+		daily := s.dailyTokens.Add(int64(usage.TotalTokens))
+		s.totalTokens.Add(int64(usage.TotalTokens))
+		log.Printf("[LLMService] tokens this call: %d (prompt %d, completion %d) | daily total: %d",
+			usage.TotalTokens, usage.PromptTokens, usage.CompletionTokens, daily)
 	}
 
 	// Safely extract content (Anthropic format: content[0].text)
 	contentBlocks, ok := result["content"].([]interface{})
 	if !ok || len(contentBlocks) == 0 {
-		return nil, fmt.Errorf("invalid response format from LLM")
+		return "", "", usage, fmt.Errorf("invalid response format from LLM")
 	}
 	firstBlock := contentBlocks[0].(map[string]interface{})
 	contentStr, ok := firstBlock["text"].(string)
 	if !ok {
-		return nil, fmt.Errorf("unexpected content block type: %v", firstBlock["type"])
+		return "", "", usage, fmt.Errorf("unexpected content block type: %v", firstBlock["type"])
 	}
+	stopReason, _ := result["stop_reason"].(string)
+	return contentStr, stopReason, usage, nil
+}
 
-	// Clean JSON (remove markdown ticks)
-	contentStr = strings.ReplaceAll(contentStr, "```json", "")
-	contentStr = strings.ReplaceAll(contentStr, "```", "")
-	contentStr = strings.TrimSpace(contentStr)
-
-	// Unmarshal
-	var signal TradeSignal
-	if err := json.Unmarshal([]byte(contentStr), &signal); err != nil {
-		log.Printf("⚠️ JSON Parse Fail. Raw Content: %s", contentStr)
-		return nil, err
+// parseUsage reads the usage object in either the Messages API spelling
+// (input_tokens/output_tokens) or the OpenAI-compatible one
+// (prompt_tokens/completion_tokens/total_tokens). A missing total is summed.
+func parseUsage(raw interface{}) Usage {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return Usage{}
+	}
+	num := func(keys ...string) int {
+		for _, k := range keys {
+			if v, ok := m[k].(float64); ok {
+				return int(v)
+			}
+		}
+		return 0
+	}
+	u := Usage{
+		PromptTokens:     num("input_tokens", "prompt_tokens"),
+		CompletionTokens: num("output_tokens", "completion_tokens"),
+		TotalTokens:      num("total_tokens"),
 	}
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	return u
+}
 
-	return &signal, nil
+// TotalTokens is every token billed through this service since it was built,
+// unlike the daily budget counter which resets at UTC midnight.
+func (s *LLMService) TotalTokens() int64 {
+	return s.totalTokens.Load()
+}
+
+// isTruncated reports whether the model stopped because it hit max_tokens
+// ("length" is the OpenAI-compatible spelling).
+func isTruncated(stopReason string) bool {
+	return stopReason == "max_tokens" || stopReason == "length"
 }
 
-// Helper
-func encodeImage(path string) (string, error) {
-	bytes, err := os.ReadFile(path)
+// Ping validates the API key with a cheap model-list call (no tokens billed).
+func (s *LLMService) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.BaseURL+"/v1/models?limit=1", nil)
 	if err != nil {
-		return "", err
+		return err
+	}
+	req.Header.Set("x-api-key", s.ApiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("API Error %d: %s", resp.StatusCode, string(body))
 	}
-	return base64.StdEncoding.EncodeToString(bytes), nil
+	return nil
 }
@@ -0,0 +1,215 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLLMService points the service at a stub Messages API.
+func newTestLLMService(t *testing.T, handler http.HandlerFunc) *LLMService {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	s := NewLLMService("test-key", "", "", 0)
+	s.BaseURL = srv.URL
+	return s
+}
+
+// messagesResponse builds an Anthropic Messages API body with one text block.
+func messagesResponse(text, stopReason string) map[string]any {
+	return map[string]any{
+		"content":     []map[string]any{{"type": "text", "text": text}},
+		"stop_reason": stopReason,
+		"usage":       map[string]any{"input_tokens": 10, "output_tokens": 20},
+	}
+}
+
+func TestGenerateSignal_KeepsRawResponse(t *testing.T) {
+	raw := "```json\n{\"signal\":\"LONG\",\"confidence\":72}\n```"
+	s := newTestLLMService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(messagesResponse(raw, "end_turn"))
+	})
+
+	signal, err := s.GenerateSignal(context.Background(), "sys", "user", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "LONG", signal.Signal)
+	assert.Equal(t, raw, signal.RawResponse)
+	assert.Equal(t, "end_turn", signal.FinishReason)
+}
+
+func TestGenerateSignal_TruncatedResponse_RetriesWithHigherBudget(t *testing.T) {
+	var budgets []float64
+	s := newTestLLMService(t, func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		budgets = append(budgets, req["max_tokens"].(float64))
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(budgets) == 1 {
+			json.NewEncoder(w).Encode(messagesResponse(`{"signal":"SHORT","synthesis":"The tape`, "max_tokens"))
+			return
+		}
+		json.NewEncoder(w).Encode(messagesResponse(`{"signal":"SHORT","confidence":64}`, "end_turn"))
+	})
+	s.MaxTokens = 1000
+	s.RetryMaxTokens = 2500
+
+	signal, err := s.GenerateSignal(context.Background(), "sys", "user", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1000, 2500}, budgets)
+	assert.Equal(t, "SHORT", signal.Signal)
+	assert.Equal(t, "end_turn", signal.FinishReason)
+}
+
+func TestGenerateSignal_TruncatedResponse_NoRetryConfigured(t *testing.T) {
+	calls := 0
+	s := newTestLLMService(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(messagesResponse(`{"signal":"SHO`, "max_tokens"))
+	})
+
+	_, err := s.GenerateSignal(context.Background(), "sys", "user", "")
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestNewLLMService_Defaults(t *testing.T) {
+	s := NewLLMService("k", "", "", 0)
+	assert.Equal(t, MODEL_NAME, s.Model)
+	assert.Equal(t, LLM_BASE_URL, s.BaseURL)
+
+	s = NewLLMService("k", "claude-haiku-4-5", "http://localhost:8080/", 0)
+	assert.Equal(t, "claude-haiku-4-5", s.Model)
+	assert.Equal(t, "http://localhost:8080", s.BaseURL)
+}
+
+func TestGenerateSignal_SendsConfiguredModel(t *testing.T) {
+	var model string
+	s := newTestLLMService(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		model = body.Model
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(messagesResponse(`{"signal":"HOLD","confidence":0}`, "end_turn"))
+	})
+	s.Model = "claude-haiku-4-5"
+
+	_, err := s.GenerateSignal(context.Background(), "sys", "user", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "claude-haiku-4-5", model)
+}
+
+func TestGenerateSignal_RetriesTransientFailures(t *testing.T) {
+	calls := 0
+	s := newTestLLMService(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			http.Error(w, `{"error":"rate limited"}`, http.StatusTooManyRequests)
+		case 2:
+			http.Error(w, `{"error":"overloaded"}`, http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(messagesResponse(`{"signal":"LONG","confidence":70}`, "end_turn"))
+		}
+	})
+	s.RetryBaseDelay = time.Millisecond
+
+	signal, err := s.GenerateSignal(context.Background(), "sys", "user", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, "LONG", signal.Signal)
+}
+
+func TestGenerateSignal_ClientErrorNotRetried(t *testing.T) {
+	calls := 0
+	s := newTestLLMService(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+	})
+	s.RetryBaseDelay = time.Millisecond
+
+	_, err := s.GenerateSignal(context.Background(), "sys", "user", "")
+
+	assert.ErrorContains(t, err, "API Error 400")
+	assert.Equal(t, 1, calls)
+}
+
+func TestGenerateSignal_GivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	s := newTestLLMService(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	})
+	s.RetryBaseDelay = time.Millisecond
+	s.MaxAttempts = 2
+
+	_, err := s.GenerateSignal(context.Background(), "sys", "user", "")
+
+	assert.ErrorContains(t, err, "giving up after 2 attempts")
+	assert.Equal(t, 2, calls)
+}
+
+func TestGenerateSignal_AttachesUsageAndAccumulates(t *testing.T) {
+	s := newTestLLMService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"content": [{"type": "text", "text": "{\"signal\":\"HOLD\",\"confidence\":0}"}],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 1200, "output_tokens": 85}
+		}`))
+	})
+
+	first, err := s.GenerateSignal(context.Background(), "sys", "user", "")
+	assert.NoError(t, err)
+	second, err := s.GenerateSignal(context.Background(), "sys", "user", "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, Usage{PromptTokens: 1200, CompletionTokens: 85, TotalTokens: 1285}, first.Usage)
+	assert.Equal(t, first.Usage, second.Usage)
+	assert.Equal(t, int64(2570), s.TotalTokens())
+}
+
+func TestGenerateSignal_UsageIncludesTruncationRetry(t *testing.T) {
+	calls := 0
+	s := newTestLLMService(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if calls == 1 {
+			json.NewEncoder(w).Encode(messagesResponse(`{"signal":"SHO`, "max_tokens"))
+			return
+		}
+		json.NewEncoder(w).Encode(messagesResponse(`{"signal":"SHORT","confidence":64}`, "end_turn"))
+	})
+	s.RetryMaxTokens = 2500
+
+	signal, err := s.GenerateSignal(context.Background(), "sys", "user", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, Usage{PromptTokens: 20, CompletionTokens: 40, TotalTokens: 60}, signal.Usage)
+	assert.Equal(t, int64(60), s.TotalTokens())
+}
+
+func TestParseUsage_OpenAICompatibleSpelling(t *testing.T) {
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(`{"usage":{"prompt_tokens":900,"completion_tokens":100,"total_tokens":1010}}`), &body))
+
+	assert.Equal(t, Usage{PromptTokens: 900, CompletionTokens: 100, TotalTokens: 1010}, parseUsage(body["usage"]))
+	assert.Equal(t, Usage{}, parseUsage(nil))
+}
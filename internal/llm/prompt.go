@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/trade"
+)
+
+// PromptBuilder produces the system prompt, user text and base64 chart for one
+// decision from the rendered candle chart PNG. LLMService implements it; tests can swap in a stub.
+type PromptBuilder interface {
+	GenerateTradingPrompt(
+		currentTime string,
+		matches []embedding.PatternLabel,
+		matches1h []embedding.PatternLabel,
+		chartPNG []byte,
+		pnlData []trade.PositionHistory,
+		regimes map[string]exchange.IntervalRegime,
+		dailyPnL float64,
+		rsi *float64,
+		symbol string,
+	) (string, string, string, error)
+}
+
+var _ PromptBuilder = (*LLMService)(nil)
+
+// Consensus is the prompt-ready summary of one match set.
+type Consensus struct {
+	Details    []HistoricalDetail
+	AvgSlope   float64 // mean forward slope (slope_3, falling back to slope_5)
+	Up         int
+	Down       int
+	ReturnLean float64 // ReturnWeightedConsensus of the same matches
+}
+
+// BuildConsensus formats each match for the prompt and computes the slope
+// statistics over the set.
+func BuildConsensus(matches []embedding.PatternLabel) Consensus {
+	c := Consensus{ReturnLean: ReturnWeightedConsensus(matches)}
+	for _, m := range matches {
+		slope := m.NextSlope3
+		if slope == 0 {
+			slope = m.NextSlope5
+		}
+		c.AvgSlope += slope
+
+		trendDir := "DOWN"
+		if slope > 0 {
+			trendDir = "UP"
+			c.Up++
+		} else {
+			c.Down++
+		}
+
+		// Calculate basic similarity % (1.0 - Distance)
+		// Distance usually 0.0 to 1.0 (Cosine Distance)
+		// If Distance is > 1.0 (Euclidean), this might need adjustment,
+		// but for Cosine, (1-Dist)*100 is a good proxy.
+		simScore := (1.0 - m.Distance) * 100
+		if simScore < 0 {
+			simScore = 0
+		}
+
+		c.Details = append(c.Details, HistoricalDetail{
+			Time:            m.Time.Format("2006-01-02 15:04"),
+			TrendSlope:      fmt.Sprintf("%.6f", slope),
+			TrendOutcome:    trendDir,
+			ImmediateReturn: fmt.Sprintf("%.4f%%", m.NextReturn*100),
+			Distance:        fmt.Sprintf("%.4f", m.Distance),
+			Similarity:      fmt.Sprintf("%.1f%%", simScore),
+		})
+	}
+	if len(matches) > 0 {
+		c.AvgSlope /= float64(len(matches))
+	}
+	return c
+}
+
+// BuildSystemPrompt is the DefaultPromptVersion persona plus the JSON output
+// contract.
+func BuildSystemPrompt(symbol string) string {
+	return GetBasePrompt(symbol) + GetPromptConstraint()
+}
+
+// BuildUserPrompt renders PnL, regime and both timeframes' matches.
+func BuildUserPrompt(
+	pnlData []trade.PositionHistory,
+	regimes map[string]exchange.IntervalRegime,
+	main Consensus,
+	hourly Consensus,
+	dailyPnL float64,
+	rsi *float64, // nil = not computed, section omitted
+) string {
+	return FormatUserPrompt(pnlData, regimes["4h"].Result, regimes["1d"].Result, main.Details, hourly.Details, dailyPnL, main.ReturnLean, rsi)
+}
+
+// EncodeImages reads each chart file and returns it base64-encoded, in order.
+func EncodeImages(paths ...string) ([]string, error) {
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		raw, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("encode image %s: %w", p, err)
+		}
+		out = append(out, base64.StdEncoding.EncodeToString(raw))
+	}
+	return out, nil
+}
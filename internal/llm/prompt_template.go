@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"time-series-rag-agent/internal/plot"
+)
+
+// DefaultPromptVersion is rendered when LLMService.PromptVersion is empty.
+const DefaultPromptVersion = "v1"
+
+// promptFS holds one directory per prompt version. Each must define
+// system.tmpl; v1 composes it from base.tmpl (persona and decision pipeline)
+// and output.tmpl (the JSON contract). The text is not passed through
+// fmt, so a literal "%%" in a template reaches the model as-is.
+//
+//go:embed prompts
+var promptFS embed.FS
+
+// SystemPromptData is what a system prompt template can reference. v1 uses
+// Symbol and MALegend; the consensus sets are there for variants that want to
+// steer the persona by the current matches.
+type SystemPromptData struct {
+	Symbol     string
+	Main       Consensus  // main-timeframe matches
+	Hourly     Consensus  // 1h matches
+	MAPeriods  []int      // MAs drawn on the candle chart; empty = 7, 25, 99
+	ChartTheme plot.Theme // palette the chart is drawn with; zero = binance
+}
+
+// MALegend describes the chart's moving averages in the active theme's
+// colours, e.g. "MA(7) orange, MA(25) purple, MA(99) pink".
+func (d SystemPromptData) MALegend() string {
+	periods := d.MAPeriods
+	if len(periods) == 0 {
+		periods = plot.DefaultMAPeriods
+	}
+	parts := make([]string, 0, len(periods))
+	for i, p := range periods {
+		if p > 0 {
+			parts = append(parts, fmt.Sprintf("MA(%d) %s", p, d.ChartTheme.MAColorName(i)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+var defaultPromptTemplates = template.Must(parsePromptVersion(DefaultPromptVersion))
+
+// parsePromptVersion loads every template under prompts/<version>/.
+func parsePromptVersion(version string) (*template.Template, error) {
+	if version == "" || strings.ContainsAny(version, `/\.`) {
+		return nil, fmt.Errorf("invalid prompt version %q", version)
+	}
+	t, err := template.New(version).Option("missingkey=error").ParseFS(promptFS, "prompts/"+version+"/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("prompt version %q: %w", version, err)
+	}
+	return t, nil
+}
+
+func execPrompt(t *template.Template, name string, data SystemPromptData) (string, error) {
+	var sb strings.Builder
+	if err := t.ExecuteTemplate(&sb, name, data); err != nil {
+		return "", fmt.Errorf("render prompt %s: %w", name, err)
+	}
+	return sb.String(), nil
+}
+
+// RenderSystemPrompt renders system.tmpl of the given version; empty means
+// DefaultPromptVersion.
+func RenderSystemPrompt(version string, data SystemPromptData) (string, error) {
+	t := defaultPromptTemplates
+	if version != "" && version != DefaultPromptVersion {
+		var err error
+		if t, err = parsePromptVersion(version); err != nil {
+			return "", err
+		}
+	}
+	return execPrompt(t, "system.tmpl", data)
+}
+
+// mustRenderDefault renders a v1 template; it is embedded and parsed at init,
+// so a failure here is a programming error.
+func mustRenderDefault(name string, data SystemPromptData) string {
+	out, err := execPrompt(defaultPromptTemplates, name, data)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// GetBasePrompt is the v1 persona and decision pipeline for symbol.
+func GetBasePrompt(symbol string) string {
+	return mustRenderDefault("base.tmpl", SystemPromptData{Symbol: symbol})
+}
+
+// GetPromptConstraint is the v1 JSON output contract.
+func GetPromptConstraint() string {
+	return mustRenderDefault("output.tmpl", SystemPromptData{})
+}
@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/plot"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSystemPrompt_V1MatchesGolden(t *testing.T) {
+	want, err := os.ReadFile("testdata/system_v1_ETHUSDT.golden")
+	require.NoError(t, err)
+
+	got, err := RenderSystemPrompt("v1", SystemPromptData{Symbol: "ETHUSDT"})
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+
+	def, err := RenderSystemPrompt("", SystemPromptData{Symbol: "ETHUSDT"})
+	require.NoError(t, err)
+	assert.Equal(t, got, def, "empty version falls back to v1")
+}
+
+func TestRenderSystemPrompt_KeySectionsWithConsensus(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	data := SystemPromptData{
+		Symbol: "BTCUSDT",
+		Main:   BuildConsensus([]embedding.PatternLabel{{Time: ts, NextSlope3: 0.002, NextReturn: 0.01, Distance: 0.1}}),
+		Hourly: BuildConsensus([]embedding.PatternLabel{{Time: ts, NextSlope5: -0.004, NextReturn: -0.01, Distance: 0.2}}),
+	}
+
+	p, err := RenderSystemPrompt(DefaultPromptVersion, data)
+
+	require.NoError(t, err)
+	for _, section := range []string{
+		"ROLE", "PRIORITY OF EVIDENCE", "COST REALITY", "DECISION PIPELINE",
+		"Step 4 - Confluence score", "OBSERVABILITY REQUIREMENT", "# OUTPUT - JSON ONLY",
+	} {
+		assert.Contains(t, p, section)
+	}
+	assert.Contains(t, p, "Binance Futures BTCUSDT Perpetual")
+	assert.Contains(t, p, "~ 1.1%% of margin", "v1 keeps its literal %% text")
+	assert.NotContains(t, p, "{{")
+}
+
+func TestRenderSystemPrompt_UnknownVersion(t *testing.T) {
+	_, err := RenderSystemPrompt("v999", SystemPromptData{})
+	assert.Error(t, err)
+	_, err = RenderSystemPrompt("../prompts", SystemPromptData{})
+	assert.Error(t, err)
+}
+
+func TestRenderSystemPrompt_MAPeriodsMatchChart(t *testing.T) {
+	p, err := RenderSystemPrompt("", SystemPromptData{Symbol: "ETHUSDT", MAPeriods: []int{5, 20}})
+
+	require.NoError(t, err)
+	assert.Contains(t, p, "Candles + volume bars. MA(5) orange, MA(20) purple.")
+	assert.NotContains(t, p, "MA(99)")
+}
+
+func TestRenderSystemPrompt_MALegendFollowsChartTheme(t *testing.T) {
+	p, err := RenderSystemPrompt("", SystemPromptData{Symbol: "ETHUSDT", ChartTheme: plot.ThemeHighContrast})
+
+	require.NoError(t, err)
+	assert.Contains(t, p, "MA(7) black, MA(25) blue, MA(99) orange.")
+}
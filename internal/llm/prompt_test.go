@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildConsensus_SlopeStatsAndFormatting(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	c := BuildConsensus([]embedding.PatternLabel{
+		{Time: ts, NextSlope3: 0.002, NextReturn: 0.0125, Distance: 0.1},
+		{Time: ts, NextSlope5: -0.004, NextReturn: -0.01, Distance: 1.3}, // slope_3 missing → slope_5
+	})
+
+	assert.InDelta(t, -0.001, c.AvgSlope, 1e-12)
+	assert.Equal(t, 1, c.Up)
+	assert.Equal(t, 1, c.Down)
+	assert.InDelta(t, ReturnWeightedConsensus([]embedding.PatternLabel{{NextReturn: 0.0125}, {NextReturn: -0.01}}), c.ReturnLean, 1e-12)
+
+	require.Len(t, c.Details, 2)
+	assert.Equal(t, HistoricalDetail{
+		Time: "2026-01-02 03:04", TrendSlope: "0.002000", TrendOutcome: "UP",
+		ImmediateReturn: "1.2500%", Distance: "0.1000", Similarity: "90.0%",
+	}, c.Details[0])
+	assert.Equal(t, "-0.004000", c.Details[1].TrendSlope)
+	assert.Equal(t, "DOWN", c.Details[1].TrendOutcome)
+	assert.Equal(t, "0.0%", c.Details[1].Similarity, "similarity clamps at zero")
+}
+
+func TestBuildConsensus_Empty(t *testing.T) {
+	c := BuildConsensus(nil)
+	assert.Zero(t, c.AvgSlope)
+	assert.Empty(t, c.Details)
+}
+
+func TestBuildSystemPrompt_NamesSymbolAndContract(t *testing.T) {
+	p := BuildSystemPrompt("SOLUSDT")
+	assert.Contains(t, p, "SOLUSDT")
+	assert.Contains(t, p, GetPromptConstraint())
+}
+
+func TestBuildUserPrompt_IncludesBothTimeframes(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	// FormatPatternMatches drops the first row (the bar matching itself).
+	self := embedding.PatternLabel{Time: ts.Add(-time.Hour)}
+	main := BuildConsensus([]embedding.PatternLabel{self, {Time: ts, NextSlope3: 0.1, NextReturn: 0.02}})
+	hourly := BuildConsensus([]embedding.PatternLabel{self, {Time: ts.Add(time.Hour), NextSlope3: -0.1}})
+
+	p := BuildUserPrompt(nil, map[string]exchange.IntervalRegime{}, main, hourly, 1.5, nil)
+
+	assert.Contains(t, p, "2026-01-02 03:04")
+	assert.Contains(t, p, "2026-01-02 04:04")
+	assert.Contains(t, p, "Return-weighted lean (rwl): +1.00")
+}
+
+func TestBuildUserPrompt_RSI(t *testing.T) {
+	rsi := 63.456
+
+	with := BuildUserPrompt(nil, map[string]exchange.IntervalRegime{}, Consensus{}, Consensus{}, 0, &rsi)
+	without := BuildUserPrompt(nil, map[string]exchange.IntervalRegime{}, Consensus{}, Consensus{}, 0, nil)
+
+	assert.Contains(t, with, "# MOMENTUM:\nRSI (Wilder, last bar): 63.46")
+	assert.NotContains(t, without, "MOMENTUM")
+}
+
+func TestEncodeImages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chart.png")
+	require.NoError(t, os.WriteFile(path, []byte("png-bytes"), 0o644))
+
+	out, err := EncodeImages(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{base64.StdEncoding.EncodeToString([]byte("png-bytes"))}, out)
+
+	_, err = EncodeImages(path, filepath.Join(t.TempDir(), "missing.png"))
+	assert.Error(t, err)
+}
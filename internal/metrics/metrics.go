@@ -0,0 +1,96 @@
+// Package metrics holds the live bot's Prometheus collectors. They live on a
+// private Registry rather than the global default so tests start clean and
+// /metrics only exposes what the bot records plus the Go runtime.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "rag_agent"
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	// Signals counts every final decision, including local HOLDs.
+	Signals = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signals_total",
+		Help:      "Decisions reported by the live pipeline, by symbol and signal.",
+	}, []string{"symbol", "signal"})
+
+	// TradesPlaced counts entries whose orders were accepted.
+	TradesPlaced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "trades_placed_total",
+		Help:      "Entries placed on the exchange, by symbol and side.",
+	}, []string{"symbol", "side"})
+
+	// Errors counts pipeline failures by the stage that reported them.
+	Errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Live pipeline errors, by stage.",
+	}, []string{"stage"})
+
+	// LLMCallDuration times GenerateSignal, retries included.
+	LLMCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_call_duration_seconds",
+		Help:      "GenerateSignal latency, by result (ok or error).",
+		Buckets:   []float64{1, 2, 5, 10, 20, 30, 60, 120},
+	}, []string{"result"})
+
+	// StageDuration times each live-loop stage (fetch, feature, search,
+	// chart, llm, trade) as recorded by the pipeline's StageTimer.
+	StageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "stage_duration_seconds",
+		Help:      "Live pipeline stage latency, by stage.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2.5, 10), // 10ms .. ~38s
+	}, []string{"stage"})
+)
+
+func init() {
+	Registry.MustRegister(
+		Signals, TradesPlaced, Errors, LLMCallDuration, StageDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveLLMCall records one GenerateSignal call that started at start.
+func ObserveLLMCall(start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	LLMCallDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
+
+// ObserveStages records a bar's stage durations.
+func ObserveStages(stages map[string]time.Duration) {
+	for name, d := range stages {
+		StageDuration.WithLabelValues(name).Observe(d.Seconds())
+	}
+}
+
+// RegisterLLMTokens exposes total, the shared LLM client's running token
+// count, as llm_tokens_total. Call it once per process.
+func RegisterLLMTokens(total func() int64) error {
+	return Registry.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "Tokens billed by the LLM client since startup.",
+	}, func() float64 { return float64(total()) }))
+}
+
+// Handler serves Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ScrapesRecordedMetrics(t *testing.T) {
+	before := testutil.ToFloat64(Signals.WithLabelValues("ETHUSDT", "LONG"))
+	Signals.WithLabelValues("ETHUSDT", "LONG").Inc()
+	ObserveLLMCall(time.Now().Add(-3*time.Second), errors.New("timeout"))
+	ObserveStages(map[string]time.Duration{"feature": 40 * time.Millisecond})
+	require.NoError(t, RegisterLLMTokens(func() int64 { return 1234 }))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(Signals.WithLabelValues("ETHUSDT", "LONG")))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body, _ := io.ReadAll(rec.Body)
+
+	assert.Contains(t, string(body), `rag_agent_signals_total{signal="LONG",symbol="ETHUSDT"}`)
+	assert.Contains(t, string(body), `rag_agent_llm_call_duration_seconds_count{result="error"} 1`)
+	assert.Contains(t, string(body), `rag_agent_stage_duration_seconds_count{stage="feature"} 1`)
+	assert.Contains(t, string(body), "rag_agent_llm_tokens_total 1234")
+	assert.Contains(t, string(body), "go_goroutines")
+}
@@ -6,12 +6,26 @@ import (
 	"log/slog"
 	"time"
 	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/embedding"
 	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/storage"
 	"time-series-rag-agent/internal/storage/postgresql"
 
 	"github.com/adshao/go-binance/v2/futures"
 )
 
+// savePatterns writes backfilled features, then their labels, to any
+// storage.PatternStore, so a backfill can also seed memory.Store.
+func savePatterns(ctx context.Context, store storage.PatternStore, symbol, interval string, features []embedding.PatternFeature, labels []embedding.LabelUpdate) error {
+	if err := store.BulkUpsertFeature(ctx, features); err != nil {
+		return fmt.Errorf("BulkUpsertFeature: %w", err)
+	}
+	if err := store.UpsertLabels(ctx, symbol, interval, labels); err != nil {
+		return fmt.Errorf("UpsertLabels: %w", err)
+	}
+	return nil
+}
+
 func NewBackfillPipeline(ctx context.Context, logger *slog.Logger, symbol string, interval string, limit int, vectorWindow int, dayLookback int) error {
 	logger.Info("[BackfillPipeline] Starting Embedding Pipeline")
 	cfg := config.LoadConfig()
@@ -19,13 +33,22 @@ func NewBackfillPipeline(ctx context.Context, logger *slog.Logger, symbol string
 
 	endTime := time.Now()
 	startTime := endTime.AddDate(0, 0, -dayLookback)
-	restCandle, err := exchange.FetchHistoryByTime(binanceClient, symbol, interval, startTime, endTime)
+	restCandle, err := exchange.FetchHistoryByTime(binanceClient, symbol, interval, startTime, endTime,
+		func(pct float64, eta time.Duration) {
+			logger.Info(fmt.Sprintf("[BackfillPipeline] fetched %.1f%% | ETA %s", pct, eta.Round(time.Second)))
+		},
+	)
 	if err != nil {
 		logger.Error(fmt.Sprintf("[BackfillPipeline] REST candle fetch: %v", err))
 		return err
 	}
 
-	feature, label := NewBackfillEmbeddingPipeline(*logger, restCandle, symbol, interval, vectorWindow)
+	returnType, err := embedding.ParseReturnType(cfg.Embedding.ReturnType)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[BackfillPipeline] embedding config: %v", err))
+		return err
+	}
+	feature, label := NewBackfillEmbeddingPipeline(*logger, restCandle, symbol, interval, vectorWindow, returnType, cfg.Embedding.ZClip, cfg.Embedding.WithVolume, cfg.Embedding.RSIPeriod, cfg.Embedding.SlopeWindows)
 
 	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
 		cfg.Database.DBUser,
@@ -34,24 +57,34 @@ func NewBackfillPipeline(ctx context.Context, logger *slog.Logger, symbol string
 		cfg.Database.DBPort,
 		cfg.Database.DBName,
 	)
-	db, err := postgresql.NewPostgresDB(ctx, connString, *logger)
+	db, err := postgresql.NewPostgresDB(ctx, connString, postgresql.PoolConfigFrom(cfg.Database), *logger)
 	if err != nil {
 		logger.Error(fmt.Sprintf("[BackfillPipeline] DB connection: %v", err))
 		return err
 	}
 	defer db.Close()
-
-	if err := db.BulkUpsertFeature(ctx, feature); err != nil {
-		logger.Error(fmt.Sprintf("[BackfillPipeline] BulkUpsertFeature: %v", err))
+	db.SetPersistWindow(cfg.Database.PersistWindow)
+	db.SetEmbeddingDim(cfg.Database.EmbeddingDim)
+	db.SetReadOnly(cfg.Database.ReadOnly)
+	// The vector index waits until after ingest; see below.
+	if err := db.Migrate(ctx, cfg.Embedding.SlopeWindows, 0); err != nil {
+		logger.Error(fmt.Sprintf("[BackfillPipeline] Migrate: %v", err))
 		return err
 	}
-	logger.Info("[BackfillPipeline] Ingested feature")
 
-	if err := db.UpsertLabels(ctx, symbol, interval, label); err != nil {
-		logger.Error(fmt.Sprintf("[BackfillPipeline] UpsertLabels: %v", err))
+	if err := savePatterns(ctx, db, symbol, interval, feature, label); err != nil {
+		logger.Error(fmt.Sprintf("[BackfillPipeline] %v", err))
 		return err
 	}
-	logger.Info("[BackfillPipeline] Ingested label")
+	logger.Info("[BackfillPipeline] Ingested feature and label", "features", len(feature), "labels", len(label))
+
+	// ivfflat clusters the rows present at build time, so build it after ingest.
+	if cfg.Search.IVFFlatLists > 0 {
+		if err := db.EnsureVectorIndex(ctx, cfg.Search.IVFFlatLists); err != nil {
+			logger.Error(fmt.Sprintf("[BackfillPipeline] EnsureVectorIndex: %v", err))
+			return err
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,42 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/storage/memory"
+)
+
+func TestSavePatterns_SeedsMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	features := []embedding.PatternFeature{
+		{Time: time.Unix(900, 0), Symbol: "ETHUSDT", Interval: "15m", Embedding: []float64{1, 0}},
+		{Time: time.Unix(1800, 0), Symbol: "ETHUSDT", Interval: "15m", Embedding: []float64{0, 1}},
+	}
+	labels := []embedding.LabelUpdate{
+		{TargetTime: 900, Column: "next_return", Value: 0.01},
+		{TargetTime: 900, Column: "next_slope_10", Value: 0.003},
+	}
+
+	require.NoError(t, savePatterns(ctx, store, "ETHUSDT", "15m", features, labels))
+
+	got, err := store.QueryTopN(ctx, "ETHUSDT", "15m", []float64{1, 0}, 1)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, int64(900), got[0].Time.Unix())
+	assert.Equal(t, 0.01, got[0].NextReturn)
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestSavePatterns_LabelErrorIsWrapped(t *testing.T) {
+	err := savePatterns(context.Background(), memory.NewStore(), "ETHUSDT", "15m", nil,
+		[]embedding.LabelUpdate{{TargetTime: 900, Column: "bogus"}})
+
+	assert.ErrorContains(t, err, "UpsertLabels")
+}
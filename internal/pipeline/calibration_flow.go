@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"time-series-rag-agent/internal/storage/postgresql"
+)
+
+// CalibrationBucket is one confidence decile of the calibration report.
+type CalibrationBucket struct {
+	Low, High int // inclusive confidence range, e.g. 70-79 (the top bucket is 90-100)
+	Signals   int
+	Wins      int
+	WinRate   float64 // percent of Signals that won; 0 when Signals is 0
+}
+
+// signalWon reports whether the next bar moved the way the signal called it.
+func signalWon(o postgresql.SignalOutcome) bool {
+	if o.Signal == "SHORT" {
+		return o.NextReturn < 0
+	}
+	return o.NextReturn > 0
+}
+
+// BucketCalibration groups outcomes into ten confidence deciles and computes
+// the realized win rate of each, so the stated confidence can be compared with
+// how often it was right. Confidence is clamped to 0-100.
+func BucketCalibration(outcomes []postgresql.SignalOutcome) []CalibrationBucket {
+	buckets := make([]CalibrationBucket, 10)
+	for i := range buckets {
+		buckets[i].Low = i * 10
+		buckets[i].High = i*10 + 9
+	}
+	buckets[9].High = 100
+
+	for _, o := range outcomes {
+		i := min(max(o.Confidence, 0), 100) / 10
+		if i == 10 {
+			i = 9
+		}
+		buckets[i].Signals++
+		if signalWon(o) {
+			buckets[i].Wins++
+		}
+	}
+	for i := range buckets {
+		if buckets[i].Signals > 0 {
+			buckets[i].WinRate = float64(buckets[i].Wins) / float64(buckets[i].Signals) * 100
+		}
+	}
+	return buckets
+}
+
+// FormatCalibrationReport renders the non-empty buckets for terminal output.
+func FormatCalibrationReport(buckets []CalibrationBucket) string {
+	var sb strings.Builder
+	sb.WriteString("confidence | signals | wins | win rate\n")
+	total, wins := 0, 0
+	for _, b := range buckets {
+		if b.Signals == 0 {
+			continue
+		}
+		total += b.Signals
+		wins += b.Wins
+		sb.WriteString(fmt.Sprintf("%3d-%-3d    | %7d | %4d | %6.1f%%\n", b.Low, b.High, b.Signals, b.Wins, b.WinRate))
+	}
+	if total == 0 {
+		sb.WriteString("no labelled LONG/SHORT signals in range\n")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("all        | %7d | %4d | %6.1f%%\n", total, wins, float64(wins)/float64(total)*100))
+	return sb.String()
+}
@@ -0,0 +1,40 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"time-series-rag-agent/internal/storage/postgresql"
+)
+
+func TestBucketCalibration_WinRatesPerDecile(t *testing.T) {
+	outcomes := []postgresql.SignalOutcome{
+		{Signal: "LONG", Confidence: 55, NextReturn: 0.01},   // win
+		{Signal: "SHORT", Confidence: 58, NextReturn: 0.01},  // loss
+		{Signal: "LONG", Confidence: 72, NextReturn: 0.002},  // win
+		{Signal: "SHORT", Confidence: 75, NextReturn: -0.01}, // win
+		{Signal: "LONG", Confidence: 79, NextReturn: -0.01},  // loss
+		{Signal: "LONG", Confidence: 79, NextReturn: 0.003},  // win
+		{Signal: "SHORT", Confidence: 100, NextReturn: 0},    // flat = loss
+	}
+
+	b := BucketCalibration(outcomes)
+
+	assert.Len(t, b, 10)
+	assert.Equal(t, CalibrationBucket{Low: 50, High: 59, Signals: 2, Wins: 1, WinRate: 50}, b[5])
+	assert.Equal(t, CalibrationBucket{Low: 70, High: 79, Signals: 4, Wins: 3, WinRate: 75}, b[7])
+	assert.Equal(t, CalibrationBucket{Low: 90, High: 100, Signals: 1, Wins: 0, WinRate: 0}, b[9])
+	assert.Zero(t, b[6].Signals)
+}
+
+func TestFormatCalibrationReport_SkipsEmptyBuckets(t *testing.T) {
+	report := FormatCalibrationReport(BucketCalibration([]postgresql.SignalOutcome{
+		{Signal: "LONG", Confidence: 65, NextReturn: 0.01},
+	}))
+
+	assert.Contains(t, report, " 60-69")
+	assert.NotContains(t, report, " 70-79")
+	assert.Contains(t, report, "100.0%")
+	assert.Contains(t, FormatCalibrationReport(BucketCalibration(nil)), "no labelled")
+}
@@ -13,7 +13,10 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-func RestIngestVectorFlow(logger *slog.Logger, symbol string, interval string, vectorSize int) error {
+// RestIngestVectorFlow fetches symbol's history from klines, the venue the
+// live bars come from, and ingests its latest feature and labels. A nil
+// klines reads Binance.
+func RestIngestVectorFlow(logger *slog.Logger, klines exchange.KlineService, symbol string, interval string, vectorSize int) error {
 	logger.Info("[RestIngestVectorFlow] Start multiple ingest data")
 
 	cfg := config.LoadConfig()
@@ -34,7 +37,7 @@ func RestIngestVectorFlow(logger *slog.Logger, symbol string, interval string, v
 
 	g1.Go(func() error {
 		var err error
-		dbIngest, err = postgresql.NewPostgresDB(ctx1, connString, *logger)
+		dbIngest, err = postgresql.NewPostgresDB(ctx1, connString, postgresql.PoolConfigFrom(cfg.Database), *logger)
 		if err != nil {
 			return fmt.Errorf("connect db: %w", err)
 		}
@@ -43,28 +46,20 @@ func RestIngestVectorFlow(logger *slog.Logger, symbol string, interval string, v
 	})
 
 	g1.Go(func() error {
-		binanceClient, err := exchange.NewBinanceClient(ctx1, cfg)
-		if err != nil {
-			return fmt.Errorf("new binance client: %w", err)
+		if klines == nil {
+			binanceClient, err := exchange.NewBinanceClient(ctx1, cfg)
+			if err != nil {
+				return fmt.Errorf("new binance client: %w", err)
+			}
+			klines = exchange.NewBinanceAdapter(binanceClient)
 		}
-		adapter := exchange.NewBinanceAdapter(binanceClient)
 
-		restCandle, err := exchange.FetchLatestCandles(ctx1, adapter, symbol, interval, vectorSize+1+99)
+		restCandle, err := exchange.FetchLatestCandles(ctx1, klines, symbol, interval, vectorSize+1+99)
 		if err != nil {
 			return fmt.Errorf("fetch candles: %w", err)
 		}
 
-		wsRestCandle = make([]exchange.WsRestCandle, len(restCandle))
-		for i, c := range restCandle {
-			wsRestCandle[i] = exchange.WsRestCandle{
-				Time:   c.Time,
-				Open:   c.Open,
-				High:   c.High,
-				Low:    c.Low,
-				Close:  c.Close,
-				Volume: c.Volume,
-			}
-		}
+		wsRestCandle = exchange.RestToWsRest(restCandle)
 		logger.Info("[RestIngestVectorFlow] Candles fetched", "count", len(wsRestCandle))
 		return nil
 	})
@@ -73,6 +68,12 @@ func RestIngestVectorFlow(logger *slog.Logger, symbol string, interval string, v
 		return fmt.Errorf("[RestIngestVectorFlow] phase 1: %w", err)
 	}
 	defer dbIngest.Close()
+	dbIngest.SetPersistWindow(cfg.Database.PersistWindow)
+	dbIngest.SetEmbeddingDim(cfg.Database.EmbeddingDim)
+	dbIngest.SetReadOnly(cfg.Database.ReadOnly)
+	if err := dbIngest.Migrate(ctx, cfg.Embedding.SlopeWindows, cfg.Search.IVFFlatLists); err != nil {
+		return fmt.Errorf("[RestIngestVectorFlow] migrate: %w", err)
+	}
 
 	// ── Phase 2: Calculate feature + label (concurrent) ──
 	var (
@@ -83,14 +84,22 @@ func RestIngestVectorFlow(logger *slog.Logger, symbol string, interval string, v
 	g2, _ := errgroup.WithContext(ctx)
 
 	g2.Go(func() error {
-		fc := embedding.NewFeatureCalculator(symbol, interval, vectorSize)
-		feature = fc.Calculate(wsRestCandle)
+		fc, err := NewConfiguredFeatureCalculator(cfg.Embedding, symbol, interval, vectorSize)
+		if err != nil {
+			return err
+		}
+		feature, err = fc.CalculateE(wsRestCandle)
+		if err != nil {
+			logger.Error("[RestIngestVectorFlow] Feature calculation failed", "error", err)
+			return fmt.Errorf("calculate feature: %w", err)
+		}
 		logger.Info("[RestIngestVectorFlow] Feature calculated")
 		return nil
 	})
 
 	g2.Go(func() error {
 		lb := embedding.NewLabelCalculator()
+		lb.SlopeWindows = cfg.Embedding.SlopeWindows
 		label = lb.CalculateFromHistory(wsRestCandle)
 		logger.Info("[RestIngestVectorFlow] Label calculated")
 		return nil
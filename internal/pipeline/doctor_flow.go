@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/llm"
+	"time-series-rag-agent/internal/storage/postgresql"
+)
+
+// DoctorCheck is one named startup dependency probe.
+type DoctorCheck struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// DoctorResult is the outcome of a single DoctorCheck.
+type DoctorResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// NewDoctorChecks wires the real dependency probes from config:
+// DB + pgvector, Binance server time, LLM API key, and AWS secret access.
+func NewDoctorChecks(cfg *config.AppConfig) []DoctorCheck {
+	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
+		cfg.Database.DBUser, cfg.Database.DBPassword,
+		cfg.Database.DBHost, cfg.Database.DBPort, cfg.Database.DBName,
+	)
+
+	return []DoctorCheck{
+		{Name: "postgres + pgvector", Run: func(ctx context.Context) error {
+			db, err := postgresql.NewPostgresDB(ctx, connString, postgresql.PoolConfigFrom(cfg.Database), *slog.Default())
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			return db.HealthCheck(ctx)
+		}},
+		{Name: "binance server time", Run: func(ctx context.Context) error {
+			_, err := exchange.NewBinanceClient(ctx, cfg)
+			return err
+		}},
+		{Name: "llm api key", Run: func(ctx context.Context) error {
+			if cfg.OpenRouter.ApiKey == "" {
+				return fmt.Errorf("OPENAI_API_KEY not set")
+			}
+			return llm.NewLLMService(cfg.OpenRouter.ApiKey, cfg.OpenRouter.Model, cfg.OpenRouter.BaseURL, 0).Ping(ctx)
+		}},
+		{Name: "aws secrets manager", Run: func(ctx context.Context) error {
+			return config.CheckAwsAccess()
+		}},
+	}
+}
+
+// RunDoctor executes every check (no fail-fast) with a per-check timeout and
+// returns the results in input order.
+func RunDoctor(ctx context.Context, checks []DoctorCheck, timeout time.Duration) []DoctorResult {
+	results := make([]DoctorResult, len(checks))
+	for i, c := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		err := c.Run(checkCtx)
+		cancel()
+		results[i] = DoctorResult{Name: c.Name, Err: err, Duration: time.Since(start)}
+	}
+	return results
+}
+
+// FormatDoctorReport renders results as a pass/fail table and reports whether
+// every check passed.
+func FormatDoctorReport(results []DoctorResult) (string, bool) {
+	var sb strings.Builder
+	allOK := true
+	for _, r := range results {
+		status := "✅ PASS"
+		detail := ""
+		if r.Err != nil {
+			status = "❌ FAIL"
+			detail = " — " + r.Err.Error()
+			allOK = false
+		}
+		sb.WriteString(fmt.Sprintf("%s  %-22s (%s)%s\n", status, r.Name, r.Duration.Round(time.Millisecond), detail))
+	}
+	return sb.String(), allOK
+}
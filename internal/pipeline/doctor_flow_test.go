@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func healthy(ctx context.Context) error { return nil }
+
+func TestRunDoctor_AllHealthy(t *testing.T) {
+	checks := []DoctorCheck{
+		{Name: "postgres + pgvector", Run: healthy},
+		{Name: "binance server time", Run: healthy},
+		{Name: "llm api key", Run: healthy},
+		{Name: "aws secrets manager", Run: healthy},
+	}
+
+	results := RunDoctor(context.Background(), checks, time.Second)
+	report, ok := FormatDoctorReport(results)
+
+	assert.True(t, ok)
+	assert.Len(t, results, 4)
+	for _, r := range results {
+		assert.NoError(t, r.Err)
+		assert.Contains(t, report, r.Name)
+	}
+	assert.NotContains(t, report, "FAIL")
+}
+
+func TestRunDoctor_OneFailureDoesNotStopOthers(t *testing.T) {
+	ran := 0
+	checks := []DoctorCheck{
+		{Name: "db", Run: func(ctx context.Context) error { ran++; return errors.New("connection refused") }},
+		{Name: "binance", Run: func(ctx context.Context) error { ran++; return nil }},
+	}
+
+	results := RunDoctor(context.Background(), checks, time.Second)
+	report, ok := FormatDoctorReport(results)
+
+	assert.False(t, ok)
+	assert.Equal(t, 2, ran)
+	assert.Contains(t, report, "connection refused")
+}
@@ -1,12 +1,29 @@
 package pipeline
 
 import (
+	"fmt"
 	"log/slog"
 
+	"time-series-rag-agent/config"
 	"time-series-rag-agent/internal/embedding"
 	"time-series-rag-agent/internal/exchange"
 )
 
+// NewConfiguredFeatureCalculator applies the EMBEDDING_* settings, so stored
+// and query vectors, and the version searches filter on, share one recipe.
+func NewConfiguredFeatureCalculator(cfg config.EmbeddingConfig, symbol, interval string, vectorWindow int) (*embedding.FeatureCalculator, error) {
+	returnType, err := embedding.ParseReturnType(cfg.ReturnType)
+	if err != nil {
+		return nil, err
+	}
+	fc := embedding.NewFeatureCalculator(symbol, interval, vectorWindow)
+	fc.ReturnType = returnType
+	fc.ZClip = cfg.ZClip
+	fc.WithVolume = cfg.WithVolume
+	fc.RSIPeriod = cfg.RSIPeriod
+	return fc, nil
+}
+
 func NewEmbeddingPipeline(
 	logger slog.Logger,
 	wsCandle []exchange.WsCandle,
@@ -14,20 +31,45 @@ func NewEmbeddingPipeline(
 	vectorSize int,
 	symbol string,
 	interval string,
-) (*embedding.PatternFeature, []embedding.LabelUpdate, []exchange.WsRestCandle) {
+	tol embedding.ContinuityTolerance,
+	returnType embedding.ReturnType,
+	zClip float64,
+	withVolume bool,
+	rsiPeriod int,
+	slopeWindows []int,
+) (*embedding.PatternFeature, []embedding.LabelUpdate, []exchange.WsRestCandle, error) {
 	logger.Info("[EmbeddingPipeline] Starting Embedding Pipeline")
+	duration, err := parseBinanceInterval(interval)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("parse interval: %w", err)
+	}
+
 	// -- Features -- //
 	fc := embedding.NewFeatureCalculator(symbol, interval, vectorSize)
-	wsRestCandle := embedding.MergeCandles(wsCandle, restCandle)
+	fc.ReturnType = returnType
+	fc.ZClip = zClip
+	fc.WithVolume = withVolume
+	fc.RSIPeriod = rsiPeriod
+	wsRestCandle, err := embedding.SafeMerge(wsCandle, restCandle, int64(duration.Seconds()), tol)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("merge candles: %w", err)
+	}
 
+	if len(wsRestCandle) < vectorSize+1 {
+		return nil, nil, nil, fmt.Errorf("merge candles: got %d candles, need %d", len(wsRestCandle), vectorSize+1)
+	}
 	featureCalculateCandle := wsRestCandle[len(wsRestCandle)-(vectorSize+1):]
-	feature := fc.Calculate(featureCalculateCandle)
+	feature, err := fc.CalculateE(featureCalculateCandle)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("calculate feature: %w", err)
+	}
 
 	// -- Labels -- //
 	lc := embedding.NewLabelCalculator()
+	lc.SlopeWindows = slopeWindows
 	label := lc.CalculateFromHistory(featureCalculateCandle)
 
-	return feature, label, wsRestCandle
+	return feature, label, wsRestCandle, nil
 }
 
 func NewBackfillEmbeddingPipeline(
@@ -36,27 +78,32 @@ func NewBackfillEmbeddingPipeline(
 	symbol string,
 	interval string,
 	vectorWindow int,
+	returnType embedding.ReturnType,
+	zClip float64,
+	withVolume bool,
+	rsiPeriod int,
+	slopeWindows []int,
 ) ([]embedding.PatternFeature, []embedding.LabelUpdate) {
 	logger.Info("[EmbeddingPipeline] Starting Backfill Pipeline")
 
 	fc := embedding.NewFeatureCalculator(symbol, interval, vectorWindow)
+	fc.ReturnType = returnType
+	fc.ZClip = zClip
+	fc.WithVolume = withVolume
+	fc.RSIPeriod = rsiPeriod
 	lc := embedding.NewLabelCalculator()
+	lc.SlopeWindows = slopeWindows
 
 	// Convert once
-	inputData := make([]exchange.WsRestCandle, len(restCandles))
-	for i, c := range restCandles {
-		inputData[i] = exchange.WsRestCandle{
-			Time: c.Time, Open: c.Open, High: c.High,
-			Low: c.Low, Close: c.Close, Volume: c.Volume,
-		}
-	}
+	inputData := exchange.RestToWsRest(restCandles)
 
 	var features []embedding.PatternFeature
 	var labels []embedding.LabelUpdate
 
 	for i := vectorWindow; i < len(inputData); i++ {
-		feature := fc.Calculate(inputData[i-vectorWindow : i+1])
-		if feature == nil {
+		feature, err := fc.CalculateE(inputData[i-vectorWindow : i+1])
+		if err != nil {
+			logger.Warn("[EmbeddingPipeline] Skipping backfill window", "time", inputData[i].Time, "error", err)
 			continue
 		}
 		features = append(features, *feature)
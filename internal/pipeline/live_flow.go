@@ -2,55 +2,141 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 	"sync"
 	"time"
 	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/cooldown"
+	"time-series-rag-agent/internal/embedding"
 	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/llm"
+	"time-series-rag-agent/internal/metrics"
+	"time-series-rag-agent/internal/plot"
 	"time-series-rag-agent/internal/prefilter"
 	"time-series-rag-agent/internal/storage/postgresql"
 	"time-series-rag-agent/internal/trade"
+	logfmt "time-series-rag-agent/pkg/logger"
 	pkg "time-series-rag-agent/pkg/notifier"
 
 	"github.com/adshao/go-binance/v2/futures"
 	"golang.org/x/sync/errgroup"
 )
 
+// patternGuard outlives a single bar so repeated patterns are remembered
+// across NewLivePipeline calls.
+var patternGuard = cooldown.NewPatternGuard()
+
+// warmup starts at process start, so the first bars after a restart only
+// analyze.
+var warmup = NewWarmupGate(time.Now())
+
+// emptyMatches tracks consecutive no-match bars across NewLivePipeline calls.
+var emptyMatches = NewEmptyMatchMonitor()
+
+// LiveDeps are resources a caller keeps across bars. A nil field is built for
+// the bar and released with it, which is all NewLivePipeline does; the
+// multi-symbol engine shares Store, Ingest, LLM and Orders between symbols and
+// gives each symbol its own Config and Executor.
+type LiveDeps struct {
+	Klines   exchange.KlineService // the streaming venue's REST history; nil = Binance
+	Config   *config.AppConfig     // symbol's config, see AppConfig.ForSymbol
+	Executor *exchange.Executor
+	Store    *postgresql.PatternStore // set up as OpenLiveStore does
+	LLM      *llm.LLMService
+	Orders   sync.Locker             // held while placing an entry on the shared balance; nil = sole trader
+	Ingest   *postgresql.IngestQueue // batches feature and label writes into Store; nil = write directly
+}
+
 func NewLivePipeline(ctx context.Context, logger *slog.Logger, binanceClient *futures.Client, hooks *pkg.PipelineHooks, wsCandle []exchange.WsCandle, symbol string, interval string, vectorSize int, wsClose float64) error {
-	logger.Info("[LivePipeline] Starting Embedding Pipeline")
-	cfg := config.LoadConfig()
-	adapter := exchange.NewBinanceAdapter(binanceClient)
+	return RunLivePipeline(ctx, LiveDeps{}, logger, binanceClient, hooks, wsCandle, symbol, interval, vectorSize, wsClose)
+}
+
+// NewLiveExecutor builds symbol's executor with the configured risk settings.
+func NewLiveExecutor(binanceClient *futures.Client, symbol string, cfg *config.AppConfig, logger *slog.Logger) *exchange.Executor {
+	executor := exchange.NewExecutor(
+		binanceClient,
+		symbol,
+		cfg.Agent.AviableTradeRatio,
+		cfg.Agent.Leverage,
+		cfg.Agent.SLPercentage,
+		cfg.Agent.TPPercentage,
+		*logger,
+	)
+	executor.CloseVerifyRetries = cfg.Agent.CloseVerifyRetries
+	executor.BreakevenR = cfg.Agent.BreakevenR
+	executor.BreakevenBuffer = cfg.Agent.BreakevenBuffer
+	executor.LimitExitTimeout = time.Duration(cfg.Agent.MaxHoldLimitExitSecs) * time.Second
+	return executor
+}
 
+// OpenLiveStore connects the pattern store the live pipeline ingests into and
+// searches, with the configured write and search settings.
+func OpenLiveStore(ctx context.Context, cfg *config.AppConfig, logger *slog.Logger) (*postgresql.PatternStore, error) {
+	fc, err := NewConfiguredFeatureCalculator(cfg.Embedding, "", "", 0)
+	if err != nil {
+		return nil, err
+	}
 	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
 		cfg.Database.DBUser, cfg.Database.DBPassword,
 		cfg.Database.DBHost, cfg.Database.DBPort, cfg.Database.DBName,
 	)
+	db, err := postgresql.NewPostgresDB(ctx, connString, postgresql.PoolConfigFrom(cfg.Database), *logger)
+	if err != nil {
+		return nil, err
+	}
+	db.SetPersistWindow(cfg.Database.PersistWindow)
+	db.SetEmbeddingDim(cfg.Database.EmbeddingDim)
+	db.SetReadOnly(cfg.Database.ReadOnly)
+	db.SetSearchParams(cfg.Search.HNSWEfSearch, cfg.Search.IVFFlatProbes)
+	db.SetEmbeddingVersion(fc.Version())
+	return db, nil
+}
+
+// RunLivePipeline is NewLivePipeline with the caller's long-lived resources.
+func RunLivePipeline(ctx context.Context, deps LiveDeps, logger *slog.Logger, binanceClient *futures.Client, hooks *pkg.PipelineHooks, wsCandle []exchange.WsCandle, symbol string, interval string, vectorSize int, wsClose float64) error {
+	logger.Info("[LivePipeline] Starting Embedding Pipeline")
+	timer := NewStageTimer(time.Now)
+	ctx = withStageTimer(ctx, timer)
+	defer func() {
+		logger.Info("[LivePipeline] stage timings", append([]any{"symbol", symbol}, timer.LogAttrs()...)...)
+		metrics.ObserveStages(timer.Durations())
+	}()
+	cfg := deps.Config
+	if cfg == nil {
+		cfg = config.LoadConfig()
+	}
+	var adapter exchange.KlineService = exchange.NewBinanceAdapter(binanceClient)
+	if deps.Klines != nil {
+		adapter = deps.Klines
+	}
 
 	duration, err := parseBinanceInterval(interval)
 	if err != nil {
 		return fmt.Errorf("[LivePipeline] parse interval: %w", err)
 	}
 
-	executor := exchange.NewExecutor(
-		binanceClient,
-		symbol,
-		cfg.Agent.AviableTradeRatio,
-		cfg.Agent.Leverage,
-		cfg.Agent.SLPercentage,
-		cfg.Agent.TPPercentage,
-		*logger,
-	)
+	executor := deps.Executor
+	if executor == nil {
+		executor = NewLiveExecutor(binanceClient, symbol, cfg, logger)
+	}
+	llmService := deps.LLM
+	if llmService == nil {
+		llmService = LLMServiceFrom(cfg)
+	}
 
 	// --- 1) REST fetch + DB connect + Cooldown check in parallel (fail-fast) ---
 	var (
 		restCandle    []exchange.RestCandle
-		dbIngest      *postgresql.PatternStore
+		dbIngest      = deps.Store
+		ownStore      = deps.Store == nil
 		isInCooldown  bool
 		barsRemaining int
 	)
 
+	stopFetch := timer.Start("fetch")
 	g1, ctx1 := errgroup.WithContext(ctx)
 
 	g1.Go(func() error {
@@ -59,11 +145,13 @@ func NewLivePipeline(ctx context.Context, logger *slog.Logger, binanceClient *fu
 		return err
 	})
 
-	g1.Go(func() error {
-		var err error
-		dbIngest, err = postgresql.NewPostgresDB(ctx1, connString, *logger)
-		return err
-	})
+	if ownStore {
+		g1.Go(func() error {
+			var err error
+			dbIngest, err = OpenLiveStore(ctx1, cfg, logger)
+			return err
+		})
+	}
 
 	g1.Go(func() error {
 		var err error
@@ -71,17 +159,33 @@ func NewLivePipeline(ctx context.Context, logger *slog.Logger, binanceClient *fu
 		return err
 	})
 
-	if err := g1.Wait(); err != nil {
-		if dbIngest != nil {
+	err = g1.Wait()
+	stopFetch()
+	if err != nil {
+		if ownStore && dbIngest != nil {
 			dbIngest.Close()
 		}
 		hooks.OnPipelineError("init", err)
 		return fmt.Errorf("[LivePipeline] init: %w", err)
 	}
-	defer dbIngest.Close()
+	if ownStore {
+		defer dbIngest.Close()
+	}
 
 	// --- 2) Embedding (sequential, depends on restCandle + dbIngest) ---
-	feature, label, wsRestCandle := NewEmbeddingPipeline(*logger, wsCandle, restCandle, vectorSize, symbol, interval)
+	tol := embedding.ContinuityTolerance{MaxHealBars: cfg.Candle.GapHealBars, SlackSecs: cfg.Candle.GapSlackSecs}
+	returnType, err := embedding.ParseReturnType(cfg.Embedding.ReturnType)
+	if err != nil {
+		hooks.OnPipelineError("embedding", err)
+		return fmt.Errorf("[LivePipeline] embedding: %w", err)
+	}
+	stopFeature := timer.Start("feature")
+	feature, label, wsRestCandle, err := NewEmbeddingPipeline(*logger, wsCandle, restCandle, vectorSize, symbol, interval, tol, returnType, cfg.Embedding.ZClip, cfg.Embedding.WithVolume, cfg.Embedding.RSIPeriod, cfg.Embedding.SlopeWindows)
+	stopFeature()
+	if err != nil {
+		hooks.OnPipelineError("embedding", err)
+		return fmt.Errorf("[LivePipeline] embedding: %w", err)
+	}
 	if feature == nil {
 		hooks.OnPipelineError("embedding", fmt.Errorf("feature is nil"))
 		return fmt.Errorf("[LivePipeline] feature is nil")
@@ -92,25 +196,40 @@ func NewLivePipeline(ctx context.Context, logger *slog.Logger, binanceClient *fu
 	// --- 3) DB upserts (ทำเสมอ ไม่ว่าจะ cooldown หรือไม่) ---
 	g2, ctx2 := errgroup.WithContext(ctx)
 
-	g2.Go(func() error {
-		if err := dbIngest.UpsertFeature(ctx2, *feature); err != nil {
-			return fmt.Errorf("upsert feature: %w", err)
-		}
-		logger.Info("[LivePipeline] Ingested feature")
-		return nil
-	})
+	if deps.Ingest != nil {
+		// Flushed before anything below reads the store back.
+		deps.Ingest.Enqueue(feature, postgresql.LabelWrites(symbol, interval, label))
+		g2.Go(func() error {
+			if err := deps.Ingest.Flush(ctx2); err != nil {
+				return fmt.Errorf("ingest batch: %w", err)
+			}
+			logger.Info("[LivePipeline] Ingested feature and label")
+			return nil
+		})
+	} else {
+		g2.Go(func() error {
+			if err := dbIngest.UpsertFeature(ctx2, *feature); err != nil {
+				return fmt.Errorf("upsert feature: %w", err)
+			}
+			logger.Info("[LivePipeline] Ingested feature")
+			return nil
+		})
 
-	g2.Go(func() error {
-		if err := dbIngest.UpsertLabels(ctx2, symbol, interval, label); err != nil {
-			return fmt.Errorf("upsert labels: %w", err)
-		}
-		logger.Info("[LivePipeline] Ingested label")
-		return nil
-	})
+		g2.Go(func() error {
+			if err := dbIngest.UpsertLabels(ctx2, symbol, interval, label); err != nil {
+				return fmt.Errorf("upsert labels: %w", err)
+			}
+			logger.Info("[LivePipeline] Ingested label")
+			return nil
+		})
+	}
 
 	// TODO running only at 00 minute porint of time
 	g2.Go(func() error {
-		if err := RestIngestVectorFlow(logger, symbol, "1h", vectorSize); err != nil {
+		if cfg.Database.ReadOnly {
+			return nil
+		}
+		if err := RestIngestVectorFlow(logger, adapter, symbol, "1h", vectorSize); err != nil {
 			return fmt.Errorf("ingest 1h timeframe: %w", err)
 		}
 		logger.Info("[LivePipeline] Ingested 1 hour timeframe")
@@ -122,15 +241,47 @@ func NewLivePipeline(ctx context.Context, logger *slog.Logger, binanceClient *fu
 		return fmt.Errorf("[LivePipeline] phase 2: %w", err)
 	}
 
+	if !cfg.Database.ReadOnly {
+		if n, err := ReconcileLabels(ctx, dbIngest, symbol, interval, wsRestCandle, cfg.Embedding.SlopeWindows); err != nil {
+			logger.Warn("[LivePipeline] label reconciliation failed", "err", err)
+		} else if n > 0 {
+			logger.Info("[LivePipeline] Reconciled NULL labels", "labels", n)
+		}
+	}
+
 	hasPosition, side, _, err := executor.HasOpenPosition(ctx)
 	if err != nil {
 		return fmt.Errorf("[LivePipeline] Checking position error: %w", err)
 	}
 	if hasPosition {
+		maxHold := time.Duration(cfg.Agent.MaxHoldFor(symbol)) * duration
+		closed, err := executor.EnforceMaxHold(ctx, maxHold, time.Now(), wsClose)
+		if err != nil {
+			hooks.OnPipelineError("max-hold", err)
+			return fmt.Errorf("[LivePipeline] max hold: %w", err)
+		}
+		if closed {
+			hooks.OnOrderExecuted(symbol, "CLOSE", wsClose, "max hold exceeded", "", "")
+			return nil
+		}
+		if executor.BreakevenR > 0 {
+			if entry, err := executor.PositionEntryPrice(ctx); err != nil {
+				logger.Warn("[LivePipeline] breakeven: entry price", "err", err)
+			} else if err := executor.MoveStopToBreakeven(ctx, side, entry, wsClose); err != nil {
+				hooks.OnPipelineError("breakeven", err)
+			}
+		}
 		logger.Info("[LivePipeline] Active position or order, skipping LLM.", "side", side)
 		return nil
 	}
 
+	// --- 3.4) Flat → clean up stray reduce-only SL/TP before any early HOLD return ---
+	if n, err := executor.CancelOrphanedReduceOnlyOrders(ctx); err != nil {
+		logger.Warn("[LivePipeline] orphaned order cleanup failed", "err", err)
+	} else if n > 0 {
+		logger.Info("[LivePipeline] cancelled orphaned reduce-only orders", "count", n)
+	}
+
 	// --- 3.5) Cooldown check (หลัง upsert แล้ว ก่อน LLM) ---
 	if isInCooldown {
 		logger.Info("[LivePipeline] ⏸ in cooldown, skipping LLM + order",
@@ -158,6 +309,20 @@ func NewLivePipeline(ctx context.Context, logger *slog.Logger, binanceClient *fu
 		return nil
 	}
 
+	// --- 3.8) Daily max-loss circuit breaker (fails closed) ---
+	canTrade, dailyPnL, err := executor.CanTrade(ctx, cfg.Agent.MaxDailyLoss)
+	if err != nil {
+		logger.Error("[LivePipeline] daily loss check failed, skipping bar", "err", err)
+		hooks.OnOrderExecuted(symbol, "HOLD", wsClose, "daily loss check failed", "", "")
+		return nil
+	}
+	if !canTrade {
+		logger.Info("[LivePipeline] Daily loss limit reached, skipping order execution",
+			"pnl", dailyPnL, "limit", cfg.Agent.MaxDailyLoss)
+		hooks.OnOrderExecuted(symbol, "HOLD", wsClose, "daily loss limit", "", "")
+		return nil
+	}
+
 	// --- 3.9) Pre-filter gate — skip LLM on low-edge bars ---
 	pfResult := prefilter.RunPrefilter(prefilter.Input{
 		Candles:   wsRestCandle,
@@ -172,17 +337,42 @@ func NewLivePipeline(ctx context.Context, logger *slog.Logger, binanceClient *fu
 		return nil
 	}
 
+	// --- 3.95) Pattern dedupe — same nearest match traded too recently ---
+	var clusterID string
+	if cfg.Agent.PatternDedupeBars > 0 {
+		nearest, err := dbIngest.QueryTopN(ctx, symbol, interval, feature.Embedding, 2)
+		if err != nil {
+			logger.Warn("[LivePipeline] pattern dedupe lookup failed", "err", err)
+		} else {
+			clusterID = cooldown.NearestClusterID(nearest, feature.Time)
+		}
+		if patternGuard.IsSuppressed(symbol, clusterID, feature.Time, duration, cfg.Agent.PatternDedupeBars) {
+			logger.Info("[LivePipeline] same pattern traded recently, skipping LLM + order", "cluster", clusterID)
+			hooks.OnOrderExecuted(symbol, "HOLD", wsClose, "pattern dedupe", "", "")
+			return nil
+		}
+	}
+
 	// --- 4) LLM ---
-	llmOutput, err := NewLLMPatternAgent(
-		ctx, binanceClient, *logger, cfg, cfg.Database, cfg.OpenRouter,
-		symbol, interval, wsRestCandle, feature.Embedding, cfg.LLM.TopN,
+	llmOutput, err := runLLMPatternAgent(
+		ctx, dbIngest, llmService, binanceClient, *logger, cfg,
+		symbol, interval, wsRestCandle, feature.Embedding, feature.RSI, cfg.LLM.TopN,
 	)
+	if errors.Is(err, ErrNoActionablePattern) {
+		logger.Info("[LivePipeline] no actionable pattern — skipping LLM, emitting local HOLD", "err", err)
+		checkMatchHealth(ctx, logger, hooks, emptyMatches, dbIngest, symbol, interval, 0, cfg.Agent.EmptyMatchAlertBars)
+		hooks.OnOrderExecuted(symbol, "HOLD", wsClose, "no actionable pattern", "", "")
+		return nil
+	}
 	if err != nil {
 		hooks.OnPipelineError("llm", err)
 		return fmt.Errorf("[LivePipeline] llm: %w", err)
 	}
 	logger.Info(fmt.Sprint("Result from Agent: ", llmOutput))
 
+	// --- 4.1) Match health — empty search on a populated store hints at missing labels ---
+	checkMatchHealth(ctx, logger, hooks, emptyMatches, dbIngest, symbol, interval, llmOutput.MatchCount, cfg.Agent.EmptyMatchAlertBars)
+
 	signalLog := postgresql.TradeSignalLog{
 		Time:            feature.Time,
 		Symbol:          symbol,
@@ -196,6 +386,8 @@ func NewLivePipeline(ctx context.Context, logger *slog.Logger, binanceClient *fu
 		RiskNote:        llmOutput.RiskNote,
 		Invalidation:    llmOutput.Invalidation,
 		WsClose:         wsClose,
+		RawResponse:     llmOutput.RawResponse,
+		FinishReason:    llmOutput.FinishReason,
 	}
 
 	// fire-and-forget log insert — ไม่ block order path
@@ -211,22 +403,131 @@ func NewLivePipeline(ctx context.Context, logger *slog.Logger, binanceClient *fu
 	}()
 
 	// --- ต่อไปคือ order path ที่ไม่มีอะไรบล็อก ---
-	if llmOutput.Confidence < cfg.LLM.ConfidenceThreshold {
-		logger.Info("[LivePipeline] Low confidence, skipping order execution", "confidence", llmOutput.Confidence)
+	consensus := llm.ConsensusFloor{
+		Strong: cfg.LLM.ConsensusStrong,
+		Weak:   cfg.LLM.ConsensusWeak,
+		Adjust: cfg.LLM.ConsensusAdjust,
+	}
+	confidenceFloor := llm.ConfidenceFloor(cfg.LLM.ConfidenceThreshold, llmOutput.ConsensusStrength, consensus)
+	decision := llmOutput.Signal
+	if llmOutput.Confidence < confidenceFloor {
+		decision = "HOLD"
+	}
+	logger.Info(logfmt.DecisionMsg,
+		"symbol", symbol,
+		"signal", decision,
+		"confidence", llmOutput.Confidence,
+		"tier", consensus.Tier(llmOutput.ConsensusStrength),
+	)
+	if cfg.Webhook.URL != "" {
+		event := pkg.DecisionEvent{
+			Time: feature.Time, Symbol: symbol, Interval: interval,
+			Decision: decision, Price: wsClose, ConfidenceFloor: confidenceFloor,
+			Signal: llmOutput,
+		}
+		// fire-and-forget like the signal log: a slow endpoint must not delay the order
+		go func() {
+			postCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := pkg.NewWebhookClient(cfg.Webhook.URL, cfg.Webhook.Secret).Post(postCtx, event); err != nil {
+				logger.Warn("[LivePipeline] decision webhook", "err", err)
+			}
+		}()
+	}
+	if llmOutput.Confidence < confidenceFloor {
+		logger.Info("[LivePipeline] Low confidence, skipping order execution",
+			"confidence", llmOutput.Confidence,
+			"floor", confidenceFloor,
+			"consensus", fmt.Sprintf("%.2f", llmOutput.ConsensusStrength),
+		)
 		hooks.OnOrderExecuted(symbol, "HOLD", wsClose, "low confidence", "", "")
 		return nil
 	}
 
-	if err := NewOrderExecutionPipeline(ctx, *logger, binanceClient, symbol, llmOutput.Signal, wsClose); err != nil {
+	// --- 4.2) Warmup — analyzed above, but no orders right after startup ---
+	if remaining := warmup.BarsRemaining(feature.Time, duration, cfg.Agent.WarmupBars); remaining > 0 {
+		logger.Info("[LivePipeline] warming up, skipping order execution",
+			"signal", llmOutput.Signal, "bars_remaining", remaining)
+		hooks.OnOrderExecuted(symbol, "HOLD", wsClose, "warmup", "", "")
+		return nil
+	}
+
+	priceToOpen := entryPrice(ctx, logger, binanceClient, cfg.Agent.EntryPriceSource, symbol, llmOutput.Signal, wsRestCandle[len(wsRestCandle)-1], wsClose)
+
+	stopTrade := timer.Start("trade")
+	err = NewOrderExecutionPipeline(ctx, *logger, binanceClient, cfg, executor, deps.Orders, symbol, llmOutput.Signal, llmOutput.Confidence, priceToOpen, wsRestCandle)
+	stopTrade()
+	if err != nil {
 		hooks.OnPipelineError("order", err)
 		return fmt.Errorf("[LivePipeline] order execution: %w", err)
 	}
 
+	if llmOutput.Signal == "LONG" || llmOutput.Signal == "SHORT" {
+		patternGuard.RecordTrade(symbol, clusterID, feature.Time)
+		annotateChart(logger, cfg, symbol, interval, wsRestCandle, tradeLevels(cfg, llmOutput.Signal, llmOutput.Confidence, priceToOpen, wsRestCandle))
+	}
+
 	hooks.OnOrderExecuted(symbol, llmOutput.Signal, wsClose, llmOutput.Synthesis, llmOutput.PatternRead, llmOutput.PriceActionRead)
 
 	return nil
 }
 
+// entryPrice resolves the configured entry reference price, falling back to
+// the WS close whenever the source is invalid or the book can't be read.
+func entryPrice(ctx context.Context, logger *slog.Logger, client *futures.Client, source, symbol, side string, candle exchange.WsRestCandle, wsClose float64) float64 {
+	if side != "LONG" && side != "SHORT" {
+		return wsClose
+	}
+	src, err := exchange.ParsePriceSource(source)
+	if err != nil {
+		logger.Warn("[LivePipeline] entry price source, using close", "err", err)
+		return wsClose
+	}
+	if src == exchange.PriceClose {
+		return wsClose
+	}
+
+	var quote exchange.BookQuote
+	if src.NeedsBook() {
+		if quote, err = exchange.FetchBookQuote(ctx, client, symbol); err != nil {
+			logger.Warn("[LivePipeline] book quote failed, using close", "err", err)
+			return wsClose
+		}
+	}
+	price, err := exchange.EntryPrice(src, candle, side, quote)
+	if err != nil {
+		logger.Warn("[LivePipeline] entry price failed, using close", "err", err)
+		return wsClose
+	}
+	logger.Info("[LivePipeline] entry price", "source", src, "price", price, "close", wsClose)
+	return price
+}
+
+// tradeLevels recomputes the entry, SL and TP the order flow placed, using the
+// same leverage tier; an account leverage cap applied by ApplyLeverage is not
+// reflected.
+func tradeLevels(cfg *config.AppConfig, side string, confidence int, entry float64, candles []exchange.WsRestCandle) plot.Levels {
+	leverage := cfg.Agent.Leverage
+	if tiers, err := exchange.ParseLeverageTiers(cfg.Agent.LeverageTiers); err == nil {
+		leverage = exchange.SelectLeverage(tiers, confidence, leverage)
+	}
+	stopMode, _ := exchange.ParseStopMode(cfg.Agent.StopMode)
+	risk := &exchange.Executor{
+		Leverage:      leverage,
+		SLPercentage:  cfg.Agent.SLPercentage,
+		TPPercentage:  cfg.Agent.TPPercentage,
+		StopMode:      stopMode,
+		ATRPeriod:     cfg.Agent.ATRPeriod,
+		ATRMultiplier: cfg.Agent.ATRMultiplier,
+	}
+	sl, _ := risk.StopLoss(entry, side, candles)
+	return plot.Levels{
+		Entry:      entry,
+		StopLoss:   sl,
+		TakeProfit: risk.CalculateTP(entry, side),
+	}
+}
+
 // SelectBestOpportunity runs the prefilter for each candidate symbol in parallel
 // and returns the one with the highest score above threshold. Returns ok=false when
 // no symbol meets the threshold or all REST fetches fail.
@@ -262,14 +563,7 @@ func SelectBestOpportunity(
 				slog.Warn("[SelectBest] fetch failed", "symbol", sym, "err", err)
 				return
 			}
-			wsRest := make([]exchange.WsRestCandle, len(rest))
-			for i, c := range rest {
-				wsRest[i] = exchange.WsRestCandle{
-					Time: c.Time, Open: c.Open, High: c.High,
-					Low: c.Low, Close: c.Close, Volume: c.Volume,
-				}
-			}
-			pf := prefilter.RunPrefilter(prefilter.Input{Candles: wsRest, Threshold: threshold})
+			pf := prefilter.RunPrefilter(prefilter.Input{Candles: exchange.RestToWsRest(rest), Threshold: threshold})
 			slog.Info("[SelectBest] scored", "symbol", sym, "score", fmt.Sprintf("%.1f", pf.Score))
 			ch <- scored{sym, wsCandle, pf.Score}
 		}(sym)
@@ -2,15 +2,21 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"time"
 	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/embedding"
 	"time-series-rag-agent/internal/exchange"
 	"time-series-rag-agent/internal/llm"
 	"time-series-rag-agent/internal/plot"
+	"time-series-rag-agent/internal/storage"
 	"time-series-rag-agent/internal/storage/postgresql"
+	"time-series-rag-agent/internal/storage/s3"
 	"time-series-rag-agent/internal/trade"
+	pkg "time-series-rag-agent/pkg/notifier"
 
 	"github.com/adshao/go-binance/v2/futures"
 )
@@ -22,7 +28,29 @@ const (
 	TopN1H                 = 10
 )
 
-func NewLLMPatternAgent(ctx context.Context, futureClient *futures.Client, logger slog.Logger, appConfig *config.AppConfig, dbConfig config.DatabaseConfig, openRouterConfig config.OpenRouterConfig, symbol string, interval string, candel []exchange.WsRestCandle, feature []float64, topN int) (llm.TradeSignal, error) {
+// ErrNoActionablePattern is returned, before any LLM call, when no stored
+// pattern lies within LLM.MaxMatchDistance of the current bar.
+var ErrNoActionablePattern = errors.New("no pattern within max match distance")
+
+// searchPatterns returns the topN main-interval matches and the TopN1H hourly
+// ones within maxDistance (<= 0 = no limit). An empty main set under a limit
+// is ErrNoActionablePattern.
+func searchPatterns(ctx context.Context, store storage.PatternStore, symbol, interval string, feature []float64, topN int, maxDistance float64) (main, hourly []embedding.PatternLabel, err error) {
+	main, err = store.QueryTopNWithin(ctx, symbol, interval, feature, topN, maxDistance)
+	if err != nil {
+		return nil, nil, err
+	}
+	if maxDistance > 0 && len(main) == 0 {
+		return nil, nil, fmt.Errorf("%w (%.3f)", ErrNoActionablePattern, maxDistance)
+	}
+	hourly, err = store.QueryTopNWithin(ctx, symbol, "1h", feature, TopN1H, maxDistance)
+	if err != nil {
+		return nil, nil, err
+	}
+	return main, hourly, nil
+}
+
+func NewLLMPatternAgent(ctx context.Context, futureClient *futures.Client, logger slog.Logger, appConfig *config.AppConfig, dbConfig config.DatabaseConfig, openRouterConfig config.OpenRouterConfig, symbol string, interval string, candel []exchange.WsRestCandle, feature []float64, rsi *float64, topN int) (llm.TradeSignal, error) {
 	connString := fmt.Sprintf("postgres://%s:%s@%s:%d/%s",
 		dbConfig.DBUser,
 		dbConfig.DBPassword,
@@ -30,29 +58,72 @@ func NewLLMPatternAgent(ctx context.Context, futureClient *futures.Client, logge
 		dbConfig.DBPort,
 		dbConfig.DBName,
 	)
-	db, err := postgresql.NewPostgresDB(ctx, connString, logger)
+	db, err := postgresql.NewPostgresDB(ctx, connString, postgresql.PoolConfigFrom(dbConfig), logger)
 	if err != nil {
 		logger.Error("[LLMPatternPipeline] Cannot establish connection for candle ingestion.")
 		return llm.TradeSignal{}, err
 	}
 	defer db.Close()
+	db.SetSearchParams(appConfig.Search.HNSWEfSearch, appConfig.Search.IVFFlatProbes)
+	if fc, err := NewConfiguredFeatureCalculator(appConfig.Embedding, symbol, interval, len(feature)); err == nil {
+		db.SetEmbeddingVersion(fc.Version())
+	}
 
-	patterns, err := db.QueryTopN(ctx, symbol, interval, feature, topN)
+	llmConfig := *appConfig
+	llmConfig.OpenRouter = openRouterConfig
+	return runLLMPatternAgent(ctx, db, LLMServiceFrom(&llmConfig), futureClient, logger, appConfig, symbol, interval, candel, feature, rsi, topN)
+}
+
+// LLMServiceFrom builds the OpenRouter client with the configured limits.
+// Callers that keep one across bars or symbols share its daily token budget.
+func LLMServiceFrom(cfg *config.AppConfig) *llm.LLMService {
+	s := llm.NewLLMService(cfg.OpenRouter.ApiKey, cfg.OpenRouter.Model, cfg.OpenRouter.BaseURL, cfg.LLM.MaxDailyTokens)
+	s.MaxTokens = cfg.LLM.MaxTokens
+	s.RetryMaxTokens = cfg.LLM.RetryMaxTokens
+	s.MaxAttempts = cfg.LLM.MaxAttempts
+	s.PromptVersion = cfg.LLM.PromptVersion
+	s.MAPeriods = cfg.Candle.ChartMAPeriods
+	// an unknown theme is reported where the chart is drawn; both fall back to binance
+	s.ChartTheme, _ = plot.ThemeByName(cfg.Candle.ChartTheme)
+	return s
+}
+
+// runLLMPatternAgent is NewLLMPatternAgent on a store and LLM client the
+// caller owns.
+func runLLMPatternAgent(ctx context.Context, store storage.PatternStore, llmService *llm.LLMService, futureClient *futures.Client, logger slog.Logger, appConfig *config.AppConfig, symbol string, interval string, candel []exchange.WsRestCandle, feature []float64, rsi *float64, topN int) (llm.TradeSignal, error) {
+	stopSearch := startStage(ctx, "search")
+	defer stopSearch()
+	patterns, patterns1h, err := searchPatterns(ctx, store, symbol, interval, feature, topN, appConfig.LLM.MaxMatchDistance)
+	if errors.Is(err, ErrNoActionablePattern) {
+		return llm.TradeSignal{}, err
+	}
 	if err != nil {
 		logger.Error("[LLMPatternPipeline] Error from query Top n")
 		return llm.TradeSignal{}, err
 	}
 
-	patterns1h, err := db.QueryTopN(ctx, symbol, "1h", feature, TopN1H)
+	stopSearch()
+
+	stopChart := startStage(ctx, "chart")
+	theme, err := plot.ThemeByName(appConfig.Candle.ChartTheme)
 	if err != nil {
-		logger.Error("[LLMPatternPipeline] Error from query Top n")
+		logger.Warn("[LLMPatternPipeline] falling back to the binance chart theme", "err", err)
+	}
+	candlePNG, err := plot.GenerateCandleChartWithMAsBytes(candel, theme, appConfig.Candle.ChartMAPeriods, LATEST_CANDLE_PLOT)
+	stopChart()
+	if err != nil {
+		logger.Error("[LLMPatternPipeline] Error at candle chart")
 		return llm.TradeSignal{}, err
 	}
-
-	plot.GenerateCandleChart(candel, CANDLE_FILE_NAME, LATEST_CANDLE_PLOT)
+	// The file is only for the Discord attachment; the prompt and S3 use the bytes.
+	if err := os.WriteFile(pkg.ChartFileName(symbol), candlePNG, 0o644); err != nil {
+		logger.Warn("[LLMPatternPipeline] write chart file", "err", err)
+	}
 	logger.Info("[LLMPatternPipeline] Finished plot")
+	if appConfig.ChartStore.S3Bucket != "" && len(candel) > 0 {
+		archiveChart(&logger, appConfig.ChartStore, symbol, interval, time.Unix(candel[len(candel)-1].Time, 0), CANDLE_FILE_NAME, candlePNG)
+	}
 
-	llmService := llm.NewLLMService(openRouterConfig.ApiKey, appConfig.LLM.MaxDailyTokens)
 	regime, err := exchange.FetchLatestRegimes(logger, futureClient, appConfig, symbol, []string{"4h", "1d"})
 	if err != nil {
 		logger.Error("[LLMPatternPipeline] Regime fetching")
@@ -79,7 +150,7 @@ func NewLLMPatternAgent(ctx context.Context, futureClient *futures.Client, logge
 
 	logger.Info(fmt.Sprintf("Current ROI=%f, PnL=%f", roi, dailyPnL))
 
-	systemMessage, userContent, b64Candle, err := llmService.GenerateTradingPrompt(currentTimestamp, patterns, patterns1h, CANDLE_FILE_NAME, promptPositions, regime, dailyPnL, symbol)
+	systemMessage, userContent, b64Candle, err := llmService.GenerateTradingPrompt(currentTimestamp, patterns, patterns1h, candlePNG, promptPositions, regime, dailyPnL, rsi, symbol)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Prompt Error: %v", err))
 		return llm.TradeSignal{}, err
@@ -87,12 +158,20 @@ func NewLLMPatternAgent(ctx context.Context, futureClient *futures.Client, logge
 	logger.Info("[LLMPatternPipeline] systemMessage", "msg", systemMessage)
 	logger.Info("[LLMPatternPipeline] userContent", "msg", userContent)
 
+	stopLLM := startStage(ctx, "llm")
 	signal, err := llmService.GenerateSignal(ctx, systemMessage, userContent, b64Candle)
+	stopLLM()
 	if err != nil {
 		logger.Error(fmt.Sprintf("LLM Error: %v", err))
 		return llm.TradeSignal{}, err
 	}
 
+	signal.ConsensusStrength = llm.ConsensusStrength(patterns)
+	signal.MatchCount = len(patterns)
+	if appConfig.LLM.LogMatches {
+		logMatchSet(&logger, symbol, interval, signal.Signal, patterns)
+	}
+
 	logger.Info("Signal result",
 		"signal", signal.Signal,
 		"confidence", signal.Confidence,
@@ -103,6 +182,58 @@ func NewLLMPatternAgent(ctx context.Context, futureClient *futures.Client, logge
 		"risk_note", signal.RiskNote,
 		"invalidation", signal.Invalidation,
 	)
+	logger.Info("[LLMPatternPipeline] token usage",
+		"prompt_tokens", signal.Usage.PromptTokens,
+		"completion_tokens", signal.Usage.CompletionTokens,
+		"total_tokens", signal.Usage.TotalTokens,
+	)
 
 	return *signal, nil
 }
+
+// annotateChart redraws the candle chart with the placed trade's levels and
+// replaces the Discord attachment with it; the LLM already saw the plain one.
+func annotateChart(logger *slog.Logger, cfg *config.AppConfig, symbol, interval string, candles []exchange.WsRestCandle, levels plot.Levels) {
+	theme, _ := plot.ThemeByName(cfg.Candle.ChartTheme)
+	png, err := plot.GenerateAnnotatedCandleChartBytes(candles, theme, cfg.Candle.ChartMAPeriods, levels, LATEST_CANDLE_PLOT)
+	if err != nil {
+		logger.Warn("[LLMPatternPipeline] annotated chart", "err", err)
+		return
+	}
+	if err := os.WriteFile(pkg.ChartFileName(symbol), png, 0o644); err != nil {
+		logger.Warn("[LLMPatternPipeline] write chart file", "err", err)
+	}
+	if cfg.ChartStore.S3Bucket != "" && len(candles) > 0 {
+		archiveChart(logger, cfg.ChartStore, symbol, interval, time.Unix(candles[len(candles)-1].Time, 0), "candle-levels.png", png)
+	}
+}
+
+// archiveChart uploads a rendered chart to S3 in the background, keyed by the
+// candle so a re-run for the same bar overwrites rather than duplicates.
+func archiveChart(logger *slog.Logger, cfg config.ChartStoreConfig, symbol, interval string, candleTime time.Time, name string, png []byte) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		uploader, err := s3.NewS3ChartUploader(ctx, cfg.S3Bucket, cfg.S3Prefix, logger)
+		if err != nil {
+			logger.Warn("[LLMPatternPipeline] S3 client", "err", err)
+			return
+		}
+		key := uploader.ChartKey(symbol, interval, candleTime, name)
+		uri, err := uploader.UploadImageToS3(ctx, key, png)
+		if err != nil {
+			logger.Warn("[LLMPatternPipeline] chart upload failed", "err", err)
+			return
+		}
+		if cfg.PresignTTLMinutes <= 0 {
+			logger.Info("[LLMPatternPipeline] chart archived", "uri", uri)
+			return
+		}
+		url, err := uploader.GetPresignedURL(ctx, key, time.Duration(cfg.PresignTTLMinutes)*time.Minute)
+		if err != nil {
+			logger.Warn("[LLMPatternPipeline] chart presign failed", "uri", uri, "err", err)
+			return
+		}
+		logger.Info("[LLMPatternPipeline] chart archived", "uri", uri, "url", url)
+	}()
+}
@@ -0,0 +1,45 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/storage/memory"
+)
+
+func TestSearchPatterns_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	require.NoError(t, store.BulkUpsertFeature(ctx, []embedding.PatternFeature{
+		{Time: time.Unix(900, 0), Symbol: "ETHUSDT", Interval: "15m", Embedding: []float64{1, 1}},   // ~0.293
+		{Time: time.Unix(1800, 0), Symbol: "ETHUSDT", Interval: "15m", Embedding: []float64{1, 0}},  // 0
+		{Time: time.Unix(2700, 0), Symbol: "ETHUSDT", Interval: "15m", Embedding: []float64{-1, 0}}, // 2
+		{Time: time.Unix(3600, 0), Symbol: "ETHUSDT", Interval: "1h", Embedding: []float64{1, 0}},
+	}))
+
+	main, hourly, err := searchPatterns(ctx, store, "ETHUSDT", "15m", []float64{1, 0}, 5, 0.5)
+
+	require.NoError(t, err)
+	require.Len(t, main, 2, "the opposite pattern is past maxDistance")
+	assert.Equal(t, int64(1800), main[0].Time.Unix())
+	assert.Equal(t, int64(900), main[1].Time.Unix())
+	require.Len(t, hourly, 1)
+	assert.Equal(t, "1h", hourly[0].Interval)
+}
+
+func TestSearchPatterns_NothingWithinDistance(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	require.NoError(t, store.UpsertFeature(ctx, embedding.PatternFeature{
+		Time: time.Unix(900, 0), Symbol: "ETHUSDT", Interval: "15m", Embedding: []float64{0, 1},
+	}))
+
+	_, _, err := searchPatterns(ctx, store, "ETHUSDT", "15m", []float64{1, 0}, 5, 0.5)
+
+	assert.ErrorIs(t, err, ErrNoActionablePattern)
+}
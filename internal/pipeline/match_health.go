@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	pkg "time-series-rag-agent/pkg/notifier"
+)
+
+// EmptyMatchMonitor counts consecutive bars where the pattern search came back
+// empty even though the store has rows for the symbol — usually a sign that
+// labels never got written. Safe for concurrent use.
+type EmptyMatchMonitor struct {
+	mu     sync.Mutex
+	streak map[string]int // keyed by symbol
+}
+
+func NewEmptyMatchMonitor() *EmptyMatchMonitor {
+	return &EmptyMatchMonitor{streak: make(map[string]int)}
+}
+
+// Observe records one bar's search result and reports whether to alert.
+// It fires when the streak reaches threshold and again every threshold bars
+// after that. Any match, or an empty store (cold start), resets the streak.
+// threshold <= 0 disables the monitor.
+func (m *EmptyMatchMonitor) Observe(symbol string, matches int, storeRows int64, threshold int) (streak int, alert bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if threshold <= 0 || matches > 0 || storeRows == 0 {
+		delete(m.streak, symbol)
+		return 0, false
+	}
+	m.streak[symbol]++
+	streak = m.streak[symbol]
+	return streak, streak%threshold == 0
+}
+
+// PatternCounter reports how many patterns are stored for a market and how
+// many of them are labeled.
+type PatternCounter interface {
+	CountPatterns(ctx context.Context, symbol, interval string) (total, labeled int64, err error)
+}
+
+// checkMatchHealth feeds one bar's match count to monitor and raises a
+// "match-health" pipeline error when it alerts. The store is only counted on
+// empty bars. threshold <= 0 disables the check.
+func checkMatchHealth(ctx context.Context, logger *slog.Logger, hooks *pkg.PipelineHooks, monitor *EmptyMatchMonitor, store PatternCounter, symbol, interval string, matches, threshold int) {
+	if threshold <= 0 {
+		return
+	}
+	var total, labeled int64
+	if matches == 0 {
+		var err error
+		if total, labeled, err = store.CountPatterns(ctx, symbol, interval); err != nil {
+			logger.Warn("[LivePipeline] pattern count failed", "err", err)
+		}
+	}
+	if streak, alert := monitor.Observe(symbol, matches, total, threshold); alert {
+		logger.Warn("[LivePipeline] no pattern matches despite non-empty store",
+			"streak", streak, "rows", total, "labeled", labeled)
+		hooks.OnPipelineError("match-health", fmt.Errorf(
+			"%s %s: 0 matches for %d consecutive bars with %d rows stored (%d labeled) — check label writes",
+			symbol, interval, streak, total, labeled))
+	}
+}
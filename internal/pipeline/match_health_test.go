@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	pkg "time-series-rag-agent/pkg/notifier"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCounter struct {
+	total, labeled int64
+	calls          int
+}
+
+func (f *fakeCounter) CountPatterns(ctx context.Context, symbol, interval string) (int64, int64, error) {
+	f.calls++
+	return f.total, f.labeled, nil
+}
+
+func TestEmptyMatchMonitor_AlertsAfterKConsecutive(t *testing.T) {
+	m := NewEmptyMatchMonitor()
+
+	var alerts []int
+	for i := 1; i <= 7; i++ {
+		if streak, alert := m.Observe("ETHUSDT", 0, 500, 3); alert {
+			alerts = append(alerts, streak)
+		}
+	}
+
+	assert.Equal(t, []int{3, 6}, alerts)
+}
+
+func TestEmptyMatchMonitor_MatchResetsStreak(t *testing.T) {
+	m := NewEmptyMatchMonitor()
+
+	m.Observe("ETHUSDT", 0, 500, 3)
+	m.Observe("ETHUSDT", 0, 500, 3)
+	m.Observe("ETHUSDT", 4, 500, 3)
+	streak, alert := m.Observe("ETHUSDT", 0, 500, 3)
+
+	assert.Equal(t, 1, streak)
+	assert.False(t, alert)
+}
+
+func TestEmptyMatchMonitor_EmptyStoreAndPerSymbol(t *testing.T) {
+	m := NewEmptyMatchMonitor()
+
+	for i := 0; i < 5; i++ {
+		_, alert := m.Observe("ETHUSDT", 0, 0, 2) // cold start: nothing to match yet
+		assert.False(t, alert)
+	}
+	m.Observe("ADAUSDT", 0, 500, 2)
+	_, alert := m.Observe("ETHUSDT", 0, 500, 2)
+	assert.False(t, alert, "ADAUSDT streak must not leak into ETHUSDT")
+
+	_, alert = m.Observe("ETHUSDT", 0, 500, 0)
+	assert.False(t, alert, "threshold 0 disables")
+}
+
+func TestCheckMatchHealth_NoActionablePatternBarsCountTowardAlert(t *testing.T) {
+	// Bars that stop at ErrNoActionablePattern report 0 matches.
+	m := NewEmptyMatchMonitor()
+	store := &fakeCounter{total: 500, labeled: 0}
+	var phases []string
+	hooks := &pkg.PipelineHooks{OnPipelineError: func(phase string, err error) { phases = append(phases, phase) }}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for range 3 {
+		checkMatchHealth(context.Background(), logger, hooks, m, store, "ETHUSDT", "15m", 0, 3)
+	}
+
+	assert.Equal(t, []string{"match-health"}, phases)
+	assert.Equal(t, 3, store.calls)
+
+	checkMatchHealth(context.Background(), logger, hooks, m, store, "ETHUSDT", "15m", 4, 3)
+	assert.Equal(t, 3, store.calls, "bars with matches skip the count")
+}
@@ -0,0 +1,30 @@
+package pipeline
+
+import (
+	"log/slog"
+
+	"time-series-rag-agent/internal/embedding"
+)
+
+// logMatchSet writes one structured line per pattern match behind a decision,
+// so a trade can be traced back to the historical bars that drove it.
+func logMatchSet(logger *slog.Logger, symbol, interval, decision string, matches []embedding.PatternLabel) {
+	logger.Info("[LLMPatternPipeline] match set",
+		"symbol", symbol,
+		"interval", interval,
+		"decision", decision,
+		"count", len(matches),
+	)
+	for i, m := range matches {
+		logger.Info("[LLMPatternPipeline] match",
+			"symbol", symbol,
+			"decision", decision,
+			"rank", i+1,
+			"time", m.Time.UTC().Format("2006-01-02 15:04"),
+			"distance", m.Distance,
+			"slope_3", m.NextSlope3,
+			"slope_5", m.NextSlope5,
+			"next_return", m.NextReturn,
+		)
+	}
+}
@@ -0,0 +1,42 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogMatchSet_EmitsMatchFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	matches := []embedding.PatternLabel{
+		{Time: time.Unix(1700000000, 0), Distance: 0.12, NextSlope3: 0.004, NextSlope5: 0.006, NextReturn: 0.0021},
+		{Time: time.Unix(1700000900, 0), Distance: 0.18, NextSlope3: -0.002, NextSlope5: -0.001, NextReturn: -0.0013},
+	}
+
+	logMatchSet(logger, "ETHUSDT", "15m", "LONG", matches)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+
+	var header map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, "LONG", header["decision"])
+	assert.Equal(t, float64(2), header["count"])
+
+	var second map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[2]), &second))
+	assert.Equal(t, float64(2), second["rank"])
+	assert.Equal(t, "2023-11-14 22:28", second["time"])
+	assert.InDelta(t, 0.18, second["distance"], 1e-12)
+	assert.InDelta(t, -0.002, second["slope_3"], 1e-12)
+	assert.InDelta(t, -0.001, second["slope_5"], 1e-12)
+	assert.InDelta(t, -0.0013, second["next_return"], 1e-12)
+}
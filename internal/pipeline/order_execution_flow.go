@@ -4,17 +4,52 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"time-series-rag-agent/config"
 	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/metrics"
 	"time-series-rag-agent/internal/trade"
 
 	"github.com/adshao/go-binance/v2/futures"
 )
 
-func NewOrderExecutionPipeline(ctx context.Context, logger slog.Logger, futureClient *futures.Client, symbol string, signal string, priceToOpen float64) error {
-	conf := config.LoadConfig()
+// ReconcileOrders runs Executor.Reconcile for each symbol, so orders orphaned
+// while the bot was down cannot fire against a new position. A failing symbol
+// is logged and the rest still run; the first error is returned.
+func ReconcileOrders(ctx context.Context, logger *slog.Logger, futureClient *futures.Client, symbols []string) error {
+	var firstErr error
+	for _, sym := range symbols {
+		executor := exchange.NewExecutor(futureClient, sym, 0, 1, 0, 0, *logger)
+		swept, err := executor.Reconcile(ctx)
+		if err != nil {
+			logger.Error(fmt.Sprintf("[OrderExecution] %v", err))
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if swept {
+			logger.Info("[OrderExecution] reconciled orders", "symbol", sym)
+		}
+	}
+	return firstErr
+}
+
+// NewOrderExecutionPipeline acts on signal with symbol's cfg and executor;
+// a nil cfg is loaded and a nil executor built as NewLiveExecutor does.
+// candles is the recent window, oldest first, for an ATR stop
+// (STOP_MODE=atr). Entries are placed under orders, if set, so symbols
+// sharing one balance size one after another off what is still free.
+func NewOrderExecutionPipeline(ctx context.Context, logger slog.Logger, futureClient *futures.Client, cfg *config.AppConfig, executor *exchange.Executor, orders sync.Locker, symbol string, signal string, confidence int, priceToOpen float64, candles []exchange.WsRestCandle) error {
+	conf := cfg
+	if conf == nil {
+		conf = config.LoadConfig()
+	}
+	if executor == nil {
+		executor = NewLiveExecutor(futureClient, symbol, conf, &logger)
+	}
 
 	_, roi, err := trade.CalculateDailyROI(futureClient)
 	if err != nil {
@@ -23,36 +58,59 @@ func NewOrderExecutionPipeline(ctx context.Context, logger slog.Logger, futureCl
 		logger.Info(fmt.Sprintf("[OrderExecution] Current Daily ROI: %.2f%%", roi*100))
 	}
 
-	var AviableTradeRatio float64
 	if roi >= conf.Agent.ReduceRoiTrigger {
-		AviableTradeRatio = conf.Agent.ReductionAviableTradeRatio
+		executor.AviableTradeRatio = conf.Agent.ReductionAviableTradeRatio
 	} else {
-		AviableTradeRatio = conf.Agent.AviableTradeRatio
+		executor.AviableTradeRatio = conf.Agent.AviableTradeRatio
 	}
-
-	executor := exchange.NewExecutor(
-		futureClient,
-		symbol,
-		AviableTradeRatio,
-		conf.Agent.Leverage,
-		conf.Agent.SLPercentage,
-		conf.Agent.TPPercentage,
-		logger,
-	)
+	executor.TrailingStop = conf.Agent.TrailingStop
+	executor.CallbackRate = conf.Agent.CallbackRate
+	executor.FeeRate = conf.Agent.FeeRate
+	executor.MarginBuffer = conf.Agent.MarginBuffer
 
 	tradeCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	switch signal {
 	case "SHORT", "LONG":
-		if err := executor.SetLeverage(tradeCtx, conf.Agent.Leverage); err != nil {
-			logger.Error(fmt.Sprintf("[OrderExecution] SetLeverage failed: %v", err))
+		tiers, err := exchange.ParseLeverageTiers(conf.Agent.LeverageTiers)
+		if err != nil {
+			logger.Error(fmt.Sprintf("[OrderExecution] Invalid LEVERAGE_TIERS: %v", err))
+			return err
+		}
+		if executor.TPLevels, err = exchange.ParseTPLevels(conf.Agent.TPLevels); err != nil {
+			logger.Error(fmt.Sprintf("[OrderExecution] Invalid TP_LEVELS: %v", err))
+			return err
+		}
+		if executor.EntryType, err = exchange.ParseEntryType(conf.Agent.EntryType); err != nil {
+			logger.Error(fmt.Sprintf("[OrderExecution] Invalid ENTRY_TYPE: %v", err))
+			return err
+		}
+		if executor.Sizing, err = exchange.ParseSizingMode(conf.Agent.SizingMode); err != nil {
+			logger.Error(fmt.Sprintf("[OrderExecution] Invalid SIZING_MODE: %v", err))
+			return err
+		}
+		executor.RiskPct = conf.Agent.RiskPct
+		if executor.StopMode, err = exchange.ParseStopMode(conf.Agent.StopMode); err != nil {
+			logger.Error(fmt.Sprintf("[OrderExecution] Invalid STOP_MODE: %v", err))
+			return err
+		}
+		executor.ATRPeriod = conf.Agent.ATRPeriod
+		executor.ATRMultiplier = conf.Agent.ATRMultiplier
+		leverage := exchange.SelectLeverage(tiers, confidence, conf.Agent.Leverage)
+		if orders != nil {
+			orders.Lock()
+			defer orders.Unlock()
+		}
+		if _, err := executor.ApplyLeverage(tradeCtx, leverage); err != nil {
+			logger.Error(fmt.Sprintf("[OrderExecution] ApplyLeverage failed: %v", err))
 			return err
 		}
-		if err := executor.PlaceTrade(tradeCtx, signal, priceToOpen); err != nil {
+		if err := executor.PlaceTrade(tradeCtx, signal, priceToOpen, candles); err != nil {
 			logger.Error(fmt.Sprintf("[OrderExecution] PlaceTrade failed: %v", err))
 			return err
 		}
+		metrics.TradesPlaced.WithLabelValues(symbol, signal).Inc()
 	case "HOLD":
 		logger.Info("[OrderExecution] HOLD - checking for stale open orders...")
 		if err := executor.CancelTrade(tradeCtx); err != nil {
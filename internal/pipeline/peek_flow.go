@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/prefilter"
+)
+
+// ProvisionalSignal is an early read on the still-forming candle. It is always
+// flagged Provisional and is only logged: the peek path holds no executor, and
+// trades happen on close only.
+type ProvisionalSignal struct {
+	Symbol         string
+	Interval       string
+	CandleTime     time.Time // open time of the forming candle
+	Price          float64   // last traded price so far
+	Signal         string    // LONG / SHORT / HOLD from pattern consensus
+	Up             int
+	Down           int
+	PrefilterScore float64
+	Provisional    bool
+}
+
+// NewEarlyPeekPipeline computes a provisional signal from the forming candle:
+// pattern consensus over the nearest matches, embedded by fc like the stored
+// ones, plus the prefilter score.
+func NewEarlyPeekPipeline(ctx context.Context, adapter exchange.KlineService, searcher PatternSearcher, fc *embedding.FeatureCalculator, topN int) (ProvisionalSignal, error) {
+	symbol, interval, vectorSize := fc.Symbol, fc.Interval, fc.VectorWindow
+	candles, err := exchange.FetchFormingCandles(ctx, adapter, symbol, interval, vectorSize+1+99)
+	if err != nil {
+		return ProvisionalSignal{}, fmt.Errorf("[EarlyPeek] fetch: %w", err)
+	}
+	if len(candles) < vectorSize+1 {
+		return ProvisionalSignal{}, fmt.Errorf("[EarlyPeek] need %d candles, got %d", vectorSize+1, len(candles))
+	}
+
+	window := candles[len(candles)-(vectorSize+1):]
+	closes := make([]float64, len(window))
+	volumes := make([]float64, len(window))
+	for i, c := range window {
+		closes[i] = c.Close
+		volumes[i] = c.Volume
+	}
+	res, err := NewCustomWindowQuery(ctx, searcher, fc, closes, volumes, topN)
+	if err != nil {
+		return ProvisionalSignal{}, fmt.Errorf("[EarlyPeek] %w", err)
+	}
+
+	pf := prefilter.RunPrefilter(prefilter.Input{Candles: exchange.RestToWsRest(candles)})
+
+	forming := candles[len(candles)-1]
+	sig := ProvisionalSignal{
+		Symbol:         symbol,
+		Interval:       interval,
+		CandleTime:     time.Unix(forming.Time, 0),
+		Price:          forming.Close,
+		Signal:         "HOLD",
+		Up:             res.Up,
+		Down:           res.Down,
+		PrefilterScore: pf.Score,
+		Provisional:    true,
+	}
+	switch {
+	case res.Up > res.Down:
+		sig.Signal = "LONG"
+	case res.Down > res.Up:
+		sig.Signal = "SHORT"
+	}
+	return sig, nil
+}
+
+// StartEarlyPeek logs a provisional signal per symbol every `every` until ctx
+// is done. Each symbol uses its Embedding.WindowFor window, vectorSize by
+// default. It never places orders.
+func StartEarlyPeek(ctx context.Context, logger *slog.Logger, adapter exchange.KlineService, searcher PatternSearcher, embCfg config.EmbeddingConfig, symbols []string, interval string, vectorSize, topN int, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, sym := range symbols {
+			fc, err := NewConfiguredFeatureCalculator(embCfg, sym, interval, embCfg.WindowFor(sym, vectorSize))
+			if err != nil {
+				logger.Warn("[EarlyPeek] failed", "symbol", sym, "err", err)
+				continue
+			}
+			sig, err := NewEarlyPeekPipeline(ctx, adapter, searcher, fc, topN)
+			if err != nil {
+				logger.Warn("[EarlyPeek] failed", "symbol", sym, "err", err)
+				continue
+			}
+			logger.Info("[EarlyPeek] provisional",
+				"symbol", sym, "signal", sig.Signal,
+				"up", sig.Up, "down", sig.Down,
+				"score", fmt.Sprintf("%.1f", sig.PrefilterScore), "price", sig.Price,
+			)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeKlines(n int) []*futures.Kline {
+	out := make([]*futures.Kline, n)
+	for i := range out {
+		price := fmt.Sprintf("%.2f", 100+float64(i%7))
+		out[i] = &futures.Kline{
+			OpenTime: int64(1700000000+i*900) * 1000,
+			Open:     price, High: price, Low: price, Close: price, Volume: "1",
+		}
+	}
+	return out
+}
+
+func TestEarlyPeek_SignalIsProvisional(t *testing.T) {
+	adapter := &exchange.MockKlineService{ReturnData: makeKlines(40)}
+	searcher := &fakeSearcher{seeded: []embedding.PatternLabel{
+		{NextSlope3: 0.002}, {NextSlope3: 0.001}, {NextSlope3: -0.001},
+	}}
+
+	fc := embedding.NewFeatureCalculator("ETHUSDT", "15m", 30)
+
+	sig, err := NewEarlyPeekPipeline(context.Background(), adapter, searcher, fc, 3)
+
+	assert.NoError(t, err)
+	assert.True(t, sig.Provisional)
+	assert.Equal(t, "LONG", sig.Signal)
+	assert.Equal(t, int64(1700000000+39*900), sig.CandleTime.Unix(), "uses the forming candle")
+}
@@ -0,0 +1,44 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// PatternPruner deletes stored patterns older than a cutoff.
+type PatternPruner interface {
+	PrunePatterns(ctx context.Context, symbol, interval string, olderThan time.Time) (int64, error)
+}
+
+// PruneOnce removes every symbol's patterns older than retention before now
+// and returns the total removed. A failing symbol is logged and skipped.
+func PruneOnce(ctx context.Context, logger *slog.Logger, pruner PatternPruner, symbols []string, interval string, retention time.Duration, now time.Time) int64 {
+	cutoff := now.Add(-retention)
+	var total int64
+	for _, sym := range symbols {
+		n, err := pruner.PrunePatterns(ctx, sym, interval, cutoff)
+		if err != nil {
+			logger.Warn("[Prune] failed", "symbol", sym, "err", err)
+			continue
+		}
+		total += n
+		logger.Info("[Prune] removed old patterns", "symbol", sym, "interval", interval, "rows", n, "before", cutoff.UTC().Format(time.RFC3339))
+	}
+	return total
+}
+
+// StartPatternPruner runs PruneOnce immediately and then every `every` until
+// ctx is done, keeping only the last `retention` of patterns.
+func StartPatternPruner(ctx context.Context, logger *slog.Logger, pruner PatternPruner, symbols []string, interval string, retention, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		PruneOnce(ctx, logger, pruner, symbols, interval, retention, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
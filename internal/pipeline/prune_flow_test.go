@@ -0,0 +1,45 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/storage/memory"
+)
+
+type failingPruner struct{ PatternPruner }
+
+func (f failingPruner) PrunePatterns(ctx context.Context, symbol, interval string, olderThan time.Time) (int64, error) {
+	if symbol == "BAD" {
+		return 0, errors.New("boom")
+	}
+	return f.PatternPruner.PrunePatterns(ctx, symbol, interval, olderThan)
+}
+
+func TestPruneOnce_KeepsRetentionWindow(t *testing.T) {
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	store := memory.NewStore()
+	ctx := context.Background()
+	var seed []embedding.PatternFeature
+	for _, age := range []int{120, 91, 89, 1} { // days old
+		for _, sym := range []string{"ETHUSDT", "ADAUSDT"} {
+			seed = append(seed, embedding.PatternFeature{
+				Time: now.AddDate(0, 0, -age), Symbol: sym, Interval: "15m", Embedding: []float64{1, 0},
+			})
+		}
+	}
+	assert.NoError(t, store.BulkUpsertFeature(ctx, seed))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	n := PruneOnce(ctx, logger, failingPruner{store}, []string{"ETHUSDT", "BAD", "ADAUSDT"}, "15m", 90*24*time.Hour, now)
+
+	assert.Equal(t, int64(4), n, "120- and 91-day rows of both symbols")
+	assert.Equal(t, 4, store.Len())
+}
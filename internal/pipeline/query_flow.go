@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"time-series-rag-agent/internal/embedding"
+)
+
+// PatternSearcher is the similarity-search half of the pattern store.
+type PatternSearcher interface {
+	QueryTopN(ctx context.Context, symbol, interval string, queryEmbedding []float64, topN int) ([]embedding.PatternLabel, error)
+}
+
+// CustomQueryResult holds the matches for a user-supplied window plus a simple
+// UP/DOWN consensus over their slopes.
+type CustomQueryResult struct {
+	Embedding []float64
+	Matches   []embedding.PatternLabel
+	Up        int
+	Down      int
+	AvgSlope  float64
+}
+
+// NewCustomWindowQuery embeds an arbitrary close series with fc, the same
+// recipe the stored patterns were built with, and searches fc's market for
+// the nearest historical patterns — no live feed involved. volumes is needed
+// only when fc.WithVolume is set.
+func NewCustomWindowQuery(ctx context.Context, searcher PatternSearcher, fc *embedding.FeatureCalculator, closes, volumes []float64, topN int) (CustomQueryResult, error) {
+	emb, err := fc.EmbedCloses(closes, volumes)
+	if err != nil {
+		return CustomQueryResult{}, fmt.Errorf("[CustomWindowQuery] embed: %w", err)
+	}
+
+	matches, err := searcher.QueryTopN(ctx, fc.Symbol, fc.Interval, emb, topN)
+	if err != nil {
+		return CustomQueryResult{}, fmt.Errorf("[CustomWindowQuery] search: %w", err)
+	}
+
+	res := CustomQueryResult{Embedding: emb, Matches: matches}
+	for _, m := range matches {
+		slope := m.NextSlope3
+		if slope == 0 {
+			slope = m.NextSlope5
+		}
+		res.AvgSlope += slope
+		if slope > 0 {
+			res.Up++
+		} else {
+			res.Down++
+		}
+	}
+	if len(matches) > 0 {
+		res.AvgSlope /= float64(len(matches))
+	}
+	return res, nil
+}
+
+// FormatCustomQueryResult renders the matches and consensus for terminal output.
+func FormatCustomQueryResult(res CustomQueryResult) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Matches: %d | UP: %d | DOWN: %d | avg slope: %.6f\n",
+		len(res.Matches), res.Up, res.Down, res.AvgSlope))
+	for _, m := range res.Matches {
+		sb.WriteString(fmt.Sprintf("%s | %s | sim: %.1f%% | slope3: %.6f | return: %.4f%%\n",
+			m.Time.Format("2006-01-02 15:04"), m.TrendOutcome(), m.SimilarityPct(), m.NextSlope3, m.NextReturn*100))
+	}
+	return sb.String()
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"time-series-rag-agent/config"
+	"time-series-rag-agent/internal/embedding"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSearcher struct {
+	seeded    []embedding.PatternLabel
+	gotSymbol string
+	gotEmb    []float64
+}
+
+func (f *fakeSearcher) QueryTopN(ctx context.Context, symbol, interval string, queryEmbedding []float64, topN int) ([]embedding.PatternLabel, error) {
+	f.gotSymbol = symbol
+	f.gotEmb = queryEmbedding
+	if topN < len(f.seeded) {
+		return f.seeded[:topN], nil
+	}
+	return f.seeded, nil
+}
+
+func TestNewCustomWindowQuery_ReturnsSeededMatches(t *testing.T) {
+	// Arrange
+	searcher := &fakeSearcher{seeded: []embedding.PatternLabel{
+		{Time: time.Unix(1000, 0), Symbol: "BTCUSDT", NextSlope3: 0.002, Distance: 0.05},
+		{Time: time.Unix(2000, 0), Symbol: "BTCUSDT", NextSlope3: -0.001, Distance: 0.10},
+		{Time: time.Unix(3000, 0), Symbol: "BTCUSDT", NextSlope3: 0, NextSlope5: 0.004, Distance: 0.12},
+	}}
+	closes := []float64{100, 101, 100.5, 102, 101.5}
+	fc := embedding.NewFeatureCalculator("BTCUSDT", "15m", len(closes)-1)
+
+	// Act
+	res, err := NewCustomWindowQuery(context.Background(), searcher, fc, closes, nil, 10)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "BTCUSDT", searcher.gotSymbol)
+	assert.Len(t, searcher.gotEmb, len(closes)-1)
+	assert.Len(t, res.Matches, 3)
+	assert.Equal(t, 2, res.Up) // slope5 fallback counts as UP
+	assert.Equal(t, 1, res.Down)
+	assert.Contains(t, FormatCustomQueryResult(res), "UP: 2 | DOWN: 1")
+}
+
+func TestNewCustomWindowQuery_TooShort(t *testing.T) {
+	fc := embedding.NewFeatureCalculator("BTCUSDT", "15m", 1)
+
+	_, err := NewCustomWindowQuery(context.Background(), &fakeSearcher{}, fc, []float64{100}, nil, 10)
+
+	assert.Error(t, err)
+}
+
+func TestNewCustomWindowQuery_UsesConfiguredRecipe(t *testing.T) {
+	// Arrange
+	closes := []float64{100, 101, 100.5, 102, 101.5}
+	volumes := []float64{10, 12, 9, 15, 11}
+	fc, err := NewConfiguredFeatureCalculator(config.EmbeddingConfig{ReturnType: "pct", ZClip: 1, WithVolume: true}, "BTCUSDT", "15m", len(closes)-1)
+	require.NoError(t, err)
+	searcher := &fakeSearcher{}
+
+	// Act
+	_, err = NewCustomWindowQuery(context.Background(), searcher, fc, closes, volumes, 10)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, searcher.gotEmb, 2*(len(closes)-1), "volume deltas follow the price part")
+	for _, v := range searcher.gotEmb {
+		assert.LessOrEqual(t, math.Abs(v), 1.0, "z-scores are clipped")
+	}
+	_, err = NewCustomWindowQuery(context.Background(), searcher, fc, closes, nil, 10)
+	assert.Error(t, err, "volume recipe without volumes")
+}
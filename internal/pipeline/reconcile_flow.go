@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+)
+
+// LabelReconciler finds rows whose labels are still NULL and writes them.
+type LabelReconciler interface {
+	UnlabeledTimes(ctx context.Context, symbol, interval string, columns []string, from, to time.Time) ([]int64, error)
+	UpsertLabels(ctx context.Context, symbol, interval string, labels []embedding.LabelUpdate) error
+}
+
+// ReconcileLabels fills NULL labels for rows covered by history. The live
+// path labels only T-1 and T-k on each bar, so a missed or failed bar leaves
+// its rows NULL for good; this recomputes them with CalculateLookahead from
+// whatever later candles history holds. A label whose lookahead runs past the
+// end of history stays NULL until a later run. Returns the number of label
+// values written.
+func ReconcileLabels(ctx context.Context, store LabelReconciler, symbol, interval string, history []exchange.WsRestCandle, slopeWindows []int) (int, error) {
+	if len(history) < 2 {
+		return 0, nil
+	}
+	if len(slopeWindows) == 0 {
+		slopeWindows = embedding.DefaultSlopeWindows
+	}
+	lc := embedding.NewLabelCalculator()
+	lc.SlopeWindows = slopeWindows
+
+	columns := []string{"next_return"}
+	for _, k := range slopeWindows {
+		columns = append(columns, embedding.SlopeColumn(k))
+	}
+
+	// The last candle has no future yet, so nothing at or after it can be filled.
+	from := time.Unix(history[0].Time, 0)
+	to := time.Unix(history[len(history)-2].Time, 0)
+	times, err := store.UnlabeledTimes(ctx, symbol, interval, columns, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("reconcile labels: %w", err)
+	}
+	if len(times) == 0 {
+		return 0, nil
+	}
+
+	index := make(map[int64]int, len(history))
+	for i, c := range history {
+		index[c.Time] = i
+	}
+	var labels []embedding.LabelUpdate
+	for _, t := range times {
+		if i, ok := index[t]; ok {
+			labels = append(labels, lc.CalculateLookahead(history, i, t)...)
+		}
+	}
+	if err := store.UpsertLabels(ctx, symbol, interval, labels); err != nil {
+		return 0, fmt.Errorf("reconcile labels: %w", err)
+	}
+	return len(labels), nil
+}
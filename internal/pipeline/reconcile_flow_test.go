@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/storage/memory"
+)
+
+func candlesFrom(start int64, closes ...float64) []exchange.WsRestCandle {
+	out := make([]exchange.WsRestCandle, len(closes))
+	for i, c := range closes {
+		out[i] = exchange.WsRestCandle{Time: start + int64(i)*900, Close: c}
+	}
+	return out
+}
+
+func TestReconcileLabels_FillsNullRowOnceFutureExists(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	history := candlesFrom(1_000_000, 100, 101, 102, 103, 104, 105, 106, 107, 108)
+	target := history[3].Time
+	// A bar the live loop missed: its feature is stored, its labels are NULL.
+	require.NoError(t, store.UpsertFeature(ctx, embedding.PatternFeature{
+		Time: time.Unix(target, 0), Symbol: "ETHUSDT", Interval: "15m", Embedding: []float64{1, 0},
+	}))
+
+	// Only 3 candles after the target: return and slope_3 are knowable, slope_5 is not.
+	n, err := ReconcileLabels(ctx, store, "ETHUSDT", "15m", history[:7], nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	left, err := store.UnlabeledTimes(ctx, "ETHUSDT", "15m", []string{"next_slope_5"}, time.Unix(0, 0), time.Unix(target, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{target}, left)
+
+	// Next bar: enough future for every label.
+	n, err = ReconcileLabels(ctx, store, "ETHUSDT", "15m", history, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	left, err = store.UnlabeledTimes(ctx, "ETHUSDT", "15m", []string{"next_return", "next_slope_3", "next_slope_5"}, time.Unix(0, 0), time.Unix(target, 0))
+	require.NoError(t, err)
+	assert.Empty(t, left)
+	rows, err := store.QueryTopN(ctx, "ETHUSDT", "15m", []float64{1, 0}, 1)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.InDelta(t, (104.0-103)/103, rows[0].NextReturn, 1e-12)
+	assert.InDelta(t, embedding.CalculateSlope([]float64{104, 105, 106}), rows[0].NextSlope3, 1e-12)
+	assert.InDelta(t, embedding.CalculateSlope([]float64{104, 105, 106, 107, 108}), rows[0].NextSlope5, 1e-12)
+}
+
+func TestReconcileLabels_NothingUnlabeled(t *testing.T) {
+	store := memory.NewStore()
+
+	n, err := ReconcileLabels(context.Background(), store, "ETHUSDT", "15m", candlesFrom(1_000_000, 100, 101, 102), []int{3})
+
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+}
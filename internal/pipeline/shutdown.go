@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+)
+
+// Inflight tracks the per-bar pipelines the live loop starts, so shutdown can
+// let a half-done ingest or order placement finish instead of killing it.
+// Safe for concurrent use.
+type Inflight struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+}
+
+// Go runs fn in a goroutine unless Drain has begun, in which case fn is
+// dropped and Go returns false.
+func (f *Inflight) Go(fn func()) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.draining {
+		return false
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		fn()
+	}()
+	return true
+}
+
+// Drain stops accepting work and waits up to timeout for running work to
+// return. It reports whether everything finished in time.
+func (f *Inflight) Drain(timeout time.Duration) bool {
+	f.mu.Lock()
+	f.draining = true
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
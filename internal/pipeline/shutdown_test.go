@@ -0,0 +1,44 @@
+package pipeline
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInflight_DrainWaitsForRunningWork(t *testing.T) {
+	var f Inflight
+	var finished atomic.Bool
+	started := make(chan struct{})
+
+	assert.True(t, f.Go(func() {
+		close(started)
+		time.Sleep(50 * time.Millisecond) // e.g. an upsert or PlaceTrade in progress
+		finished.Store(true)
+	}))
+	<-started
+
+	assert.True(t, f.Drain(time.Second))
+	assert.True(t, finished.Load(), "in-flight work completed before Drain returned")
+}
+
+func TestInflight_RejectsWorkAfterDrain(t *testing.T) {
+	var f Inflight
+	assert.True(t, f.Drain(time.Second))
+
+	var ran atomic.Bool
+	assert.False(t, f.Go(func() { ran.Store(true) }))
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, ran.Load())
+}
+
+func TestInflight_DrainTimesOut(t *testing.T) {
+	var f Inflight
+	release := make(chan struct{})
+	defer close(release)
+	f.Go(func() { <-release })
+
+	assert.False(t, f.Drain(20*time.Millisecond))
+}
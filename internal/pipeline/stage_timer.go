@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// StageTimer records how long each live-loop stage (fetch, feature, search,
+// chart, llm, trade) took for one candle, so slow bars can be traced to the
+// stage that caused them.
+type StageTimer struct {
+	now    func() time.Time
+	start  time.Time
+	stages []stageDuration
+}
+
+type stageDuration struct {
+	name string
+	d    time.Duration
+}
+
+// NewStageTimer starts a timer; now is the clock (time.Now outside tests).
+func NewStageTimer(now func() time.Time) *StageTimer {
+	return &StageTimer{now: now, start: now()}
+}
+
+// Start begins a stage and returns the func that ends it. Calling the stop
+// func more than once only records the first call.
+func (t *StageTimer) Start(name string) func() {
+	if t == nil {
+		return func() {}
+	}
+	begin := t.now()
+	done := false
+	return func() {
+		if done {
+			return
+		}
+		done = true
+		t.stages = append(t.stages, stageDuration{name: name, d: t.now().Sub(begin)})
+	}
+}
+
+// Durations returns the recorded stages by name; a stage entered twice is summed.
+func (t *StageTimer) Durations() map[string]time.Duration {
+	out := make(map[string]time.Duration, len(t.stages))
+	for _, s := range t.stages {
+		out[s.name] += s.d
+	}
+	return out
+}
+
+// LogAttrs renders the stages as "<name>_ms" fields plus total_ms, in the
+// order they ran.
+func (t *StageTimer) LogAttrs() []any {
+	attrs := make([]any, 0, 2*len(t.stages)+2)
+	for _, s := range t.stages {
+		attrs = append(attrs, slog.Int64(s.name+"_ms", s.d.Milliseconds()))
+	}
+	return append(attrs, slog.Int64("total_ms", t.now().Sub(t.start).Milliseconds()))
+}
+
+type stageTimerKey struct{}
+
+// withStageTimer attaches t so nested flows can time their own stages.
+func withStageTimer(ctx context.Context, t *StageTimer) context.Context {
+	return context.WithValue(ctx, stageTimerKey{}, t)
+}
+
+// startStage starts name on the context's timer; a no-op without one.
+func startStage(ctx context.Context, name string) func() {
+	t, _ := ctx.Value(stageTimerKey{}).(*StageTimer)
+	return t.Start(name)
+}
@@ -0,0 +1,46 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock advances by step on every read.
+func fakeClock(step time.Duration) func() time.Time {
+	t := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		t = t.Add(step)
+		return t
+	}
+}
+
+func TestStageTimer_RecordsEachStage(t *testing.T) {
+	timer := NewStageTimer(fakeClock(10 * time.Millisecond))
+	ctx := withStageTimer(context.Background(), timer)
+
+	stopFeature := timer.Start("feature")
+	stopFeature()
+	stopSearch := startStage(ctx, "search")
+	stopSearch()
+	stopSearch() // second stop is ignored
+
+	d := timer.Durations()
+	assert.Equal(t, 10*time.Millisecond, d["feature"])
+	assert.Equal(t, 10*time.Millisecond, d["search"])
+	assert.Len(t, d, 2)
+
+	attrs := timer.LogAttrs()
+	assert.Equal(t, []any{
+		slog.Int64("feature_ms", 10),
+		slog.Int64("search_ms", 10),
+		slog.Int64("total_ms", 50),
+	}, attrs)
+}
+
+func TestStartStage_NoTimerIsNoop(t *testing.T) {
+	assert.NotPanics(t, func() { startStage(context.Background(), "llm")() })
+}
@@ -0,0 +1,34 @@
+package pipeline
+
+import "time"
+
+// WarmupGate holds off trading for the first bars after startup, while the
+// candle buffer and recent patterns fill in. Those bars still run the full
+// ingest and analysis; only order execution waits.
+type WarmupGate struct {
+	start time.Time
+}
+
+func NewWarmupGate(start time.Time) *WarmupGate {
+	return &WarmupGate{start: start}
+}
+
+// BarsRemaining is how many more bars must pass before barTime (a candle's
+// open time) may trade. The bar in progress at start is the first warmup
+// bar, so with bars = 4 and a 15m interval, a 10:07 start trades from the
+// 11:00 bar on. bars <= 0 disables the gate.
+func (g *WarmupGate) BarsRemaining(barTime time.Time, interval time.Duration, bars int) int {
+	if bars <= 0 || interval <= 0 {
+		return 0
+	}
+	readyAt := g.start.Truncate(interval).Add(time.Duration(bars) * interval)
+	if !barTime.Before(readyAt) {
+		return 0
+	}
+	diff := readyAt.Sub(barTime)
+	n := int(diff / interval)
+	if diff%interval > 0 {
+		n++ // round up
+	}
+	return n
+}
@@ -0,0 +1,27 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmupGate_BlocksThenAllows(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 7, 0, 0, time.UTC)
+	g := NewWarmupGate(start)
+	bar := func(hh, mm int) time.Time { return time.Date(2026, 3, 1, hh, mm, 0, 0, time.UTC) }
+
+	assert.Equal(t, 4, g.BarsRemaining(bar(10, 0), 15*time.Minute, 4), "bar in progress at startup")
+	assert.Equal(t, 3, g.BarsRemaining(bar(10, 15), 15*time.Minute, 4))
+	assert.Equal(t, 1, g.BarsRemaining(bar(10, 45), 15*time.Minute, 4))
+	assert.Zero(t, g.BarsRemaining(bar(11, 0), 15*time.Minute, 4), "trading allowed after N bars")
+	assert.Zero(t, g.BarsRemaining(bar(12, 30), 15*time.Minute, 4))
+}
+
+func TestWarmupGate_Disabled(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 7, 0, 0, time.UTC)
+	g := NewWarmupGate(start)
+
+	assert.Zero(t, g.BarsRemaining(start.Truncate(15*time.Minute), 15*time.Minute, 0))
+}
@@ -1,10 +1,12 @@
 package plot
 
 import (
+	"bytes"
 	"fmt"
 	"image/color"
 	"math"
 	"os"
+	"strconv"
 	"time"
 
 	"gonum.org/v1/plot"
@@ -28,15 +30,120 @@ var (
 	Ma99Color   = color.RGBA{R: 216, G: 64, B: 174, A: 255}  // Pink
 )
 
+// Theme is the candle chart palette. The MA names describe the MA colours
+// in words, for the LLM prompt's chart legend.
+type Theme struct {
+	Background, Grid, Text      color.RGBA
+	Up, Down                    color.RGBA
+	MA7, MA25, MA99             color.RGBA
+	MA7Name, MA25Name, MA99Name string
+}
+
+// Built-in themes; ThemeBinance is the default.
+var (
+	ThemeBinance = Theme{
+		Background: BgDark, Grid: GridDark, Text: TextLight,
+		Up: BinanceUp, Down: BinanceDown,
+		MA7: Ma7Color, MA25: Ma25Color, MA99: Ma99Color,
+		// "orange" is the name the v1 prompt has always used for MA7; kept
+		// so the default v1 prompt is unchanged.
+		MA7Name: "orange", MA25Name: "purple", MA99Name: "pink",
+	}
+	// ThemeHighContrast is black on white with saturated candles.
+	ThemeHighContrast = Theme{
+		Background: color.RGBA{R: 255, G: 255, B: 255, A: 255},
+		Grid:       color.RGBA{R: 200, G: 200, B: 200, A: 255},
+		Text:       color.RGBA{R: 0, G: 0, B: 0, A: 255},
+		Up:         color.RGBA{R: 0, G: 160, B: 0, A: 255},
+		Down:       color.RGBA{R: 220, G: 0, B: 0, A: 255},
+		MA7:        color.RGBA{R: 0, G: 0, B: 0, A: 255},
+		MA25:       color.RGBA{R: 0, G: 90, B: 255, A: 255},
+		MA99:       color.RGBA{R: 255, G: 140, B: 0, A: 255},
+		MA7Name:    "black",
+		MA25Name:   "blue",
+		MA99Name:   "orange",
+	}
+	// ThemeColorblind swaps red/green for the Okabe-Ito blue/orange pair.
+	ThemeColorblind = Theme{
+		Background: BgDark, Grid: GridDark, Text: TextLight,
+		Up:      color.RGBA{R: 0, G: 114, B: 178, A: 255},   // #0072b2 (Blue)
+		Down:    color.RGBA{R: 230, G: 159, B: 0, A: 255},   // #e69f00 (Orange)
+		MA7:     color.RGBA{R: 240, G: 228, B: 66, A: 255},  // #f0e442 (Yellow)
+		MA25:    color.RGBA{R: 204, G: 121, B: 167, A: 255}, // #cc79a7 (Pink)
+		MA99:    color.RGBA{R: 86, G: 180, B: 233, A: 255},  // #56b4e9 (Sky)
+		MA7Name: "yellow", MA25Name: "pink", MA99Name: "sky blue",
+	}
+)
+
+// ThemeByName maps a config value (binance | high-contrast | colorblind) to a
+// Theme; empty means binance.
+func ThemeByName(name string) (Theme, error) {
+	switch name {
+	case "", "binance":
+		return ThemeBinance, nil
+	case "high-contrast":
+		return ThemeHighContrast, nil
+	case "colorblind":
+		return ThemeColorblind, nil
+	}
+	return Theme{}, fmt.Errorf("unknown chart theme %q (want binance, high-contrast or colorblind)", name)
+}
+
+// orDefault lets zero-value plotters keep the Binance palette.
+func (t Theme) orDefault() Theme {
+	if t == (Theme{}) {
+		return ThemeBinance
+	}
+	return t
+}
+
+// maColor is the palette slot for the i-th moving average; a chart with
+// more than three MAs reuses the slots in order.
+func (t Theme) maColor(i int) color.RGBA {
+	slots := [...]color.RGBA{t.MA7, t.MA25, t.MA99}
+	return slots[i%len(slots)]
+}
+
+// MAColorName names the colour of the i-th moving average, following the
+// same slots as the chart; the zero Theme names the Binance palette.
+func (t Theme) MAColorName(i int) string {
+	t = t.orDefault()
+	slots := [...]string{t.MA7Name, t.MA25Name, t.MA99Name}
+	return slots[i%len(slots)]
+}
+
+// candleColor is Up for a bullish (or flat) bar and Down otherwise.
+func (t Theme) candleColor(up bool) color.RGBA {
+	t = t.orDefault()
+	if up {
+		return t.Up
+	}
+	return t.Down
+}
+
 const displayN = 30
 
+// DefaultMAPeriods are the moving averages drawn when none are configured.
+var DefaultMAPeriods = []int{7, 25, 99}
+
+// Levels are the prices a trade was placed at; a zero level is not drawn.
+type Levels struct {
+	Entry, StopLoss, TakeProfit float64
+}
+
+// MALabel is the legend entry for a period, e.g. "MA(25)".
+func MALabel(period int) string {
+	return fmt.Sprintf("MA(%d)", period)
+}
+
 // --- 1. Custom Candlestick Plotter ---
 type OHLC struct {
 	Open, High, Low, Close float64
 }
 
 type Candles struct {
-	Data []OHLC
+	Data  []OHLC
+	Theme Theme // zero value = ThemeBinance
 }
 
 type V struct {
@@ -44,7 +151,8 @@ type V struct {
 	Up     bool
 }
 type VolumeCandle struct {
-	Data []V
+	Data  []V
+	Theme Theme // zero value = ThemeBinance
 }
 
 // Plot implements the Plotter interface to draw candles manually
@@ -60,10 +168,7 @@ func (c *Candles) Plot(canvas draw.Canvas, plt *plot.Plot) {
 		x := trX(float64(i))
 
 		// 1. Determine Color
-		col := BinanceDown
-		if d.Close >= d.Open {
-			col = BinanceUp
-		}
+		col := c.Theme.candleColor(d.Close >= d.Open)
 
 		// 2. Draw Wick (High to Low line)
 		lineStyle := draw.LineStyle{Color: col, Width: vg.Points(1)}
@@ -99,10 +204,7 @@ func (vc *VolumeCandle) Plot(canvas draw.Canvas, plt *plot.Plot) {
 		x := trX(float64(i))
 
 		// Choose color based on up/down
-		col := BinanceDown
-		if v.Up {
-			col = BinanceUp
-		}
+		col := vc.Theme.candleColor(v.Up)
 
 		// Draw volume bar from 0 to the volume value
 		rect := vg.Rectangle{
@@ -175,35 +277,101 @@ func calculateSMA(data []float64, period int) []float64 {
 	return sma
 }
 
+// hasVolume reports whether any candle carries volume. Feeds that never parse
+// it leave every bar at 0, and an empty panel would read as "no participation".
+func hasVolume(candles []exchange.WsRestCandle) bool {
+	for _, c := range candles {
+		if c.Volume > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCanvas gives price the top 3/4 and volume the bottom 1/4, or the whole
+// area to price when there is no volume panel.
+func splitCanvas(r vg.Rectangle, withVolume bool) (price, volume vg.Rectangle) {
+	if !withVolume {
+		return r, vg.Rectangle{}
+	}
+	splitY := r.Min.Y + (r.Max.Y-r.Min.Y)/4
+	price = vg.Rectangle{Min: vg.Point{X: r.Min.X, Y: splitY}, Max: r.Max}
+	volume = vg.Rectangle{Min: r.Min, Max: vg.Point{X: r.Max.X, Y: splitY}}
+	return price, volume
+}
+
 // --- 3. Main Chart Generation Function ---
 // ... (Imports and Ticker struct remain the same) ...
 
 func GenerateCandleChart(candles []exchange.WsRestCandle, filename string, lastNPlot ...int) error {
+	return GenerateThemedCandleChart(candles, filename, ThemeBinance, lastNPlot...)
+}
+
+// GenerateThemedCandleChart is GenerateCandleChart drawn with theme.
+func GenerateThemedCandleChart(candles []exchange.WsRestCandle, filename string, theme Theme, lastNPlot ...int) error {
+	png, err := GenerateThemedCandleChartBytes(candles, theme, lastNPlot...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, png, 0o644)
+}
+
+// GenerateCandleChartBytes renders the candle chart as PNG bytes without
+// touching disk, so concurrent symbols never share a file.
+func GenerateCandleChartBytes(candles []exchange.WsRestCandle, lastNPlot ...int) ([]byte, error) {
+	return GenerateThemedCandleChartBytes(candles, ThemeBinance, lastNPlot...)
+}
+
+// GenerateThemedCandleChartBytes is GenerateCandleChartBytes drawn with theme.
+func GenerateThemedCandleChartBytes(candles []exchange.WsRestCandle, theme Theme, lastNPlot ...int) ([]byte, error) {
+	return GenerateCandleChartWithMAsBytes(candles, theme, DefaultMAPeriods, lastNPlot...)
+}
+
+// GenerateCandleChartWithMAsBytes is GenerateThemedCandleChartBytes with the
+// moving averages of maPeriods (empty = DefaultMAPeriods) in that order.
+func GenerateCandleChartWithMAsBytes(candles []exchange.WsRestCandle, theme Theme, maPeriods []int, lastNPlot ...int) ([]byte, error) {
+	return GenerateAnnotatedCandleChartBytes(candles, theme, maPeriods, Levels{}, lastNPlot...)
+}
+
+// GenerateAnnotatedCandleChartBytes is GenerateCandleChartWithMAsBytes with
+// the trade's entry, stop-loss and take-profit drawn as labelled dashed lines,
+// so a saved chart shows where the bot meant to get in and out.
+func GenerateAnnotatedCandleChartBytes(candles []exchange.WsRestCandle, theme Theme, maPeriods []int, levels Levels, lastNPlot ...int) ([]byte, error) {
+	png, _, err := renderCandleChart(candles, theme, maPeriods, levels, lastNPlot...)
+	return png, err
+}
+
+// renderCandleChart also returns the legend entries it added, in order.
+func renderCandleChart(candles []exchange.WsRestCandle, theme Theme, maPeriods []int, levels Levels, lastNPlot ...int) ([]byte, []string, error) {
+	theme = theme.orDefault()
+	if len(maPeriods) == 0 {
+		maPeriods = DefaultMAPeriods
+	}
 	p := plot.New()
 	volumePlot := plot.New()
 
 	// 1. Styling
-	p.BackgroundColor = BgDark
+	p.BackgroundColor = theme.Background
 	p.Title.Text = fmt.Sprintf("Price Action [%s]", time.Now().Format("15:04"))
-	p.Title.TextStyle.Color = TextLight
-	p.X.Label.TextStyle.Color = TextLight
-	p.Y.Label.TextStyle.Color = TextLight
-	p.X.Tick.Label.Color = TextLight
-	p.Y.Tick.Label.Color = TextLight
-	p.X.Tick.LineStyle.Color = TextLight
-	p.Y.Tick.LineStyle.Color = TextLight
+	p.Title.TextStyle.Color = theme.Text
+	p.X.Label.TextStyle.Color = theme.Text
+	p.Y.Label.TextStyle.Color = theme.Text
+	p.X.Tick.Label.Color = theme.Text
+	p.Y.Tick.Label.Color = theme.Text
+	p.X.Tick.LineStyle.Color = theme.Text
+	p.Y.Tick.LineStyle.Color = theme.Text
 
 	// Grid
 	grid := plotter.NewGrid()
-	grid.Vertical.Color = GridDark
-	grid.Horizontal.Color = GridDark
+	grid.Vertical.Color = theme.Grid
+	grid.Horizontal.Color = theme.Grid
 	p.Add(grid)
 
-	volumePlot.BackgroundColor = BgDark
-	volumePlot.X.Tick.Label.Color = TextLight
-	volumePlot.Y.Tick.Label.Color = TextLight
-	volumePlot.X.Tick.LineStyle.Color = TextLight
-	volumePlot.Y.Tick.LineStyle.Color = TextLight
+	volumePlot.BackgroundColor = theme.Background
+	volumePlot.X.Tick.Label.Color = theme.Text
+	volumePlot.Y.Tick.Label.Color = theme.Text
+	volumePlot.X.Tick.LineStyle.Color = theme.Text
+	volumePlot.Y.Tick.LineStyle.Color = theme.Text
 
 	// 2. Prepare Data
 	closePrices := make([]float64, len(candles))
@@ -241,17 +409,18 @@ func GenerateCandleChart(candles []exchange.WsRestCandle, filename string, lastN
 	}
 
 	// 3. Add Candles
-	candlePlot := &Candles{Data: ohlcData}
+	candlePlot := &Candles{Data: ohlcData, Theme: theme}
 	p.Add(candlePlot)
 	p.X.Min = 0
 	p.X.Max = float64(plotLen)
 
-	volumeBars := &VolumeCandle{Data: vData}
+	volumeBars := &VolumeCandle{Data: vData, Theme: theme}
 	volumePlot.Add(volumeBars)
 	volumePlot.X.Min = 0
 	volumePlot.X.Max = float64(plotLen)
 
 	// 4. Add Moving Averages (คำนวณจาก closePrices ทั้งหมด แต่ plot เฉพาะช่วง lastNPlot)
+	var legend []string
 	addMA := func(period int, col color.RGBA) {
 		maData := calculateSMA(closePrices, period) // คำนวณทั้งหมด
 		pts := make(plotter.XYs, 0)
@@ -268,16 +437,40 @@ func GenerateCandleChart(candles []exchange.WsRestCandle, filename string, lastN
 		line.LineStyle.Color = col
 		line.LineStyle.Width = vg.Points(1.5)
 		p.Add(line)
-		p.Legend.Add(fmt.Sprintf("MA(%d)", period), line)
+		p.Legend.Add(MALabel(period), line)
+		legend = append(legend, MALabel(period))
+	}
+
+	for i, period := range maPeriods {
+		if period > 0 {
+			addMA(period, theme.maColor(i))
+		}
 	}
 
-	addMA(7, Ma7Color)
-	addMA(25, Ma25Color)
-	addMA(99, Ma99Color)
+	// 5. Trade levels: entry in the text color, SL/TP in the down/up colors.
+	addLevel := func(name string, price float64, col color.RGBA) {
+		if price <= 0 || plotLen == 0 {
+			return
+		}
+		line, err := plotter.NewLine(plotter.XYs{{X: 0, Y: price}, {X: float64(plotLen), Y: price}})
+		if err != nil {
+			return
+		}
+		line.LineStyle.Color = col
+		line.LineStyle.Width = vg.Points(1)
+		line.LineStyle.Dashes = []vg.Length{vg.Points(4), vg.Points(3)}
+		p.Add(line)
+		label := fmt.Sprintf("%s %s", name, strconv.FormatFloat(price, 'f', -1, 64))
+		p.Legend.Add(label, line)
+		legend = append(legend, label)
+	}
+	addLevel("Entry", levels.Entry, theme.Text)
+	addLevel("SL", levels.StopLoss, theme.Down)
+	addLevel("TP", levels.TakeProfit, theme.Up)
 
 	p.Legend.Top = true
 	p.Legend.Left = true
-	p.Legend.TextStyle.Color = TextLight
+	p.Legend.TextStyle.Color = theme.Text
 
 	img := vgimg.NewWith(
 		vgimg.UseWH(8*vg.Inch, 5*vg.Inch),
@@ -285,36 +478,23 @@ func GenerateCandleChart(candles []exchange.WsRestCandle, filename string, lastN
 	)
 	dc := draw.New(img)
 
-	// price = 2/3, volume = 1/3
-	totalH := dc.Rectangle.Max.Y - dc.Rectangle.Min.Y
-	splitY := dc.Rectangle.Min.Y + totalH/4
+	withVolume := hasVolume(plotCandles)
+	priceRect, volumeRect := splitCanvas(dc.Rectangle, withVolume)
 
-	priceCanvas := draw.Canvas{
-		Canvas: dc,
-		Rectangle: vg.Rectangle{
-			Min: vg.Point{X: dc.Rectangle.Min.X, Y: splitY},
-			Max: vg.Point{X: dc.Rectangle.Max.X, Y: dc.Rectangle.Max.Y},
-		},
-	}
-	volumeCanvas := draw.Canvas{
-		Canvas: dc,
-		Rectangle: vg.Rectangle{
-			Min: vg.Point{X: dc.Rectangle.Min.X, Y: dc.Rectangle.Min.Y},
-			Max: vg.Point{X: dc.Rectangle.Max.X, Y: splitY},
-		},
+	p.Draw(draw.Canvas{Canvas: dc, Rectangle: priceRect})
+	if withVolume {
+		volumePlot.Draw(draw.Canvas{Canvas: dc, Rectangle: volumeRect})
 	}
 
-	p.Draw(priceCanvas)
-	volumePlot.Draw(volumeCanvas)
+	png, err := encodePNG(img)
+	return png, legend, err
+}
 
-	w, err := os.Create(filename)
-	if err != nil {
-		return err
+// encodePNG serialises a rendered canvas.
+func encodePNG(img *vgimg.Canvas) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := (vgimg.PngCanvas{Canvas: img}).WriteTo(&buf); err != nil {
+		return nil, err
 	}
-	defer w.Close()
-
-	png := vgimg.PngCanvas{Canvas: img}
-	_, err = png.WriteTo(w)
-
-	return err
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,137 @@
+package plot
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+
+	"time-series-rag-agent/internal/exchange"
+)
+
+func flatCandles(n int, volume float64) []exchange.WsRestCandle {
+	out := make([]exchange.WsRestCandle, n)
+	for i := range out {
+		p := 100 + float64(i)
+		out[i] = exchange.WsRestCandle{Time: int64(i * 900), Open: p, High: p + 1, Low: p - 1, Close: p + 0.5, Volume: volume}
+	}
+	return out
+}
+
+func TestGenerateCandleChart_ZeroVolume_PriceTakesWholeCanvas(t *testing.T) {
+	candles := flatCandles(40, 0)
+	out := filepath.Join(t.TempDir(), "candle.png")
+
+	err := GenerateCandleChart(candles, out, 30)
+
+	assert.NoError(t, err)
+	assert.FileExists(t, out)
+	assert.False(t, hasVolume(candles))
+	full := vg.Rectangle{Max: vg.Point{X: 576, Y: 360}}
+	price, volume := splitCanvas(full, false)
+	assert.Equal(t, full, price, "no empty volume panel is reserved")
+	assert.Equal(t, vg.Rectangle{}, volume)
+}
+
+func TestSplitCanvas_WithVolume_QuarterPanel(t *testing.T) {
+	full := vg.Rectangle{Max: vg.Point{X: 576, Y: 360}}
+
+	price, volume := splitCanvas(full, true)
+
+	assert.Equal(t, vg.Length(90), volume.Max.Y)
+	assert.Equal(t, vg.Length(90), price.Min.Y)
+	assert.Equal(t, full.Max, price.Max)
+	assert.True(t, hasVolume(flatCandles(3, 1.5)))
+}
+
+func TestCandles_CustomTheme_BullishUsesUpColor(t *testing.T) {
+	up := color.RGBA{R: 1, G: 2, B: 250, A: 255}
+	theme := ThemeHighContrast
+	theme.Up = up
+	p := plot.New()
+	p.HideAxes()
+	p.Add(&Candles{Data: []OHLC{{Open: 10, High: 21, Low: 9, Close: 20}}, Theme: theme})
+	img := vgimg.New(100, 100)
+
+	p.Draw(draw.New(img))
+
+	// Bar 0 sits on the left edge and its body spans most of the height.
+	b := img.Image().Bounds()
+	r, g, bl, _ := img.Image().At(b.Dx()/10, b.Dy()/2).RGBA()
+	assert.Equal(t, [3]uint32{uint32(up.R), uint32(up.G), uint32(up.B)}, [3]uint32{r >> 8, g >> 8, bl >> 8})
+}
+
+func TestThemeByName(t *testing.T) {
+	th, err := ThemeByName("")
+	assert.NoError(t, err)
+	assert.Equal(t, ThemeBinance, th)
+
+	th, err = ThemeByName("colorblind")
+	assert.NoError(t, err)
+	assert.Equal(t, ThemeColorblind.Up, th.Up)
+
+	_, err = ThemeByName("neon")
+	assert.Error(t, err)
+}
+
+func TestGenerateCandleChartBytes_DecodesAsPNG(t *testing.T) {
+	b, err := GenerateCandleChartBytes(flatCandles(40, 2), 30)
+
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 576, 360), img.Bounds(), "8x5in at 72dpi")
+}
+
+func TestRenderCandleChart_CustomMAPeriods_Legend(t *testing.T) {
+	b, legend, err := renderCandleChart(flatCandles(60, 2), ThemeBinance, []int{5, 20}, Levels{}, 30)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MA(5)", "MA(20)"}, legend)
+	_, err = png.Decode(bytes.NewReader(b))
+	assert.NoError(t, err)
+
+	_, legend, err = renderCandleChart(flatCandles(60, 2), ThemeBinance, nil, Levels{}, 30)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MA(7)", "MA(25)", "MA(99)"}, legend, "empty keeps the default set")
+}
+
+func TestRenderCandleChart_TradeLevels(t *testing.T) {
+	b, legend, err := renderCandleChart(flatCandles(60, 2), ThemeBinance, []int{7}, Levels{Entry: 150.5, StopLoss: 149, TakeProfit: 153.25}, 30)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MA(7)", "Entry 150.5", "SL 149", "TP 153.25"}, legend)
+	_, err = png.Decode(bytes.NewReader(b))
+	assert.NoError(t, err)
+}
+
+func TestRenderCandleChart_ZeroOrPartialLevelsAreSkipped(t *testing.T) {
+	tests := []struct {
+		levels Levels
+		want   []string
+	}{
+		{Levels{}, []string{"MA(7)"}},
+		{Levels{Entry: 150}, []string{"MA(7)", "Entry 150"}},
+		{Levels{StopLoss: -1, TakeProfit: 153}, []string{"MA(7)", "TP 153"}},
+	}
+	for _, tt := range tests {
+		assert.NotPanics(t, func() {
+			_, legend, err := renderCandleChart(flatCandles(60, 2), ThemeBinance, []int{7}, tt.levels, 30)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, legend)
+		})
+	}
+	assert.NotPanics(t, func() {
+		_, err := GenerateAnnotatedCandleChartBytes(nil, ThemeBinance, nil, Levels{Entry: 1, StopLoss: 1, TakeProfit: 1})
+		assert.NoError(t, err)
+	})
+}
@@ -3,6 +3,7 @@ package plot
 import (
 	"fmt"
 	"image/color"
+	"log/slog"
 	"math"
 	"os"
 	"time"
@@ -27,9 +28,171 @@ func cumSum(data []float64) []float64 {
 	return res
 }
 
-func GeneratePredictionChart(currentEmbedding []float64, matches []embedding.PatternLabel, filename string) error {
+// priceShape is the cumulative price path of an embedding. Volume-augmented
+// embeddings carry log-volume deltas after the first priceDims values; those
+// are not a price path, so only the leading part is summed. priceDims <= 0
+// (or >= len) means the whole vector is price.
+func priceShape(vec []float64, priceDims int) []float64 {
+	if priceDims > 0 && priceDims < len(vec) {
+		vec = vec[:priceDims]
+	}
+	return cumSum(vec)
+}
+
+// ProjectionOptions sizes the dashed projections. Slope magnitudes grow with
+// the timeframe, so one fixed scale either flattens 1m projections or throws
+// 15m ones off the chart.
+type ProjectionOptions struct {
+	SlopeScale  float64 // cumulative Z-score units per unit of slope; <= 0 = fit to the history's y-range
+	FutureSteps float64 // projection length on the x axis, in bars; <= 0 = 15
+}
+
+const (
+	defaultFutureSteps = 15.0
+	// projectionReach is how far the steepest projection travels, as a share
+	// of the history's y-range, when the slope scale is fitted.
+	projectionReach = 0.5
+)
+
+func (o ProjectionOptions) futureSteps() float64 {
+	if o.FutureSteps <= 0 {
+		return defaultFutureSteps
+	}
+	return o.FutureSteps
+}
+
+// projectionSlope mirrors the prompt builder: slope_3, falling back to slope_5
+// when slope_3 is zero (short lookahead windows often leave it unset).
+func projectionSlope(m embedding.PatternLabel) float64 {
+	if m.NextSlope3 == 0 {
+		return m.NextSlope5
+	}
+	return m.NextSlope3
+}
+
+// projectionEndY is where a match's dashed projection ends, starting at lastY.
+func projectionEndY(lastY float64, m embedding.PatternLabel, scale float64) float64 {
+	return lastY + projectionSlope(m)*scale
+}
+
+// fitSlopeScale is the scale at which the steepest match projects
+// projectionReach of yRange. It is 0 when no match slopes, since every
+// projection is then flat whatever the scale.
+func fitSlopeScale(yRange float64, matches []matchShape) float64 {
+	steepest := 0.0
+	for _, m := range matches {
+		steepest = max(steepest, math.Abs(projectionSlope(m.label)))
+	}
+	if steepest == 0 {
+		return 0
+	}
+	if yRange <= 0 {
+		yRange = 1
+	}
+	return projectionReach * yRange / steepest
+}
+
+// matchShape is a drawable match with its cumulative price path.
+type matchShape struct {
+	label embedding.PatternLabel
+	shape []float64
+}
+
+// projectionLayout resolves the slope scale and the y-axis limits. The
+// limits cover every history point and projection end, plus 10% padding so
+// lines don't touch the edge.
+func projectionLayout(currentShape []float64, matches []matchShape, opts ProjectionOptions) (scale, yMin, yMax float64) {
+	yMin, yMax = math.Inf(1), math.Inf(-1)
+	include := func(y float64) {
+		yMin, yMax = min(yMin, y), max(yMax, y)
+	}
+	for _, v := range currentShape {
+		include(v)
+	}
+	for _, m := range matches {
+		for _, v := range m.shape {
+			include(v)
+		}
+	}
+	if math.IsInf(yMin, 1) {
+		yMin, yMax = 0, 0
+	}
+
+	scale = opts.SlopeScale
+	if scale <= 0 {
+		scale = fitSlopeScale(yMax-yMin, matches)
+	}
+	for _, m := range matches {
+		include(projectionEndY(m.shape[len(m.shape)-1], m.label, scale))
+	}
+
+	yRange := yMax - yMin
+	if yRange == 0 {
+		yRange = 1
+	} // prevent div/0
+	pad := yRange * 0.1
+	return scale, yMin - pad, yMax + pad
+}
+
+// consensusSubtitle summarises the matches' projected direction the way the
+// prompt counts it: slope > 0 is up, anything else down.
+func consensusSubtitle(matches []embedding.PatternLabel) string {
+	if len(matches) == 0 {
+		return "Consensus: no matches"
+	}
+	up := 0
+	for _, m := range matches {
+		if projectionSlope(m) > 0 {
+			up++
+		}
+	}
+	down := len(matches) - up
+	lean, pct := "UP", 100*up/len(matches)
+	if down > up {
+		lean, pct = "DOWN", 100*down/len(matches)
+	}
+	return fmt.Sprintf("Consensus: %d%% %s (%d up / %d down of %d)", pct, lean, up, down, len(matches))
+}
+
+// splitByDimension keeps the matches whose embedding is as long as current.
+// Vectors from a backfill run with a different VectorWindow would otherwise
+// be summed and projected on a misaligned time axis.
+func splitByDimension(current []float64, matches []embedding.PatternLabel) (kept []embedding.PatternLabel, skipped int) {
+	for _, m := range matches {
+		if len(m.Embedding.Slice()) != len(current) {
+			skipped++
+			continue
+		}
+		kept = append(kept, m)
+	}
+	return kept, skipped
+}
+
+// GeneratePredictionChart draws the current shape and each match's shape plus
+// its projected slope. priceDims is the price part of the vector (see
+// priceShape); pass 0 for plain price embeddings. Matches whose embedding
+// length differs from currentEmbedding are left out, logged, and counted in
+// the subtitle.
+func GeneratePredictionChart(currentEmbedding []float64, matches []embedding.PatternLabel, filename string, priceDims int, opts ProjectionOptions) error {
+	png, err := GeneratePredictionChartBytes(currentEmbedding, matches, priceDims, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, png, 0o644)
+}
+
+// GeneratePredictionChartBytes renders the prediction chart as PNG bytes
+// without touching disk.
+func GeneratePredictionChartBytes(currentEmbedding []float64, matches []embedding.PatternLabel, priceDims int, opts ProjectionOptions) ([]byte, error) {
+	matches, skipped := splitByDimension(currentEmbedding, matches)
+	subtitle := consensusSubtitle(matches)
+	if skipped > 0 {
+		slog.Warn("[PredictionChart] skipped matches with mismatched embedding length", "skipped", skipped, "want", len(currentEmbedding))
+		subtitle += fmt.Sprintf(" | %d skipped (dim != %d)", skipped, len(currentEmbedding))
+	}
+
 	p := plot.New()
-	p.Title.Text = fmt.Sprintf("AI Pattern Projection [%s]", time.Now().Format("15:04"))
+	p.Title.Text = fmt.Sprintf("AI Pattern Projection [%s]\n%s", time.Now().Format("15:04"), subtitle)
 	p.X.Label.Text = "Time Steps (Left=History | Right=Future)"
 	p.Y.Label.Text = "Cumulative Z-Score"
 	p.BackgroundColor = color.White
@@ -47,35 +210,24 @@ func GeneratePredictionChart(currentEmbedding []float64, matches []embedding.Pat
 	p.Add(grid)
 
 	// Settings
-	lookback := float64(len(currentEmbedding)) - 1
-	futureSteps := 15.0
-	const slopeScale = 2000.0
-
-	// Track Min/Max for Autoscaling
-	yMin, yMax := math.Inf(1), math.Inf(-1)
+	currentShape := priceShape(currentEmbedding, priceDims)
+	lookback := float64(len(currentShape)) - 1
+	futureSteps := opts.futureSteps()
 
-	// Helper to update limits
-	updateLimits := func(y float64) {
-		if y < yMin {
-			yMin = y
-		}
-		if y > yMax {
-			yMax = y
-		}
-	}
-
-	// --- 1. Plot Matches ---
+	var shapes []matchShape
 	for _, m := range matches {
 		if len(m.Embedding.Slice()) == 0 {
 			continue
 		}
+		shapes = append(shapes, matchShape{m, priceShape(toFloat64Slice(m.Embedding.Slice()), priceDims)})
+	}
+	slopeScale, plotMin, plotMax := projectionLayout(currentShape, shapes, opts)
 
-		shapeData := cumSum(toFloat64Slice(m.Embedding.Slice()))
-
-		// Update limits based on history
-		for _, v := range shapeData {
-			updateLimits(v)
-		}
+	// --- 1. Plot Matches ---
+	// The legend gets one sample line per colour, not one entry per match.
+	var upSample, downSample *plotter.Line
+	for _, ms := range shapes {
+		shapeData := ms.shape
 
 		// Plot Shape (Left)
 		shapePts := make(plotter.XYs, len(shapeData))
@@ -88,10 +240,8 @@ func GeneratePredictionChart(currentEmbedding []float64, matches []embedding.Pat
 
 		// Plot Projection (Right)
 		lastY := shapeData[len(shapeData)-1]
-		endY := lastY + (m.NextSlope3 * slopeScale)
-
-		// Update limits based on projection
-		updateLimits(endY)
+		slope := projectionSlope(ms.label)
+		endY := projectionEndY(lastY, ms.label, slopeScale)
 
 		lineRight, _ := plotter.NewLine(plotter.XYs{
 			{X: lookback, Y: lastY},
@@ -101,23 +251,27 @@ func GeneratePredictionChart(currentEmbedding []float64, matches []embedding.Pat
 		lineRight.LineStyle.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
 
 		// Color Logic
-		if m.NextSlope3 > 0 {
+		if slope > 0 {
 			lineLeft.LineStyle.Color = colGreen
 			lineRight.LineStyle.Color = colGreen
+			if upSample == nil {
+				upSample = lineLeft
+			}
 		} else {
 			lineLeft.LineStyle.Color = colRed
 			lineRight.LineStyle.Color = colRed
+			if downSample == nil {
+				downSample = lineLeft
+			}
 		}
 		p.Add(lineLeft, lineRight)
 	}
 
 	// --- 2. Plot Current Market ---
-	currentShape := cumSum(currentEmbedding)
 	currentPts := make(plotter.XYs, len(currentShape))
 	for i, v := range currentShape {
 		currentPts[i].X = float64(i)
 		currentPts[i].Y = v
-		updateLimits(v) // Check current market limits too
 	}
 
 	lineCurrent, _ := plotter.NewLine(currentPts)
@@ -126,15 +280,6 @@ func GeneratePredictionChart(currentEmbedding []float64, matches []embedding.Pat
 	p.Add(lineCurrent)
 
 	// --- 3. Dynamic Vertical Line ---
-	// Add 10% padding to the limits so lines don't touch the edge
-	yRange := yMax - yMin
-	if yRange == 0 {
-		yRange = 1
-	} // prevent div/0
-	pad := yRange * 0.1
-	plotMin := yMin - pad
-	plotMax := yMax + pad
-
 	cutoffLine, _ := plotter.NewLine(plotter.XYs{
 		{X: lookback, Y: plotMin},
 		{X: lookback, Y: plotMax},
@@ -144,6 +289,17 @@ func GeneratePredictionChart(currentEmbedding []float64, matches []embedding.Pat
 	cutoffLine.LineStyle.Dashes = []vg.Length{vg.Points(2), vg.Points(2)}
 	p.Add(cutoffLine)
 
+	p.Legend.Add("Current market", lineCurrent)
+	if upSample != nil {
+		p.Legend.Add("Match, projected up", upSample)
+	}
+	if downSample != nil {
+		p.Legend.Add("Match, projected down", downSample)
+	}
+	p.Legend.Add("Now (projection starts)", cutoffLine)
+	p.Legend.Top = true
+	p.Legend.Left = true
+
 	// --- 4. Final Scale ---
 	p.X.Min = 0
 	p.X.Max = lookback + futureSteps + 2
@@ -157,15 +313,7 @@ func GeneratePredictionChart(currentEmbedding []float64, matches []embedding.Pat
 	dc := draw.New(img)
 	p.Draw(dc)
 
-	w, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer w.Close()
-
-	png := vgimg.PngCanvas{Canvas: img}
-	_, err = png.WriteTo(w)
-	return err
+	return encodePNG(img)
 }
 
 func toFloat64Slice(f32 []float32) []float64 {
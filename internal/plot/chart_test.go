@@ -0,0 +1,186 @@
+package plot
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"time-series-rag-agent/internal/embedding"
+)
+
+func TestProjectionSlope_ZeroSlope3_FallsBackToSlope5(t *testing.T) {
+	m := embedding.PatternLabel{NextSlope3: 0, NextSlope5: 0.002}
+
+	assert.Equal(t, 0.002, projectionSlope(m))
+}
+
+func TestProjectionEndY_ZeroSlope3_IsSloped(t *testing.T) {
+	m := embedding.PatternLabel{NextSlope3: 0, NextSlope5: 0.001}
+
+	endY := projectionEndY(1.5, m, 2000)
+
+	assert.NotEqual(t, 1.5, endY)
+	assert.Greater(t, endY, 1.5)
+}
+
+func TestProjectionSlope_PrefersSlope3(t *testing.T) {
+	m := embedding.PatternLabel{NextSlope3: -0.001, NextSlope5: 0.002}
+
+	assert.Equal(t, -0.001, projectionSlope(m))
+}
+
+func TestGeneratePredictionChart_ZeroSlope3_Renders(t *testing.T) {
+	matches := []embedding.PatternLabel{{
+		Embedding:  pgvector.NewVector([]float32{0.1, -0.2, 0.3}),
+		NextSlope5: 0.001,
+	}}
+	out := filepath.Join(t.TempDir(), "chart.png")
+
+	err := GeneratePredictionChart([]float64{0.2, -0.1, 0.4}, matches, out, 0, ProjectionOptions{})
+
+	assert.NoError(t, err)
+	assert.FileExists(t, out)
+}
+
+func TestPriceShape_VolumeAugmented_SumsPricePartOnly(t *testing.T) {
+	vec := []float64{1, 2, 3, 100, 200, 300} // 3 price dims + 3 volume dims
+
+	assert.Equal(t, []float64{1, 3, 6}, priceShape(vec, 3))
+	assert.Len(t, priceShape(vec, 0), 6, "0 keeps the whole vector")
+}
+
+func TestGeneratePredictionChartBytes_DecodesAsPNG(t *testing.T) {
+	matches := []embedding.PatternLabel{{
+		Embedding:  pgvector.NewVector([]float32{0.1, -0.2, 0.3}),
+		NextSlope3: 0.002,
+	}}
+
+	b, err := GeneratePredictionChartBytes([]float64{0.2, -0.1, 0.4}, matches, 0, ProjectionOptions{})
+
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 576, 288), img.Bounds(), "8x4in at 72dpi")
+}
+
+func TestConsensusSubtitle(t *testing.T) {
+	up := embedding.PatternLabel{NextSlope3: 0.001}
+	down := embedding.PatternLabel{NextSlope3: -0.001}
+	flat := embedding.PatternLabel{}
+
+	assert.Equal(t, "Consensus: no matches", consensusSubtitle(nil))
+	assert.Equal(t, "Consensus: 75% UP (3 up / 1 down of 4)", consensusSubtitle([]embedding.PatternLabel{up, up, up, down}))
+	assert.Equal(t, "Consensus: 66% DOWN (1 up / 2 down of 3)", consensusSubtitle([]embedding.PatternLabel{up, down, flat}), "zero slope counts as down, like the prompt")
+}
+
+func TestGeneratePredictionChartBytes_ZeroAndManyMatches(t *testing.T) {
+	current := []float64{0.2, -0.1, 0.4, 0.3, -0.2}
+
+	_, err := GeneratePredictionChartBytes(current, nil, 0, ProjectionOptions{})
+	require.NoError(t, err, "zero matches")
+
+	var matches []embedding.PatternLabel
+	for i := range 50 {
+		slope := 0.001
+		if i%3 == 0 {
+			slope = -0.001
+		}
+		matches = append(matches, embedding.PatternLabel{
+			Embedding:  pgvector.NewVector([]float32{0.1, float32(i%7) * 0.05, -0.2, 0.3, 0.1}),
+			NextSlope3: slope,
+		})
+	}
+	b, err := GeneratePredictionChartBytes(current, matches, 0, ProjectionOptions{})
+	require.NoError(t, err, "many matches")
+	_, err = png.Decode(bytes.NewReader(b))
+	assert.NoError(t, err)
+}
+
+func vectorOfLen(n int, v float32) pgvector.Vector {
+	vec := make([]float32, n)
+	for i := range vec {
+		vec[i] = v
+	}
+	return pgvector.NewVector(vec)
+}
+
+func TestSplitByDimension_MixedLengths(t *testing.T) {
+	current := make([]float64, 60)
+	matches := []embedding.PatternLabel{
+		{Embedding: vectorOfLen(60, 0.1), NextSlope3: 0.001},
+		{Embedding: vectorOfLen(30, 0.1), NextSlope3: 0.001},
+		{Embedding: vectorOfLen(60, -0.1), NextSlope3: -0.001},
+		{Embedding: vectorOfLen(30, -0.1), NextSlope3: -0.001},
+		{Embedding: vectorOfLen(30, 0.2), NextSlope3: 0.002},
+	}
+
+	kept, skipped := splitByDimension(current, matches)
+
+	assert.Equal(t, 3, skipped)
+	require.Len(t, kept, 2)
+	for _, m := range kept {
+		assert.Len(t, m.Embedding.Slice(), 60)
+	}
+}
+
+func TestGeneratePredictionChartBytes_MixedLengths_Renders(t *testing.T) {
+	current := make([]float64, 60)
+	for i := range current {
+		current[i] = 0.05
+	}
+	matches := []embedding.PatternLabel{
+		{Embedding: vectorOfLen(60, 0.1), NextSlope3: 0.001},
+		{Embedding: vectorOfLen(30, 0.1), NextSlope3: 0.001},
+	}
+
+	b, err := GeneratePredictionChartBytes(current, matches, 0, ProjectionOptions{})
+
+	require.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(b))
+	assert.NoError(t, err)
+}
+
+func TestProjectionLayout_EndpointsStayWithinBounds(t *testing.T) {
+	current := []float64{0.2, -0.1, 0.4, 0.3, -0.2}
+	shape := priceShape(current, 0)
+	cases := map[string]float64{
+		"1m, tiny slopes":   1e-6,
+		"15m, large slopes": 0.05,
+	}
+	for name, slope := range cases {
+		t.Run(name, func(t *testing.T) {
+			shapes := []matchShape{
+				{embedding.PatternLabel{NextSlope3: slope}, shape},
+				{embedding.PatternLabel{NextSlope3: -slope / 2}, shape},
+			}
+			for _, opts := range []ProjectionOptions{{}, {SlopeScale: 2000}} {
+				scale, yMin, yMax := projectionLayout(shape, shapes, opts)
+				for _, m := range shapes {
+					endY := projectionEndY(shape[len(shape)-1], m.label, scale)
+					assert.GreaterOrEqual(t, endY, yMin)
+					assert.LessOrEqual(t, endY, yMax)
+				}
+			}
+
+			// Fitted, the steepest projection travels half the history's range.
+			scale, _, _ := projectionLayout(shape, shapes, ProjectionOptions{})
+			lo, hi := shape[0], shape[0]
+			for _, v := range shape {
+				lo, hi = min(lo, v), max(hi, v)
+			}
+			assert.InDelta(t, projectionReach*(hi-lo), slope*scale, 1e-9)
+		})
+	}
+}
+
+func TestProjectionOptions_Defaults(t *testing.T) {
+	assert.Equal(t, 15.0, ProjectionOptions{}.futureSteps())
+	assert.Equal(t, 30.0, ProjectionOptions{FutureSteps: 30}.futureSteps())
+	assert.Zero(t, fitSlopeScale(1, []matchShape{{label: embedding.PatternLabel{}}}), "flat matches need no scale")
+}
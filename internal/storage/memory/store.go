@@ -0,0 +1,212 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/storage"
+
+	"github.com/pgvector/pgvector-go"
+)
+
+var _ storage.PatternStore = (*Store)(nil)
+
+// Store is an in-memory storage.PatternStore that ranks by cosine distance in
+// Go. Meant for tests and small deployments; search is O(rows).
+type Store struct {
+	mu   sync.RWMutex
+	rows map[rowKey]*row
+}
+
+type rowKey struct {
+	time     int64
+	symbol   string
+	interval string
+}
+
+type row struct {
+	embedding  []float64
+	closePrice float64
+	labels     map[string]float64 // by column; absent = NULL
+}
+
+// slopeColumnRe accepts the same next_slope_<bars> columns as the SQL store,
+// so any configured SlopeWindows can be backfilled here.
+var slopeColumnRe = regexp.MustCompile(`^next_slope_[1-9][0-9]{0,3}$`)
+
+func validateLabelColumn(col string) error {
+	if col != "next_return" && !slopeColumnRe.MatchString(col) {
+		return fmt.Errorf("invalid label column: %q", col)
+	}
+	return nil
+}
+
+func NewStore() *Store {
+	return &Store{rows: make(map[rowKey]*row)}
+}
+
+func (s *Store) UpsertFeature(ctx context.Context, f embedding.PatternFeature) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.upsert(f)
+	return nil
+}
+
+func (s *Store) BulkUpsertFeature(ctx context.Context, features []embedding.PatternFeature) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range features {
+		s.upsert(f)
+	}
+	return nil
+}
+
+func (s *Store) upsert(f embedding.PatternFeature) {
+	k := rowKey{f.Time.Unix(), f.Symbol, f.Interval}
+	r, ok := s.rows[k]
+	if !ok {
+		r = &row{}
+		s.rows[k] = r
+	}
+	r.embedding = append([]float64(nil), f.Embedding...)
+	r.closePrice = f.ClosePrice
+}
+
+// UpsertLabels mirrors the SQL version: a label for a missing row creates it.
+func (s *Store) UpsertLabels(ctx context.Context, symbol, interval string, labels []embedding.LabelUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range labels {
+		k := rowKey{l.TargetTime, symbol, interval}
+		r, ok := s.rows[k]
+		if !ok {
+			r = &row{}
+			s.rows[k] = r
+		}
+		if err := validateLabelColumn(l.Column); err != nil {
+			return err
+		}
+		if r.labels == nil {
+			r.labels = make(map[string]float64)
+		}
+		r.labels[l.Column] = l.Value
+	}
+	return nil
+}
+
+// QueryTopN returns the topN rows for symbol/interval with the same dimension
+// as queryEmbedding, nearest first by cosine distance.
+func (s *Store) QueryTopN(ctx context.Context, symbol, interval string, queryEmbedding []float64, topN int) ([]embedding.PatternLabel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []embedding.PatternLabel
+	for k, r := range s.rows {
+		if k.symbol != symbol || k.interval != interval || len(r.embedding) == 0 || len(r.embedding) != len(queryEmbedding) {
+			continue
+		}
+		vec := make([]float32, len(r.embedding))
+		for i, v := range r.embedding {
+			vec[i] = float32(v)
+		}
+		results = append(results, embedding.PatternLabel{
+			Time:       time.Unix(k.time, 0),
+			Symbol:     k.symbol,
+			Interval:   k.interval,
+			ClosePrice: r.closePrice,
+			NextReturn: r.labels["next_return"],
+			NextSlope3: r.labels["next_slope_3"],
+			NextSlope5: r.labels["next_slope_5"],
+			Embedding:  pgvector.NewVector(vec),
+			Distance:   CosineDistance(queryEmbedding, r.embedding),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Distance != results[j].Distance {
+			return results[i].Distance < results[j].Distance
+		}
+		return results[i].Time.Before(results[j].Time)
+	})
+	if topN >= 0 && len(results) > topN {
+		results = results[:topN]
+	}
+	return results, nil
+}
+
+// QueryTopNWithin is QueryTopN without the rows farther than maxDistance, so
+// it may return fewer than topN (or none).
+func (s *Store) QueryTopNWithin(ctx context.Context, symbol, interval string, queryEmbedding []float64, topN int, maxDistance float64) ([]embedding.PatternLabel, error) {
+	rows, err := s.QueryTopN(ctx, symbol, interval, queryEmbedding, topN)
+	if err != nil {
+		return nil, err
+	}
+	return storage.WithinDistance(rows, maxDistance), nil
+}
+
+// PrunePatterns deletes symbol/interval rows older than olderThan and returns
+// how many were removed.
+func (s *Store) PrunePatterns(ctx context.Context, symbol, interval string, olderThan time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for k := range s.rows {
+		if k.symbol == symbol && k.interval == interval && k.time < olderThan.Unix() {
+			delete(s.rows, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// UnlabeledTimes returns, oldest first, the times of symbol/interval rows
+// between from and to (inclusive) with a nil value in any of columns.
+func (s *Store) UnlabeledTimes(ctx context.Context, symbol, interval string, columns []string, from, to time.Time) ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []int64
+	for k, r := range s.rows {
+		if k.symbol != symbol || k.interval != interval || k.time < from.Unix() || k.time > to.Unix() {
+			continue
+		}
+		for _, col := range columns {
+			if err := validateLabelColumn(col); err != nil {
+				return nil, err
+			}
+			if _, ok := r.labels[col]; !ok {
+				out = append(out, k.time)
+				break
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out, nil
+}
+
+// Len returns the number of stored rows.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.rows)
+}
+
+// CosineDistance matches pgvector's <=>: 1 - cos(a, b). A zero vector yields
+// NaN in pgvector; here it yields 1 so such rows sort last instead of erroring.
+func CosineDistance(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 1
+	}
+	return 1 - dot/(math.Sqrt(na)*math.Sqrt(nb))
+}
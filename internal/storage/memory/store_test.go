@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func feature(t int64, symbol string, emb ...float64) embedding.PatternFeature {
+	return embedding.PatternFeature{Time: time.Unix(t, 0), Symbol: symbol, Interval: "15m", Embedding: emb}
+}
+
+func TestQueryTopN_OrdersByCosineDistance(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	assert.NoError(t, s.BulkUpsertFeature(ctx, []embedding.PatternFeature{
+		feature(1, "ETHUSDT", 1, 0, 0),    // same direction as query → 0
+		feature(2, "ETHUSDT", -1, 0, 0),   // opposite → 2
+		feature(3, "ETHUSDT", 1, 1, 0),    // 45° → ~0.293
+		feature(4, "ETHUSDT", 0, 1, 0),    // orthogonal → 1
+		feature(5, "ADAUSDT", 1, 0, 0),    // other symbol, excluded
+		feature(6, "ETHUSDT", 1, 0, 0, 0), // other dimension, excluded
+	}))
+
+	got, err := s.QueryTopN(ctx, "ETHUSDT", "15m", []float64{2, 0, 0}, 3)
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+	assert.Equal(t, []int64{1, 3, 4}, []int64{got[0].Time.Unix(), got[1].Time.Unix(), got[2].Time.Unix()})
+	assert.InDelta(t, 0.0, got[0].Distance, 1e-9)
+	assert.InDelta(t, 0.2928932, got[1].Distance, 1e-6)
+	assert.InDelta(t, 1.0, got[2].Distance, 1e-9)
+}
+
+func TestUpsertLabels_AttachToFeature(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	assert.NoError(t, s.UpsertFeature(ctx, feature(100, "ETHUSDT", 1, 2, 3)))
+
+	assert.NoError(t, s.UpsertLabels(ctx, "ETHUSDT", "15m", []embedding.LabelUpdate{
+		{TargetTime: 100, Column: "next_return", Value: 0.01},
+		{TargetTime: 100, Column: "next_slope_3", Value: 0.002},
+	}))
+	got, _ := s.QueryTopN(ctx, "ETHUSDT", "15m", []float64{1, 2, 3}, 1)
+
+	assert.Equal(t, 0.01, got[0].NextReturn)
+	assert.Equal(t, 0.002, got[0].NextSlope3)
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestUpsertLabels_InvalidColumn(t *testing.T) {
+	err := NewStore().UpsertLabels(context.Background(), "ETHUSDT", "15m", []embedding.LabelUpdate{{Column: "drop table"}})
+
+	assert.Error(t, err)
+}
+
+func TestQueryTopNWithin_DropsFarMatches(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	assert.NoError(t, s.BulkUpsertFeature(ctx, []embedding.PatternFeature{
+		feature(1, "ETHUSDT", 1, 0.1, 0), // near → ~0.005
+		feature(2, "ETHUSDT", 1, 1, 0),   // 45° → ~0.293
+		feature(3, "ETHUSDT", 0, 1, 0),   // orthogonal → 1
+		feature(4, "ETHUSDT", -1, 0, 0),  // opposite → 2
+	}))
+
+	near, err := s.QueryTopNWithin(ctx, "ETHUSDT", "15m", []float64{1, 0, 0}, 4, 0.3)
+	assert.NoError(t, err)
+	assert.Len(t, near, 2, "fewer than topN when the rest are too far")
+	assert.Equal(t, []int64{1, 2}, []int64{near[0].Time.Unix(), near[1].Time.Unix()})
+
+	none, err := s.QueryTopNWithin(ctx, "ETHUSDT", "15m", []float64{0, 0, 1}, 4, 0.3)
+	assert.NoError(t, err)
+	assert.Empty(t, none)
+
+	all, _ := s.QueryTopNWithin(ctx, "ETHUSDT", "15m", []float64{1, 0, 0}, 4, 0)
+	assert.Len(t, all, 4, "0 disables the threshold")
+}
+
+func TestPrunePatterns_RemovesOnlyOldRowsOfThatMarket(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	assert.NoError(t, s.BulkUpsertFeature(ctx, []embedding.PatternFeature{
+		feature(100, "ETHUSDT", 1, 0),
+		feature(200, "ETHUSDT", 1, 0),
+		feature(300, "ETHUSDT", 1, 0),
+		feature(100, "ADAUSDT", 1, 0),
+	}))
+
+	n, err := s.PrunePatterns(ctx, "ETHUSDT", "15m", time.Unix(300, 0))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, 2, s.Len(), "ETHUSDT@300 (not older) and ADAUSDT stay")
+	got, _ := s.QueryTopN(ctx, "ETHUSDT", "15m", []float64{1, 0}, 10)
+	assert.Len(t, got, 1)
+	assert.Equal(t, int64(300), got[0].Time.Unix())
+}
+
+func TestUnlabeledTimes_AnyNullColumnInRange(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	assert.NoError(t, s.BulkUpsertFeature(ctx, []embedding.PatternFeature{
+		feature(100, "ETHUSDT", 1, 0),
+		feature(200, "ETHUSDT", 1, 0),
+		feature(300, "ETHUSDT", 1, 0),
+		feature(400, "ETHUSDT", 1, 0),
+		feature(200, "ADAUSDT", 1, 0),
+	}))
+	assert.NoError(t, s.UpsertLabels(ctx, "ETHUSDT", "15m", []embedding.LabelUpdate{
+		{TargetTime: 200, Column: "next_return", Value: 0.01},
+		{TargetTime: 200, Column: "next_slope_3", Value: 0.1},
+		{TargetTime: 300, Column: "next_return", Value: 0.01},
+	}))
+	cols := []string{"next_return", "next_slope_3"}
+
+	got, err := s.UnlabeledTimes(ctx, "ETHUSDT", "15m", cols, time.Unix(100, 0), time.Unix(300, 0))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{100, 300}, got, "200 is fully labelled, 400 is out of range")
+	_, err = s.UnlabeledTimes(ctx, "ETHUSDT", "15m", []string{"bogus"}, time.Unix(0, 0), time.Unix(500, 0))
+	assert.Error(t, err)
+}
+
+func TestUpsertLabels_AnySlopeWindow(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	assert.NoError(t, s.UpsertFeature(ctx, feature(100, "ETHUSDT", 1, 2, 3)))
+
+	assert.NoError(t, s.UpsertLabels(ctx, "ETHUSDT", "15m", []embedding.LabelUpdate{
+		{TargetTime: 100, Column: "next_slope_10", Value: 0.004},
+	}))
+	missing, err := s.UnlabeledTimes(ctx, "ETHUSDT", "15m", []string{"next_slope_10"}, time.Unix(0, 0), time.Unix(200, 0))
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+
+	_, err = s.UnlabeledTimes(ctx, "ETHUSDT", "15m", []string{"next_slope_0"}, time.Unix(0, 0), time.Unix(200, 0))
+	assert.Error(t, err)
+}
+
+func TestQueryTopN_EqualDistancesOldestFirst(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	assert.NoError(t, s.BulkUpsertFeature(ctx, []embedding.PatternFeature{
+		feature(30, "ETHUSDT", 0, 1),
+		feature(10, "ETHUSDT", 0, -1),
+		feature(20, "ETHUSDT", 0, 2),
+		feature(40, "ETHUSDT", 3, 0),
+	}))
+
+	got, err := s.QueryTopN(ctx, "ETHUSDT", "15m", []float64{1, 0}, 4)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{40, 10, 20, 30}, []int64{got[0].Time.Unix(), got[1].Time.Unix(), got[2].Time.Unix(), got[3].Time.Unix()})
+}
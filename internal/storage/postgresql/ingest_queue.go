@@ -0,0 +1,302 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+)
+
+// LabelWrite is a LabelUpdate bound to its market.
+type LabelWrite struct {
+	Symbol   string
+	Interval string
+	embedding.LabelUpdate
+}
+
+// LabelWrites binds labels to symbol/interval.
+func LabelWrites(symbol, interval string, labels []embedding.LabelUpdate) []LabelWrite {
+	out := make([]LabelWrite, len(labels))
+	for i, l := range labels {
+		out[i] = LabelWrite{Symbol: symbol, Interval: interval, LabelUpdate: l}
+	}
+	return out
+}
+
+// IngestBatch is a set of feature and label writes applied together.
+type IngestBatch struct {
+	Features []embedding.PatternFeature
+	Labels   []LabelWrite
+}
+
+func (b IngestBatch) len() int { return len(b.Features) + len(b.Labels) }
+
+// coalesceBatches merges batches in arrival order. A later write to the same
+// row (feature) or row and column (label) replaces the earlier one, so one
+// multi-row upsert leaves the same state as applying each batch in turn and
+// never hits a row twice, which ON CONFLICT DO UPDATE rejects.
+func coalesceBatches(batches []IngestBatch) IngestBatch {
+	type rowKey struct {
+		symbol, interval string
+		time             int64
+	}
+	type labelKey struct {
+		rowKey
+		column string
+	}
+	var out IngestBatch
+	lastFeature := map[rowKey]int{}
+	lastLabel := map[labelKey]int{}
+	var features []embedding.PatternFeature
+	var labels []LabelWrite
+	for _, b := range batches {
+		for _, f := range b.Features {
+			lastFeature[rowKey{f.Symbol, f.Interval, f.Time.Unix()}] = len(features)
+			features = append(features, f)
+		}
+		for _, l := range b.Labels {
+			lastLabel[labelKey{rowKey{l.Symbol, l.Interval, l.TargetTime}, l.Column}] = len(labels)
+			labels = append(labels, l)
+		}
+	}
+	for i, f := range features {
+		if lastFeature[rowKey{f.Symbol, f.Interval, f.Time.Unix()}] == i {
+			out.Features = append(out.Features, f)
+		}
+	}
+	for i, l := range labels {
+		if lastLabel[labelKey{rowKey{l.Symbol, l.Interval, l.TargetTime}, l.Column}] == i {
+			out.Labels = append(out.Labels, l)
+		}
+	}
+	return out
+}
+
+// IngestBatch writes every feature, then every label, in one transaction.
+// Labels are applied in order; batches from coalesceBatches hold at most one
+// write per row and column.
+func (s *PatternStore) IngestBatch(ctx context.Context, b IngestBatch) error {
+	if b.len() == 0 || s.skipWrite("IngestBatch") {
+		return nil
+	}
+	if err := s.checkDim(b.Features...); err != nil {
+		return fmt.Errorf("IngestBatch: %w", err)
+	}
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("IngestBatch begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if len(b.Features) > 0 {
+		if err := s.upsertFeatureBatch(ctx, tx, b.Features); err != nil {
+			return fmt.Errorf("IngestBatch features: %w", err)
+		}
+	}
+
+	type group struct{ symbol, interval, column string }
+	var order []group
+	grouped := map[group][]LabelWrite{}
+	for _, l := range b.Labels {
+		if _, err := validateLabelColumn(l.Column); err != nil {
+			return err
+		}
+		g := group{l.Symbol, l.Interval, l.Column}
+		if _, ok := grouped[g]; !ok {
+			order = append(order, g)
+		}
+		grouped[g] = append(grouped[g], l)
+	}
+	for _, g := range order {
+		rows := grouped[g]
+		times := make([]int64, len(rows))
+		symbols := make([]string, len(rows))
+		intervals := make([]string, len(rows))
+		values := make([]float64, len(rows))
+		for i, l := range rows {
+			times[i], symbols[i], intervals[i], values[i] = l.TargetTime, l.Symbol, l.Interval, l.Value
+		}
+		if _, err := tx.Exec(ctx, labelColumnUpsertSQL(g.column), times, symbols, intervals, values); err != nil {
+			return fmt.Errorf("IngestBatch labels [%s %s %s]: %w", g.symbol, g.interval, g.column, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("IngestBatch commit: %w", err)
+	}
+	return nil
+}
+
+var errQueueClosed = errors.New("ingest queue closed")
+
+// BatchWriter applies one coalesced batch; *PatternStore implements it.
+type BatchWriter interface {
+	IngestBatch(ctx context.Context, b IngestBatch) error
+}
+
+var _ BatchWriter = (*PatternStore)(nil)
+
+const (
+	defaultIngestWindow  = 200 * time.Millisecond
+	defaultIngestMaxRows = 500
+	defaultIngestBuffer  = 64
+	ingestWriteTimeout   = 60 * time.Second
+)
+
+// IngestQueue buffers feature and label writes from many producers and
+// applies them in as few transactions as possible: everything enqueued within
+// the window after the first pending write (or up to maxRows rows, whichever
+// comes first) goes out as one IngestBatch. Writes are not visible until flushed, so a
+// caller that reads its own write must Flush first. Backfill should keep
+// calling UpsertFeature/UpsertLabels directly.
+type IngestQueue struct {
+	w       BatchWriter
+	logger  *slog.Logger
+	window  time.Duration
+	maxRows int
+
+	in        chan IngestBatch
+	flush     chan chan error
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewIngestQueue starts the worker; window <= 0 and maxRows <= 0 take the
+// defaults. Call Close on shutdown.
+func NewIngestQueue(w BatchWriter, window time.Duration, maxRows int, logger *slog.Logger) *IngestQueue {
+	if window <= 0 {
+		window = defaultIngestWindow
+	}
+	if maxRows <= 0 {
+		maxRows = defaultIngestMaxRows
+	}
+	q := &IngestQueue{
+		w:       w,
+		logger:  logger,
+		window:  window,
+		maxRows: maxRows,
+		in:      make(chan IngestBatch, defaultIngestBuffer),
+		flush:   make(chan chan error),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Enqueue adds one candle's feature and labels. It blocks only while the
+// buffer is full. It must not be called after Close.
+func (q *IngestQueue) Enqueue(feature *embedding.PatternFeature, labels []LabelWrite) {
+	var b IngestBatch
+	if feature != nil {
+		b.Features = []embedding.PatternFeature{*feature}
+	}
+	b.Labels = labels
+	if b.len() > 0 {
+		q.in <- b
+	}
+}
+
+// Flush writes everything enqueued before the call and returns the first
+// error seen since the previous Flush, including background writes.
+func (q *IngestQueue) Flush(ctx context.Context) error {
+	reply := make(chan error, 1)
+	select {
+	case q.flush <- reply:
+	case <-q.done:
+		return errQueueClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes what is pending and stops the worker. Later calls only wait
+// for the worker and return nil.
+func (q *IngestQueue) Close(ctx context.Context) error {
+	var err error
+	q.closeOnce.Do(func() {
+		err = q.Flush(ctx)
+		close(q.stop)
+	})
+	<-q.done
+	return err
+}
+
+func (q *IngestQueue) run() {
+	defer close(q.done)
+	var (
+		pending  []IngestBatch
+		rows     int
+		timer    *time.Timer
+		timerC   <-chan time.Time
+		firstErr error
+	)
+	write := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timerC = nil, nil
+		}
+		if len(pending) == 0 {
+			return
+		}
+		batch := coalesceBatches(pending)
+		pending, rows = nil, 0
+		ctx, cancel := context.WithTimeout(context.Background(), ingestWriteTimeout)
+		defer cancel()
+		if err := q.w.IngestBatch(ctx, batch); err != nil {
+			if q.logger != nil {
+				q.logger.Error("[IngestQueue] batch write failed", "features", len(batch.Features), "labels", len(batch.Labels), "err", err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	add := func(b IngestBatch) {
+		pending = append(pending, b)
+		rows += b.len()
+		if timer == nil {
+			timer = time.NewTimer(q.window)
+			timerC = timer.C
+		}
+		if rows >= q.maxRows {
+			write()
+		}
+	}
+
+	for {
+		select {
+		case b := <-q.in:
+			add(b)
+		case <-timerC:
+			timer, timerC = nil, nil
+			write()
+		case reply := <-q.flush:
+			// Anything enqueued before Flush is already buffered.
+			for drained := false; !drained; {
+				select {
+				case b := <-q.in:
+					add(b)
+				default:
+					drained = true
+				}
+			}
+			write()
+			reply <- firstErr
+			firstErr = nil
+		case <-q.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"time-series-rag-agent/internal/embedding"
+)
+
+// fakeBatchWriter applies batches to an in-memory table the way the SQL
+// upserts would, and keeps every batch it was handed.
+type fakeBatchWriter struct {
+	mu       sync.Mutex
+	batches  []IngestBatch
+	features map[int64]embedding.PatternFeature
+	labels   map[string]float64 // "time/column"
+	fail     error
+}
+
+func newFakeBatchWriter() *fakeBatchWriter {
+	return &fakeBatchWriter{features: map[int64]embedding.PatternFeature{}, labels: map[string]float64{}}
+}
+
+func (f *fakeBatchWriter) IngestBatch(_ context.Context, b IngestBatch) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, b)
+	if f.fail != nil {
+		return f.fail
+	}
+	for _, ft := range b.Features {
+		f.features[ft.Time.Unix()] = ft
+	}
+	for _, l := range b.Labels {
+		f.labels[labelCell(l.TargetTime, l.Column)] = l.Value
+	}
+	return nil
+}
+
+func labelCell(t int64, col string) string { return time.Unix(t, 0).UTC().Format("15:04") + "/" + col }
+
+func qFeature(t int64) *embedding.PatternFeature {
+	return &embedding.PatternFeature{Time: time.Unix(t, 0), Symbol: "ETHUSDT", Interval: "15m", Embedding: []float64{1, 0}}
+}
+
+func qLabel(t int64, col string, v float64) LabelWrite {
+	return LabelWrite{Symbol: "ETHUSDT", Interval: "15m", LabelUpdate: embedding.LabelUpdate{TargetTime: t, Column: col, Value: v}}
+}
+
+func TestIngestQueue_CoalescesWritesIntoOneBatch(t *testing.T) {
+	w := newFakeBatchWriter()
+	q := NewIngestQueue(w, time.Hour, 0, nil)
+
+	for i := int64(0); i < 5; i++ {
+		bar := i * 900
+		q.Enqueue(qFeature(bar), []LabelWrite{qLabel(bar-900, "next_return", float64(i))})
+	}
+	require.NoError(t, q.Flush(context.Background()))
+
+	require.Len(t, w.batches, 1, "one transaction for the whole burst")
+	assert.Len(t, w.features, 5)
+	assert.Len(t, w.labels, 5)
+	require.NoError(t, q.Close(context.Background()))
+}
+
+func TestIngestQueue_LabelOrderPreserved(t *testing.T) {
+	w := newFakeBatchWriter()
+	q := NewIngestQueue(w, time.Hour, 0, nil)
+
+	// T-1 gets next_return on one bar and is corrected on the next.
+	q.Enqueue(nil, []LabelWrite{qLabel(900, "next_return", 0.01), qLabel(900, "next_slope_3", 0.2)})
+	q.Enqueue(nil, []LabelWrite{qLabel(900, "next_return", 0.02), qLabel(1800, "next_return", 0.03)})
+	require.NoError(t, q.Close(context.Background()))
+
+	require.Len(t, w.batches, 1)
+	assert.Equal(t, []LabelWrite{
+		qLabel(900, "next_slope_3", 0.2),
+		qLabel(900, "next_return", 0.02),
+		qLabel(1800, "next_return", 0.03),
+	}, w.batches[0].Labels, "one write per cell, in the order of the last writes")
+	assert.Equal(t, 0.02, w.labels[labelCell(900, "next_return")], "later update wins")
+}
+
+func TestIngestQueue_WindowAndMaxRowsTriggerWrites(t *testing.T) {
+	w := newFakeBatchWriter()
+	q := NewIngestQueue(w, 10*time.Millisecond, 0, nil)
+	q.Enqueue(qFeature(0), nil)
+	assert.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return len(w.batches) == 1
+	}, time.Second, 5*time.Millisecond, "window elapsed without Flush")
+	require.NoError(t, q.Close(context.Background()))
+
+	w = newFakeBatchWriter()
+	q = NewIngestQueue(w, time.Hour, 2, nil)
+	q.Enqueue(qFeature(0), []LabelWrite{qLabel(-900, "next_return", 1)})
+	assert.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return len(w.batches) == 1
+	}, time.Second, 5*time.Millisecond, "maxRows reached")
+	require.NoError(t, q.Close(context.Background()))
+}
+
+func TestIngestQueue_FlushReportsBackgroundError(t *testing.T) {
+	w := newFakeBatchWriter()
+	w.fail = errors.New("connection reset")
+	q := NewIngestQueue(w, time.Hour, 0, nil)
+
+	q.Enqueue(qFeature(0), nil)
+
+	assert.ErrorContains(t, q.Flush(context.Background()), "connection reset")
+	assert.NoError(t, q.Flush(context.Background()), "error reported once")
+	require.NoError(t, q.Close(context.Background()))
+	assert.ErrorIs(t, q.Flush(context.Background()), errQueueClosed)
+}
+
+func TestIngestQueue_CloseTwice(t *testing.T) {
+	w := newFakeBatchWriter()
+	q := NewIngestQueue(w, time.Hour, 0, nil)
+	q.Enqueue(qFeature(0), nil)
+
+	require.NoError(t, q.Close(context.Background()))
+	assert.NotPanics(t, func() { assert.NoError(t, q.Close(context.Background())) })
+	assert.Len(t, w.features, 1, "first Close flushed the pending write")
+}
+
+func TestCoalesceBatches_LastFeatureWins(t *testing.T) {
+	a, b := qFeature(0), qFeature(0)
+	b.ClosePrice = 2
+	out := coalesceBatches([]IngestBatch{{Features: []embedding.PatternFeature{*a}}, {Features: []embedding.PatternFeature{*b, *qFeature(900)}}})
+
+	require.Len(t, out.Features, 2)
+	assert.Equal(t, 2.0, out.Features[0].ClosePrice)
+}
@@ -0,0 +1,149 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"time-series-rag-agent/internal/embedding"
+)
+
+// vectorIndexName is the ivfflat index EnsureVectorIndex manages.
+const vectorIndexName = "market_pattern_go_embedding_ivfflat_idx"
+
+// vectorIndexStatement builds the idempotent ivfflat CREATE INDEX. lists is
+// an int, so inline formatting is injection-safe.
+func vectorIndexStatement(lists int) (string, error) {
+	if lists <= 0 {
+		return "", fmt.Errorf("ivfflat lists must be > 0, got %d", lists)
+	}
+	return fmt.Sprintf(
+		"CREATE INDEX IF NOT EXISTS %s ON market_pattern_go USING ivfflat (embedding vector_cosine_ops) WITH (lists = %d)",
+		vectorIndexName, lists), nil
+}
+
+// EnsureVectorIndex creates an ivfflat cosine index on embedding so QueryTopN
+// stops scanning the whole table. It is a no-op when the index already exists.
+//
+// lists is the number of clusters the rows are split into: more lists means
+// each search scans fewer rows (faster) but a neighbour in an unprobed list is
+// missed (lower recall). pgvector suggests rows/1000 up to 1M rows and
+// sqrt(rows) beyond; pair it with IVFFLAT_PROBES (≈ sqrt(lists)) to buy recall
+// back at query time. The clusters come from the rows present at build time,
+// so build after a backfill, and REINDEX once the table has grown severalfold.
+// ivfflat needs a fixed-dimension column: a table mixing window sizes must
+// keep embedding untyped and skip the index.
+func (s *PatternStore) EnsureVectorIndex(ctx context.Context, lists int) error {
+	stmt, err := vectorIndexStatement(lists)
+	if err != nil {
+		return err
+	}
+	if s.skipWrite("EnsureVectorIndex") {
+		return nil
+	}
+
+	var exists bool
+	if err := s.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM pg_indexes WHERE tablename = 'market_pattern_go' AND indexname = $1)`,
+		vectorIndexName,
+	).Scan(&exists); err != nil {
+		return fmt.Errorf("EnsureVectorIndex: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	s.logger.Info(fmt.Sprintf("[EnsureVectorIndex] %s", stmt))
+	if _, err := s.db.Exec(ctx, stmt); err != nil {
+		return fmt.Errorf("EnsureVectorIndex: %w", err)
+	}
+	return nil
+}
+
+// addedColumn is a column added to a table after it was first created.
+type addedColumn struct {
+	table, column, definition string
+}
+
+func (c addedColumn) statement() string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", c.table, c.column, c.definition)
+}
+
+// addedColumns are written by every insert or upsert that names them, so
+// each must exist before the first write.
+var addedColumns = []addedColumn{
+	{"market_pattern_go", "candle_window", "JSONB"},  // PERSIST_WINDOW
+	{"market_pattern_go", "rsi", "DOUBLE PRECISION"}, // EMBEDDING_RSI_PERIOD
+	// Rows from before the column were built with the default recipe.
+	{"market_pattern_go", "embedding_version", "TEXT DEFAULT '" + embedding.ReturnLog.EmbeddingVersion() + "'"},
+	{"trade_signal_log", "raw_response", "TEXT"},
+	{"trade_signal_log", "finish_reason", "TEXT"},
+}
+
+// missingColumnStatements returns the ADD COLUMN for every addedColumns entry
+// not in existing, keyed "table.column".
+func missingColumnStatements(existing map[string]bool) []string {
+	var stmts []string
+	for _, c := range addedColumns {
+		if !existing[c.table+"."+c.column] {
+			stmts = append(stmts, c.statement())
+		}
+	}
+	return stmts
+}
+
+// EnsureColumns adds the addedColumns the database lacks. Like
+// EnsureLabelColumns it reads information_schema first, so a start with
+// nothing to add takes no table lock.
+func (s *PatternStore) EnsureColumns(ctx context.Context) error {
+	if s.skipWrite("EnsureColumns") {
+		return nil
+	}
+	var tables []string
+	for _, c := range addedColumns {
+		tables = append(tables, c.table)
+	}
+	rows, err := s.db.Query(ctx,
+		`SELECT table_name, column_name FROM information_schema.columns WHERE table_name = ANY($1)`, tables)
+	if err != nil {
+		return fmt.Errorf("EnsureColumns: %w", err)
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var table, col string
+		if err := rows.Scan(&table, &col); err != nil {
+			rows.Close()
+			return fmt.Errorf("EnsureColumns scan: %w", err)
+		}
+		existing[table+"."+col] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("EnsureColumns: %w", err)
+	}
+
+	for _, stmt := range missingColumnStatements(existing) {
+		if _, err := s.db.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("EnsureColumns %q: %w", stmt, err)
+		}
+		s.logger.Info(fmt.Sprintf("[EnsureColumns] %s", stmt))
+	}
+	return nil
+}
+
+// Migrate brings the schema up to what the code and configuration need: the
+// addedColumns, one column per slope window and, when ivfflatLists > 0, the
+// vector index. Every step is idempotent, so it is meant to run on each start.
+func (s *PatternStore) Migrate(ctx context.Context, slopeWindows []int, ivfflatLists int) error {
+	if err := s.EnsureColumns(ctx); err != nil {
+		return err
+	}
+	if err := s.EnsureLabelColumns(ctx, slopeWindows); err != nil {
+		return err
+	}
+	if ivfflatLists > 0 {
+		if err := s.EnsureVectorIndex(ctx, ivfflatLists); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package postgresql
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVectorIndexStatement(t *testing.T) {
+	stmt, err := vectorIndexStatement(100)
+	assert.NoError(t, err)
+
+	assert.Contains(t, stmt, "CREATE INDEX IF NOT EXISTS "+vectorIndexName)
+	assert.Contains(t, stmt, "USING ivfflat (embedding vector_cosine_ops)")
+	assert.Contains(t, stmt, "WITH (lists = 100)")
+}
+
+// Migrate runs on every start, so each statement it can issue must be a no-op
+// when the object already exists.
+func TestMigrateStatements_AllIfNotExists(t *testing.T) {
+	stmts := missingColumnStatements(map[string]bool{})
+	stmts = append(stmts, labelColumnStatements([]int{3, 5, 10, 20}, map[string]bool{})...)
+	index, err := vectorIndexStatement(100)
+	assert.NoError(t, err)
+	stmts = append(stmts, index)
+
+	assert.Len(t, stmts, len(addedColumns)+4+1)
+	for _, stmt := range stmts {
+		assert.Contains(t, stmt, "IF NOT EXISTS", stmt)
+	}
+}
+
+func TestMissingColumnStatements_PatternColumns(t *testing.T) {
+	stmts := missingColumnStatements(map[string]bool{})
+
+	assert.Contains(t, stmts, "ALTER TABLE market_pattern_go ADD COLUMN IF NOT EXISTS candle_window JSONB")
+	assert.Contains(t, stmts, "ALTER TABLE market_pattern_go ADD COLUMN IF NOT EXISTS rsi DOUBLE PRECISION")
+}
+
+func TestVectorIndexStatement_RejectsNonPositiveLists(t *testing.T) {
+	_, err := vectorIndexStatement(0)
+
+	assert.Error(t, err)
+}
+
+// No pool: a read-only store must not touch s.db while migrating.
+func TestMigrate_ReadOnly_IssuesNoStatements(t *testing.T) {
+	s := &PatternStore{logger: *slog.New(slog.NewTextHandler(io.Discard, nil))}
+	s.SetReadOnly(true)
+
+	assert.NotPanics(t, func() {
+		assert.NoError(t, s.Migrate(context.Background(), []int{3, 5, 10}, 100))
+	})
+}
+
+func TestEnsureColumns_ReadOnly_IssuesNoStatements(t *testing.T) {
+	s := &PatternStore{logger: *slog.New(slog.NewTextHandler(io.Discard, nil))}
+	s.SetReadOnly(true)
+
+	assert.NotPanics(t, func() {
+		assert.NoError(t, s.EnsureColumns(context.Background()))
+	})
+}
+
+func TestMissingColumnStatements_SignalLogColumns(t *testing.T) {
+	stmts := missingColumnStatements(map[string]bool{})
+
+	assert.Contains(t, stmts, "ALTER TABLE trade_signal_log ADD COLUMN IF NOT EXISTS raw_response TEXT")
+	assert.Contains(t, stmts, "ALTER TABLE trade_signal_log ADD COLUMN IF NOT EXISTS finish_reason TEXT")
+}
+
+func TestMissingColumnStatements_SkipsExisting(t *testing.T) {
+	existing := map[string]bool{}
+	for _, c := range addedColumns {
+		existing[c.table+"."+c.column] = true
+	}
+
+	assert.Empty(t, missingColumnStatements(existing))
+}
@@ -17,4 +17,15 @@ type TradeSignalLog struct {
 	WsClose         float64
 	Executed        bool
 	SkipReason      string
+	RawResponse     string // full LLM text before parsing
+	FinishReason    string // LLM stop_reason
+}
+
+// SignalOutcome pairs a logged LONG/SHORT call with the realized next-bar
+// return of the candle it was made on.
+type SignalOutcome struct {
+	Time       time.Time
+	Signal     string
+	Confidence int
+	NextReturn float64
 }
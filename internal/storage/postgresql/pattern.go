@@ -2,13 +2,20 @@ package postgresql
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pgvector/pgvector-go"
 
 	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+	"time-series-rag-agent/internal/storage"
 
 	"log/slog"
 )
@@ -17,55 +24,178 @@ const upsertPatternSQL = `
 INSERT INTO market_pattern_go (
     time, symbol, interval,
     embedding,
-    close_price, next_return, next_slope_3, next_slope_5
+    close_price, next_return, next_slope_3, next_slope_5,
+    embedding_version
 )
-VALUES ($1, $2, $3, $4::vector, $5, $6, $7, $8)
+VALUES ($1, $2, $3, $4::vector, $5, $6, $7, $8, NULLIF($9, ''))
 ON CONFLICT (time, symbol, interval) DO UPDATE SET
-    embedding   = EXCLUDED.embedding,
-    close_price = EXCLUDED.close_price,
+    embedding         = EXCLUDED.embedding,
+    embedding_version = EXCLUDED.embedding_version,
+    close_price       = EXCLUDED.close_price,
 	next_return  = COALESCE(EXCLUDED.next_return,  market_pattern_go.next_return),
 	next_slope_3 = COALESCE(EXCLUDED.next_slope_3, market_pattern_go.next_slope_3),
 	next_slope_5 = COALESCE(EXCLUDED.next_slope_5, market_pattern_go.next_slope_5)
 `
 
+// upsertPatternWindowSQL is upsertPatternSQL plus the raw candle window.
+// Migrate adds candle_window.
+const upsertPatternWindowSQL = `
+INSERT INTO market_pattern_go (
+    time, symbol, interval,
+    embedding,
+    close_price, next_return, next_slope_3, next_slope_5,
+    embedding_version, candle_window
+)
+VALUES ($1, $2, $3, $4::vector, $5, $6, $7, $8, NULLIF($9, ''), $10::jsonb)
+ON CONFLICT (time, symbol, interval) DO UPDATE SET
+    embedding         = EXCLUDED.embedding,
+    embedding_version = EXCLUDED.embedding_version,
+    close_price       = EXCLUDED.close_price,
+    candle_window     = EXCLUDED.candle_window,
+	next_return  = COALESCE(EXCLUDED.next_return,  market_pattern_go.next_return),
+	next_slope_3 = COALESCE(EXCLUDED.next_slope_3, market_pattern_go.next_slope_3),
+	next_slope_5 = COALESCE(EXCLUDED.next_slope_5, market_pattern_go.next_slope_5)
+`
+
+// upsertRSISQL sets rsi on rows the feature upsert just wrote.
+const upsertRSISQL = `
+UPDATE market_pattern_go AS m SET rsi = v.rsi
+FROM (SELECT UNNEST($1::bigint[]) AS time, UNNEST($2::text[]) AS symbol,
+             UNNEST($3::text[]) AS interval, UNNEST($4::float8[]) AS rsi) AS v
+WHERE m.time = v.time AND m.symbol = v.symbol AND m.interval = v.interval
+`
+
 type PatternStore struct {
 	db     *pgxpool.Pool
 	logger slog.Logger
+
+	efSearch int // hnsw.ef_search applied per search; 0 = server default
+	probes   int // ivfflat.probes applied per search; 0 = server default
+
+	persistWindow bool // also write PatternFeature.Window to candle_window
+	readOnly      bool // trader-only process on a replica: every write is skipped
+	embeddingDim  int  // required embedding length on write; 0 = any
+
+	embeddingVersion string // searches only match rows of this recipe; "" = any
 }
 
-func NewPostgresDB(ctx context.Context, connString string, logger slog.Logger) (*PatternStore, error) {
-	pool, err := pgxpool.New(ctx, connString)
+var _ storage.PatternStore = (*PatternStore)(nil)
+
+// NewPostgresDB opens a pool sized by pool (see PoolConfig).
+func NewPostgresDB(ctx context.Context, connString string, pool PoolConfig, logger slog.Logger) (*PatternStore, error) {
+	poolCfg, err := parsePoolConfig(connString, pool)
 	if err != nil {
 		return nil, err
 	}
-	return &PatternStore{db: pool, logger: logger}, nil
+	db, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &PatternStore{db: db, logger: logger}, nil
+}
+
+// SetSearchParams configures the ANN index query-time knobs issued before each
+// QueryTopN. Higher values trade latency for recall; 0 leaves the server default.
+func (s *PatternStore) SetSearchParams(efSearch, probes int) {
+	s.efSearch = efSearch
+	s.probes = probes
+}
+
+// SetPersistWindow toggles writing the raw candle window alongside embeddings.
+func (s *PatternStore) SetPersistWindow(enabled bool) {
+	s.persistWindow = enabled
+}
+
+// SetReadOnly turns every write (feature, bulk, label, signal log) into a
+// logged no-op so the store only serves QueryTopN.
+func (s *PatternStore) SetReadOnly(enabled bool) {
+	s.readOnly = enabled
+}
+
+// SetEmbeddingDim makes every feature write reject an embedding whose length
+// is not dim, naming both lengths, instead of failing in the driver against
+// a fixed-dimension vector column. 0 accepts any length, for an untyped
+// column that mixes window sizes.
+func (s *PatternStore) SetEmbeddingDim(dim int) {
+	s.embeddingDim = dim
+}
+
+// SetEmbeddingVersion restricts QueryTopN to rows written with this
+// FeatureCalculator.Version, so a changed RETURN_TYPE, ZCLIP or WITH_VOLUME
+// never compares vectors built differently. "" matches every row.
+func (s *PatternStore) SetEmbeddingVersion(version string) {
+	s.embeddingVersion = version
+}
+
+// checkDim fails on the first feature whose embedding length is not
+// embeddingDim.
+func (s *PatternStore) checkDim(features ...embedding.PatternFeature) error {
+	if s.embeddingDim <= 0 {
+		return nil
+	}
+	for _, f := range features {
+		if len(f.Embedding) != s.embeddingDim {
+			return fmt.Errorf("embedding dimension mismatch for %s %s at %d: expected %d, got %d",
+				f.Symbol, f.Interval, f.Time.Unix(), s.embeddingDim, len(f.Embedding))
+		}
+	}
+	return nil
+}
+
+// skipWrite reports whether op must be dropped because the store is read-only.
+func (s *PatternStore) skipWrite(op string) bool {
+	if s.readOnly {
+		s.logger.Debug(fmt.Sprintf("[%s] read-only store, skipping write", op))
+	}
+	return s.readOnly
 }
 
 // UpsertFeature inserts or updates embedding + close_price for a given candle time.
 func (s *PatternStore) UpsertFeature(ctx context.Context, f embedding.PatternFeature) error {
+	if s.skipWrite("UpsertFeature") {
+		return nil
+	}
+	if err := s.checkDim(f); err != nil {
+		return fmt.Errorf("UpsertFeature: %w", err)
+	}
 	vec := make([]float32, len(f.Embedding))
 	for i, v := range f.Embedding {
 		vec[i] = float32(v)
 	}
 
-	_, err := s.db.Exec(ctx, upsertPatternSQL,
+	args := []any{
 		f.Time.Unix(),
 		f.Symbol,
 		f.Interval,
 		pgvector.NewVector(vec),
 		f.ClosePrice,
 		nil, nil, nil,
-	)
+		f.Version,
+	}
+	sql := upsertPatternSQL
+	if s.persistWindow {
+		window, err := encodeWindow(f.Window)
+		if err != nil {
+			return fmt.Errorf("UpsertFeature: %w", err)
+		}
+		sql = upsertPatternWindowSQL
+		args = append(args, window)
+	}
+
+	_, err := s.db.Exec(ctx, sql, args...)
 	if err != nil {
 		return fmt.Errorf("UpsertFeature: %w", err)
 	}
+	if err := upsertRSI(ctx, s.db, []embedding.PatternFeature{f}); err != nil {
+		return fmt.Errorf("UpsertFeature: %w", err)
+	}
 	return nil
 }
 
 // UpsertLabels updates label columns for past candles.
 // Each LabelUpdate targets a specific (TargetTime, symbol, interval) row.
 func (s *PatternStore) UpsertLabels(ctx context.Context, symbol, interval string, labels []embedding.LabelUpdate) error {
-	if len(labels) == 0 {
+	if len(labels) == 0 || s.skipWrite("UpsertLabels") {
 		return nil
 	}
 
@@ -86,11 +216,10 @@ func (s *PatternStore) UpsertLabels(ctx context.Context, symbol, interval string
 	return nil
 }
 
-func (s *PatternStore) bulkUpsertLabelColumn(ctx context.Context, symbol, interval, col string, labels []embedding.LabelUpdate) error {
-	const batchSize = 1000
-	const maxRetries = 3
-
-	sql := fmt.Sprintf(`
+// labelColumnUpsertSQL binds $1 times, $2 symbols, $3 intervals and $4 values
+// for one already-validated label column.
+func labelColumnUpsertSQL(col string) string {
+	return fmt.Sprintf(`
 		INSERT INTO market_pattern_go (time, symbol, interval, %s)
 		SELECT
 			UNNEST($1::bigint[]),
@@ -100,6 +229,13 @@ func (s *PatternStore) bulkUpsertLabelColumn(ctx context.Context, symbol, interv
 		ON CONFLICT (time, symbol, interval) DO UPDATE SET
 			%s = EXCLUDED.%s
 	`, col, col, col)
+}
+
+func (s *PatternStore) bulkUpsertLabelColumn(ctx context.Context, symbol, interval, col string, labels []embedding.LabelUpdate) error {
+	const batchSize = 1000
+	const maxRetries = 3
+
+	sql := labelColumnUpsertSQL(col)
 
 	for i := 0; i < len(labels); i += batchSize {
 		end := i + batchSize
@@ -141,24 +277,51 @@ func (s *PatternStore) bulkUpsertLabelColumn(ctx context.Context, symbol, interv
 	return nil
 }
 
+// queryTopNSQL binds $1 query vector, $2 symbol, $3 interval, $4 limit, $5
+// dimension and $6 embedding version ("" = any); symbol and interval are
+// always filtered so one table can hold several markets without cross-symbol
+// matches.
+const queryTopNSQL = `
+	SELECT
+		time, symbol, interval,
+		close_price, next_return, next_slope_3, next_slope_5,
+		embedding,
+		embedding <=> $1 AS distance
+	FROM market_pattern_go
+	WHERE symbol   = $2
+		AND interval = $3
+		AND embedding IS NOT NULL
+		AND vector_dims(embedding) = $5
+		AND ($6 = '' OR embedding_version = $6)
+	ORDER BY embedding <=> $1
+	LIMIT $4
+`
+
 // QueryTopN returns the N most similar rows to the given embedding using cosine distance.
 func (s *PatternStore) QueryTopN(ctx context.Context, symbol, interval string, queryEmbedding []float64, topN int) ([]embedding.PatternLabel, error) {
-	sql := `
-		SELECT
-			time, symbol, interval,
-			close_price, next_return, next_slope_3, next_slope_5,
-			embedding,
-			embedding <=> $1 AS distance
-		FROM market_pattern_go
-		WHERE symbol   = $2
-			AND interval = $3
-			AND embedding IS NOT NULL
-		ORDER BY embedding <=> $1
-		LIMIT $4
-	`
-
 	s.logger.Info(fmt.Sprintf("Querying with param: symbol=%s, interval=%s, topN=%d", symbol, interval, topN))
-	rows, err := s.db.Query(ctx, sql, toVectorLiteral(queryEmbedding), symbol, interval, topN)
+
+	// SET LOCAL only lives for the transaction, so pooled connections stay clean.
+	var q interface {
+		Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	} = s.db
+	if stmts := searchParamStatements(s.efSearch, s.probes); len(stmts) > 0 {
+		tx, err := s.db.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("QueryTopN begin: %w", err)
+		}
+		defer tx.Rollback(ctx)
+		for _, stmt := range stmts {
+			if _, err := tx.Exec(ctx, stmt); err != nil {
+				return nil, fmt.Errorf("QueryTopN %q: %w", stmt, err)
+			}
+		}
+		q = tx
+	}
+
+	// vector_dims keeps windows of different sizes (e.g. 30-dim ADA, 60-dim ETH)
+	// in one table without <=> erroring on a dimension mismatch.
+	rows, err := q.Query(ctx, queryTopNSQL, toVectorLiteral(queryEmbedding), symbol, interval, topN, len(queryEmbedding), s.embeddingVersion)
 
 	if err != nil {
 		return nil, fmt.Errorf("QueryTopN: %w", err)
@@ -197,11 +360,46 @@ func (s *PatternStore) QueryTopN(ctx context.Context, symbol, interval string, q
 		return nil, fmt.Errorf("QueryTopN rows: %w", err)
 	}
 
-	return results, nil
+	return keepMatching(results, symbol, interval, len(queryEmbedding)), nil
+}
+
+// QueryTopNWithin is QueryTopN without the rows farther than maxDistance, so a
+// bar with no close historical analogue yields fewer than topN rows, or none.
+func (s *PatternStore) QueryTopNWithin(ctx context.Context, symbol, interval string, queryEmbedding []float64, topN int, maxDistance float64) ([]embedding.PatternLabel, error) {
+	rows, err := s.QueryTopN(ctx, symbol, interval, queryEmbedding, topN)
+	if err != nil {
+		return nil, err
+	}
+	return storage.WithinDistance(rows, maxDistance), nil
 }
 
 // --- helpers ---
 
+// keepMatching drops rows whose symbol, interval or embedding dimension differs
+// from the query, so a mis-tagged row can never leak into another market's prompt.
+func keepMatching(rows []embedding.PatternLabel, symbol, interval string, dim int) []embedding.PatternLabel {
+	out := rows[:0]
+	for _, r := range rows {
+		if r.Symbol == symbol && r.Interval == interval && len(r.Embedding.Slice()) == dim {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// searchParamStatements builds the SET LOCAL statements for the configured ANN
+// knobs. Values are ints so formatting them inline is injection-safe.
+func searchParamStatements(efSearch, probes int) []string {
+	var stmts []string
+	if efSearch > 0 {
+		stmts = append(stmts, fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", efSearch))
+	}
+	if probes > 0 {
+		stmts = append(stmts, fmt.Sprintf("SET LOCAL ivfflat.probes = %d", probes))
+	}
+	return stmts
+}
+
 // toVectorLiteral converts []float64 to pgvector literal e.g. "[0.1,0.2,0.3]"
 func toVectorLiteral(v []float64) string {
 	if len(v) == 0 {
@@ -218,19 +416,90 @@ func toVectorLiteral(v []float64) string {
 	return s
 }
 
+// slopeColumnRe matches the next_slope_<bars> columns embedding.SlopeColumn emits.
+var slopeColumnRe = regexp.MustCompile(`^next_slope_[1-9][0-9]{0,3}$`)
+
 // validateLabelColumn whitelists allowed column names to prevent SQL injection.
 func validateLabelColumn(col string) (string, error) {
-	allowed := map[string]bool{
-		"next_return":  true,
-		"next_slope_3": true,
-		"next_slope_5": true,
-	}
-	if !allowed[col] {
+	if col != "next_return" && !slopeColumnRe.MatchString(col) {
 		return "", fmt.Errorf("invalid label column: %q", col)
 	}
 	return col, nil
 }
 
+// labelColumnStatements builds one idempotent ALTER TABLE per slope window
+// whose column is not in existing. Windows are ints, so inline formatting is
+// injection-safe.
+func labelColumnStatements(windows []int, existing map[string]bool) []string {
+	var stmts []string
+	for _, k := range windows {
+		col := embedding.SlopeColumn(k)
+		if k <= 0 || existing[col] {
+			continue
+		}
+		existing[col] = true
+		stmts = append(stmts, fmt.Sprintf(
+			"ALTER TABLE market_pattern_go ADD COLUMN IF NOT EXISTS %s DOUBLE PRECISION", col))
+	}
+	return stmts
+}
+
+// EnsureLabelColumns adds the next_slope_<k> column for every configured slope
+// window that the table lacks, so custom lookaheads (e.g. 10, 20) can be
+// upserted. Columns already present are skipped without taking a table lock,
+// so it is cheap to call on every start.
+func (s *PatternStore) EnsureLabelColumns(ctx context.Context, windows []int) error {
+	if s.skipWrite("EnsureLabelColumns") {
+		return nil
+	}
+	rows, err := s.db.Query(ctx,
+		`SELECT column_name FROM information_schema.columns WHERE table_name = 'market_pattern_go'`)
+	if err != nil {
+		return fmt.Errorf("EnsureLabelColumns: %w", err)
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			rows.Close()
+			return fmt.Errorf("EnsureLabelColumns scan: %w", err)
+		}
+		existing[col] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("EnsureLabelColumns: %w", err)
+	}
+
+	for _, stmt := range labelColumnStatements(windows, existing) {
+		if _, err := s.db.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("EnsureLabelColumns %q: %w", stmt, err)
+		}
+		s.logger.Info(fmt.Sprintf("[EnsureLabelColumns] %s", stmt))
+	}
+	return nil
+}
+
+// encodeWindow serialises a candle window to the JSON stored in candle_window.
+func encodeWindow(window []exchange.WsRestCandle) (string, error) {
+	if window == nil {
+		window = []exchange.WsRestCandle{}
+	}
+	b, err := json.Marshal(window)
+	if err != nil {
+		return "", fmt.Errorf("encode window: %w", err)
+	}
+	return string(b), nil
+}
+
+func decodeWindow(raw string) ([]exchange.WsRestCandle, error) {
+	var window []exchange.WsRestCandle
+	if err := json.Unmarshal([]byte(raw), &window); err != nil {
+		return nil, fmt.Errorf("decode window: %w", err)
+	}
+	return window, nil
+}
+
 func derefOr(v *float64, fallback float64) float64 {
 	if v == nil {
 		return fallback
@@ -239,9 +508,12 @@ func derefOr(v *float64, fallback float64) float64 {
 }
 
 func (s *PatternStore) BulkUpsertFeature(ctx context.Context, features []embedding.PatternFeature) error {
-	if len(features) == 0 {
+	if len(features) == 0 || s.skipWrite("BulkUpsertFeature") {
 		return nil
 	}
+	if err := s.checkDim(features...); err != nil {
+		return fmt.Errorf("BulkUpsertFeature: %w", err)
+	}
 
 	const batchSize = 1000
 
@@ -253,19 +525,25 @@ func (s *PatternStore) BulkUpsertFeature(ctx context.Context, features []embeddi
 		}
 		batch := features[i:end]
 
-		if err := s.upsertFeatureBatch(ctx, batch); err != nil {
+		if err := s.upsertFeatureBatch(ctx, s.db, batch); err != nil {
 			return fmt.Errorf("BulkUpsertFeature batch %d-%d: %w", i, end, err)
 		}
 	}
 	return nil
 }
 
-func (s *PatternStore) upsertFeatureBatch(ctx context.Context, features []embedding.PatternFeature) error {
+// execer runs one write statement; both the pool and a transaction qualify.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+func (s *PatternStore) upsertFeatureBatch(ctx context.Context, db execer, features []embedding.PatternFeature) error {
 	times := make([]int64, len(features))
 	symbols := make([]string, len(features))
 	intervals := make([]string, len(features))
 	embeddings := make([]string, len(features))
 	closePrices := make([]float64, len(features))
+	versions := make([]string, len(features))
 
 	for i, f := range features {
 		times[i] = f.Time.Unix()
@@ -273,23 +551,185 @@ func (s *PatternStore) upsertFeatureBatch(ctx context.Context, features []embedd
 		intervals[i] = f.Interval
 		embeddings[i] = toVectorLiteral(f.Embedding)
 		closePrices[i] = f.ClosePrice
+		versions[i] = f.Version
 	}
 
-	_, err := s.db.Exec(ctx, `
-        INSERT INTO market_pattern_go (time, symbol, interval, embedding, close_price)
+	if s.persistWindow {
+		windows := make([]string, len(features))
+		for i, f := range features {
+			w, err := encodeWindow(f.Window)
+			if err != nil {
+				return err
+			}
+			windows[i] = w
+		}
+		_, err := db.Exec(ctx, `
+        INSERT INTO market_pattern_go (time, symbol, interval, embedding, close_price, embedding_version, candle_window)
         SELECT
             UNNEST($1::bigint[]),
             UNNEST($2::text[]),
             UNNEST($3::text[]),
             UNNEST($4::text[])::vector,
-            UNNEST($5::float8[])
+            UNNEST($5::float8[]),
+            NULLIF(UNNEST($6::text[]), ''),
+            UNNEST($7::text[])::jsonb
         ON CONFLICT (time, symbol, interval) DO UPDATE SET
-            embedding   = EXCLUDED.embedding,
-            close_price = EXCLUDED.close_price
-    `, times, symbols, intervals, embeddings, closePrices)
+            embedding         = EXCLUDED.embedding,
+            close_price       = EXCLUDED.close_price,
+            embedding_version = EXCLUDED.embedding_version,
+            candle_window     = EXCLUDED.candle_window
+    `, times, symbols, intervals, embeddings, closePrices, versions, windows)
+		if err != nil {
+			return err
+		}
+		return upsertRSI(ctx, db, features)
+	}
+
+	_, err := db.Exec(ctx, `
+        INSERT INTO market_pattern_go (time, symbol, interval, embedding, close_price, embedding_version)
+        SELECT
+            UNNEST($1::bigint[]),
+            UNNEST($2::text[]),
+            UNNEST($3::text[]),
+            UNNEST($4::text[])::vector,
+            UNNEST($5::float8[]),
+            NULLIF(UNNEST($6::text[]), '')
+        ON CONFLICT (time, symbol, interval) DO UPDATE SET
+            embedding         = EXCLUDED.embedding,
+            close_price       = EXCLUDED.close_price,
+            embedding_version = EXCLUDED.embedding_version
+    `, times, symbols, intervals, embeddings, closePrices, versions)
 	if err != nil {
 		return err
 	}
+	return upsertRSI(ctx, db, features)
+}
+
+// upsertRSI stores PatternFeature.RSI for the features that carry one, in a
+// separate statement so stores not yet migrated to the rsi column keep
+// working while RSI is off.
+func upsertRSI(ctx context.Context, db execer, features []embedding.PatternFeature) error {
+	var times []int64
+	var symbols, intervals []string
+	var values []float64
+	for _, f := range features {
+		if f.RSI == nil {
+			continue
+		}
+		times = append(times, f.Time.Unix())
+		symbols = append(symbols, f.Symbol)
+		intervals = append(intervals, f.Interval)
+		values = append(values, *f.RSI)
+	}
+	if len(times) == 0 {
+		return nil
+	}
+	if _, err := db.Exec(ctx, upsertRSISQL, times, symbols, intervals, values); err != nil {
+		return fmt.Errorf("rsi: %w", err)
+	}
+	return nil
+}
+
+// GetWindow returns the raw candle window stored for (symbol, interval, t).
+// Returns nil when the row exists but no window was persisted.
+func (s *PatternStore) GetWindow(ctx context.Context, symbol, interval string, t time.Time) ([]exchange.WsRestCandle, error) {
+	var raw *string
+	err := s.db.QueryRow(ctx, `
+		SELECT candle_window::text
+		FROM market_pattern_go
+		WHERE time = $1 AND symbol = $2 AND interval = $3
+	`, t.Unix(), symbol, interval).Scan(&raw)
+	if err != nil {
+		return nil, fmt.Errorf("GetWindow: %w", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	return decodeWindow(*raw)
+}
+
+// PrunePatterns deletes symbol/interval rows older than olderThan and returns
+// how many were removed, keeping the table (and vector search) bounded.
+func (s *PatternStore) PrunePatterns(ctx context.Context, symbol, interval string, olderThan time.Time) (int64, error) {
+	if s.skipWrite("PrunePatterns") {
+		return 0, nil
+	}
+	tag, err := s.db.Exec(ctx,
+		`DELETE FROM market_pattern_go WHERE symbol = $1 AND interval = $2 AND time < $3`,
+		symbol, interval, olderThan.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("PrunePatterns: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// CountPatterns returns how many rows exist for symbol/interval and how many
+// of those carry a next_return label.
+func (s *PatternStore) CountPatterns(ctx context.Context, symbol, interval string) (total, labeled int64, err error) {
+	err = s.db.QueryRow(ctx,
+		`SELECT count(*), count(next_return) FROM market_pattern_go WHERE symbol = $1 AND interval = $2`,
+		symbol, interval,
+	).Scan(&total, &labeled)
+	if err != nil {
+		return 0, 0, fmt.Errorf("CountPatterns: %w", err)
+	}
+	return total, labeled, nil
+}
+
+// unlabeledTimesSQL selects row times in [$3, $4] where any of columns is
+// NULL. Columns are whitelisted by validateLabelColumn before formatting.
+func unlabeledTimesSQL(columns []string) (string, error) {
+	if len(columns) == 0 {
+		return "", fmt.Errorf("unlabeledTimesSQL: no columns")
+	}
+	nulls := make([]string, len(columns))
+	for i, c := range columns {
+		col, err := validateLabelColumn(c)
+		if err != nil {
+			return "", err
+		}
+		nulls[i] = col + " IS NULL"
+	}
+	return fmt.Sprintf(`SELECT time FROM market_pattern_go
+		WHERE symbol = $1 AND interval = $2 AND time BETWEEN $3 AND $4
+			AND (%s)
+		ORDER BY time`, strings.Join(nulls, " OR ")), nil
+}
+
+// UnlabeledTimes returns the unix times of symbol/interval rows between from
+// and to (inclusive) that still have a NULL in any of columns.
+func (s *PatternStore) UnlabeledTimes(ctx context.Context, symbol, interval string, columns []string, from, to time.Time) ([]int64, error) {
+	sql, err := unlabeledTimesSQL(columns)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(ctx, sql, symbol, interval, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("UnlabeledTimes: %w", err)
+	}
+	defer rows.Close()
+
+	var times []int64
+	for rows.Next() {
+		var t int64
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("UnlabeledTimes scan: %w", err)
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
+
+// HealthCheck pings the pool and runs a trivial pgvector distance query so a
+// missing extension fails here instead of on the first live search.
+func (s *PatternStore) HealthCheck(ctx context.Context) error {
+	if err := s.db.Ping(ctx); err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+	var d float64
+	if err := s.db.QueryRow(ctx, `SELECT '[1,2,3]'::vector <=> '[1,2,3]'::vector`).Scan(&d); err != nil {
+		return fmt.Errorf("pgvector query: %w", err)
+	}
 	return nil
 }
 
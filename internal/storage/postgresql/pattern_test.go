@@ -0,0 +1,235 @@
+package postgresql
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"time-series-rag-agent/internal/embedding"
+	"time-series-rag-agent/internal/exchange"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchParamStatements_Unset(t *testing.T) {
+	assert.Empty(t, searchParamStatements(0, 0))
+}
+
+func TestSearchParamStatements_EfSearch(t *testing.T) {
+	stmts := searchParamStatements(80, 0)
+
+	assert.Equal(t, []string{"SET LOCAL hnsw.ef_search = 80"}, stmts)
+}
+
+func TestSearchParamStatements_Both(t *testing.T) {
+	stmts := searchParamStatements(120, 10)
+
+	assert.Equal(t, []string{
+		"SET LOCAL hnsw.ef_search = 120",
+		"SET LOCAL ivfflat.probes = 10",
+	}, stmts)
+}
+
+func TestWindow_RoundTrip(t *testing.T) {
+	window := []exchange.WsRestCandle{
+		{Time: 1000, Open: 100.0, High: 105.0, Low: 99.0, Close: 103.0, Volume: 500.0},
+		{Time: 1900, Open: 103.0, High: 108.0, Low: 102.0, Close: 107.0, Volume: 600.0},
+	}
+
+	raw, err := encodeWindow(window)
+	assert.NoError(t, err)
+
+	got, err := decodeWindow(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, window, got)
+}
+
+func TestWindow_NilEncodesAsEmptyArray(t *testing.T) {
+	raw, err := encodeWindow(nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", raw)
+}
+
+func TestKeepMatching_DropsOtherSymbolAndDimension(t *testing.T) {
+	label := func(symbol string, dim int) embedding.PatternLabel {
+		return embedding.PatternLabel{Symbol: symbol, Interval: "15m", Embedding: pgvector.NewVector(make([]float32, dim))}
+	}
+	rows := []embedding.PatternLabel{
+		label("ADAUSDT", 30),
+		label("ETHUSDT", 60),
+		label("ADAUSDT", 60), // stale row from an older ADA window size
+		label("ADAUSDT", 30),
+	}
+
+	got := keepMatching(rows, "ADAUSDT", "15m", 30)
+
+	assert.Len(t, got, 2)
+	for _, r := range got {
+		assert.Equal(t, "ADAUSDT", r.Symbol)
+		assert.Len(t, r.Embedding.Slice(), 30)
+	}
+}
+
+func TestQueryTopNSQL_FiltersSymbolAndInterval(t *testing.T) {
+	assert.Contains(t, queryTopNSQL, "symbol   = $2")
+	assert.Contains(t, queryTopNSQL, "interval = $3")
+	assert.Contains(t, queryTopNSQL, "LIMIT $4")
+}
+
+func TestKeepMatching_TwoSymbolsSeeded_OnlyRequestedReturned(t *testing.T) {
+	vec := pgvector.NewVector(make([]float32, 3))
+	rows := []embedding.PatternLabel{
+		{Symbol: "ETHUSDT", Interval: "15m", Embedding: vec},
+		{Symbol: "ADAUSDT", Interval: "15m", Embedding: vec},
+		{Symbol: "ETHUSDT", Interval: "15m", Embedding: vec},
+	}
+
+	got := keepMatching(rows, "ETHUSDT", "15m", 3)
+
+	assert.Len(t, got, 2)
+	for _, r := range got {
+		assert.Equal(t, "ETHUSDT", r.Symbol)
+	}
+}
+
+func TestKeepMatching_MixedIntervals_OnlyRequestedReturned(t *testing.T) {
+	vec := pgvector.NewVector(make([]float32, 60))
+	rows := []embedding.PatternLabel{
+		{Symbol: "ETHUSDT", Interval: "1m", Embedding: vec},
+		{Symbol: "ETHUSDT", Interval: "15m", Embedding: vec},
+		{Symbol: "ETHUSDT", Interval: "1m", Embedding: vec},
+	}
+
+	got := keepMatching(rows, "ETHUSDT", "15m", 60)
+
+	assert.Len(t, got, 1)
+	assert.Equal(t, "15m", got[0].Interval)
+}
+
+// A read-only store has no pool here, so any write that reached s.db would panic.
+func TestReadOnly_IssuesNoWrites(t *testing.T) {
+	s := &PatternStore{logger: *slog.New(slog.NewTextHandler(io.Discard, nil))}
+	s.SetReadOnly(true)
+	ctx := context.Background()
+	f := embedding.PatternFeature{Time: time.Unix(1700000000, 0), Symbol: "ETHUSDT", Interval: "15m", Embedding: []float64{1, 2}}
+
+	assert.NotPanics(t, func() {
+		assert.NoError(t, s.UpsertFeature(ctx, f))
+		assert.NoError(t, s.BulkUpsertFeature(ctx, []embedding.PatternFeature{f}))
+		assert.NoError(t, s.UpsertLabels(ctx, "ETHUSDT", "15m", []embedding.LabelUpdate{{TargetTime: 1700000000, Column: "next_return", Value: 0.01}}))
+		assert.NoError(t, s.InsertTradeSignal(ctx, TradeSignalLog{Time: f.Time, Symbol: "ETHUSDT", Signal: "LONG"}))
+	})
+}
+
+// Like the read-only test, there is no pool: a rejected write must fail before s.db.
+func TestEmbeddingDim_RejectsWrongLength(t *testing.T) {
+	s := &PatternStore{logger: *slog.New(slog.NewTextHandler(io.Discard, nil))}
+	s.SetEmbeddingDim(60)
+	ctx := context.Background()
+	f := embedding.PatternFeature{Time: time.Unix(1700000000, 0), Symbol: "ETHUSDT", Interval: "15m", Embedding: make([]float64, 30)}
+
+	assert.NotPanics(t, func() {
+		err := s.UpsertFeature(ctx, f)
+		assert.ErrorContains(t, err, "embedding dimension mismatch for ETHUSDT 15m at 1700000000: expected 60, got 30")
+		assert.Error(t, s.BulkUpsertFeature(ctx, []embedding.PatternFeature{f}))
+		assert.Error(t, s.IngestBatch(ctx, IngestBatch{Features: []embedding.PatternFeature{f}}))
+	})
+}
+
+func TestEmbeddingDim_ZeroAcceptsAnyLength(t *testing.T) {
+	s := &PatternStore{}
+
+	assert.NoError(t, s.checkDim(embedding.PatternFeature{Embedding: make([]float64, 30)}, embedding.PatternFeature{Embedding: make([]float64, 60)}))
+	s.SetEmbeddingDim(60)
+	assert.NoError(t, s.checkDim(embedding.PatternFeature{Embedding: make([]float64, 60)}))
+}
+
+func TestValidateLabelColumn_AllowsSlopeWindows(t *testing.T) {
+	for _, col := range []string{"next_return", "next_slope_3", "next_slope_20"} {
+		_, err := validateLabelColumn(col)
+		assert.NoError(t, err, col)
+	}
+	for _, col := range []string{"next_slope_0", "next_slope_x", "next_slope_3; DROP TABLE x", "close_price"} {
+		_, err := validateLabelColumn(col)
+		assert.Error(t, err, col)
+	}
+}
+
+func TestLabelColumnStatements_OnlyMissingColumns(t *testing.T) {
+	existing := map[string]bool{"next_slope_3": true, "next_slope_5": true}
+
+	stmts := labelColumnStatements([]int{3, 5, 10, 20, 10}, existing)
+
+	assert.Equal(t, []string{
+		"ALTER TABLE market_pattern_go ADD COLUMN IF NOT EXISTS next_slope_10 DOUBLE PRECISION",
+		"ALTER TABLE market_pattern_go ADD COLUMN IF NOT EXISTS next_slope_20 DOUBLE PRECISION",
+	}, stmts)
+	assert.Empty(t, labelColumnStatements([]int{10, 20}, existing), "second run is a no-op")
+}
+
+func TestUnlabeledTimesSQL_OrsEachColumn(t *testing.T) {
+	sql, err := unlabeledTimesSQL([]string{"next_return", "next_slope_3", "next_slope_8"})
+	assert.NoError(t, err)
+	assert.Contains(t, sql, "(next_return IS NULL OR next_slope_3 IS NULL OR next_slope_8 IS NULL)")
+	assert.Contains(t, sql, "symbol = $1 AND interval = $2 AND time BETWEEN $3 AND $4")
+
+	_, err = unlabeledTimesSQL([]string{"next_return; DROP TABLE x"})
+	assert.Error(t, err)
+	_, err = unlabeledTimesSQL(nil)
+	assert.Error(t, err)
+}
+
+type recordingExecer struct {
+	sqls []string
+	args [][]any
+}
+
+func (r *recordingExecer) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	r.sqls = append(r.sqls, sql)
+	r.args = append(r.args, args)
+	return pgconn.CommandTag{}, nil
+}
+
+func TestUpsertRSI_OnlyFeaturesWithRSI(t *testing.T) {
+	rsi := 61.5
+	db := &recordingExecer{}
+
+	err := upsertRSI(context.Background(), db, []embedding.PatternFeature{
+		{Time: time.Unix(900, 0), Symbol: "ETHUSDT", Interval: "15m"},
+		{Time: time.Unix(1800, 0), Symbol: "ETHUSDT", Interval: "15m", RSI: &rsi},
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, db.sqls, 1) {
+		assert.Equal(t, upsertRSISQL, db.sqls[0])
+		assert.Equal(t, []any{[]int64{1800}, []string{"ETHUSDT"}, []string{"15m"}, []float64{61.5}}, db.args[0])
+	}
+}
+
+func TestUpsertRSI_NoRSI_NoStatement(t *testing.T) {
+	db := &recordingExecer{}
+
+	assert.NoError(t, upsertRSI(context.Background(), db, []embedding.PatternFeature{{Symbol: "ETHUSDT"}}))
+	assert.Empty(t, db.sqls, "stores without the rsi column keep working while RSI is off")
+}
+
+func TestQueryTopNSQL_FiltersEmbeddingVersion(t *testing.T) {
+	assert.Contains(t, queryTopNSQL, "embedding_version = $6")
+}
+
+func TestUpsertPatternSQL_WritesEmbeddingVersion(t *testing.T) {
+	for _, sql := range []string{upsertPatternSQL, upsertPatternWindowSQL} {
+		assert.Contains(t, sql, "embedding_version = EXCLUDED.embedding_version")
+		assert.Contains(t, sql, "NULLIF($9, '')")
+	}
+}
+
+func TestMissingColumnStatements_EmbeddingVersionDefaultsToLegacyRecipe(t *testing.T) {
+	assert.Contains(t, missingColumnStatements(map[string]bool{}),
+		"ALTER TABLE market_pattern_go ADD COLUMN IF NOT EXISTS embedding_version TEXT DEFAULT 'log-v1'")
+}
@@ -0,0 +1,78 @@
+package postgresql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"time-series-rag-agent/config"
+)
+
+// PoolConfig sizes the pgx pool. Zero fields take DefaultPoolConfig's value.
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	HealthCheckPeriod time.Duration
+	ConnectTimeout    time.Duration
+}
+
+// DefaultPoolConfig leaves room for the live loop's concurrent ingest,
+// search and signal log without holding many idle connections.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxConns:          10,
+		MinConns:          1,
+		MaxConnLifetime:   30 * time.Minute,
+		HealthCheckPeriod: time.Minute,
+		ConnectTimeout:    10 * time.Second,
+	}
+}
+
+// PoolConfigFrom reads the DB_POOL_* / DB_CONNECT_TIMEOUT_SEC settings.
+func PoolConfigFrom(c config.DatabaseConfig) PoolConfig {
+	return PoolConfig{
+		MaxConns:          int32(c.PoolMaxConns),
+		MinConns:          int32(c.PoolMinConns),
+		MaxConnLifetime:   time.Duration(c.PoolMaxConnLifetimeMin) * time.Minute,
+		HealthCheckPeriod: time.Duration(c.PoolHealthCheckSec) * time.Second,
+		ConnectTimeout:    time.Duration(c.ConnectTimeoutSec) * time.Second,
+	}
+}
+
+func (p PoolConfig) withDefaults() PoolConfig {
+	d := DefaultPoolConfig()
+	if p.MaxConns <= 0 {
+		p.MaxConns = d.MaxConns
+	}
+	if p.MinConns <= 0 {
+		p.MinConns = d.MinConns
+	}
+	p.MinConns = min(p.MinConns, p.MaxConns)
+	if p.MaxConnLifetime <= 0 {
+		p.MaxConnLifetime = d.MaxConnLifetime
+	}
+	if p.HealthCheckPeriod <= 0 {
+		p.HealthCheckPeriod = d.HealthCheckPeriod
+	}
+	if p.ConnectTimeout <= 0 {
+		p.ConnectTimeout = d.ConnectTimeout
+	}
+	return p
+}
+
+// parsePoolConfig parses connString and applies p over it.
+func parsePoolConfig(connString string, p PoolConfig) (*pgxpool.Config, error) {
+	cfg, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("parse pool config: %w", err)
+	}
+	p = p.withDefaults()
+	cfg.MaxConns = p.MaxConns
+	cfg.MinConns = p.MinConns
+	cfg.MaxConnLifetime = p.MaxConnLifetime
+	cfg.HealthCheckPeriod = p.HealthCheckPeriod
+	cfg.ConnConfig.ConnectTimeout = p.ConnectTimeout
+	return cfg, nil
+}
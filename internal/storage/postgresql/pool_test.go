@@ -0,0 +1,51 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConnString = "postgres://u:p@localhost:5432/db"
+
+func TestParsePoolConfig_AppliesSettings(t *testing.T) {
+	cfg, err := parsePoolConfig(testConnString, PoolConfig{
+		MaxConns:          25,
+		MinConns:          4,
+		MaxConnLifetime:   5 * time.Minute,
+		HealthCheckPeriod: 15 * time.Second,
+		ConnectTimeout:    3 * time.Second,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(25), cfg.MaxConns)
+	assert.Equal(t, int32(4), cfg.MinConns)
+	assert.Equal(t, 5*time.Minute, cfg.MaxConnLifetime)
+	assert.Equal(t, 15*time.Second, cfg.HealthCheckPeriod)
+	assert.Equal(t, 3*time.Second, cfg.ConnConfig.ConnectTimeout)
+}
+
+func TestParsePoolConfig_ZeroTakesDefaults(t *testing.T) {
+	cfg, err := parsePoolConfig(testConnString, PoolConfig{})
+
+	require.NoError(t, err)
+	d := DefaultPoolConfig()
+	assert.Equal(t, d.MaxConns, cfg.MaxConns)
+	assert.Equal(t, d.MinConns, cfg.MinConns)
+	assert.Equal(t, d.ConnectTimeout, cfg.ConnConfig.ConnectTimeout)
+}
+
+func TestParsePoolConfig_MinConnsCappedAtMax(t *testing.T) {
+	cfg, err := parsePoolConfig(testConnString, PoolConfig{MaxConns: 2, MinConns: 8})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), cfg.MinConns)
+}
+
+func TestParsePoolConfig_BadConnString(t *testing.T) {
+	_, err := parsePoolConfig("postgres://u:p@localhost:notaport/db", PoolConfig{})
+
+	assert.Error(t, err)
+}
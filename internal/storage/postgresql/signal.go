@@ -3,34 +3,87 @@ package postgresql
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
+// raw_response and finish_reason are added by Migrate.
 const insertTradeSignalSQL = `
 INSERT INTO trade_signal_log (
     time, symbol, interval,
     signal, confidence,
     regime_read, pattern_read, price_action_read,
     synthesis, risk_note, invalidation,
-    ws_close, executed, skip_reason
+    ws_close, executed, skip_reason,
+    raw_response, finish_reason
 ) VALUES (
     $1, $2, $3,
     $4, $5,
     $6, $7, $8,
     $9, $10, $11,
-    $12, $13, $14
+    $12, $13, $14,
+    $15, $16
 )
 `
 
 func (s *PatternStore) InsertTradeSignal(ctx context.Context, l TradeSignalLog) error {
-	_, err := s.db.Exec(ctx, insertTradeSignalSQL,
+	if s.skipWrite("InsertTradeSignal") {
+		return nil
+	}
+	_, err := s.db.Exec(ctx, insertTradeSignalSQL, tradeSignalArgs(l)...)
+	if err != nil {
+		return fmt.Errorf("InsertTradeSignal: %w", err)
+	}
+	return nil
+}
+
+// tradeSignalArgs orders l's fields to match insertTradeSignalSQL.
+func tradeSignalArgs(l TradeSignalLog) []any {
+	return []any{
 		l.Time.Unix(), l.Symbol, l.Interval,
 		l.Signal, l.Confidence,
 		l.RegimeRead, l.PatternRead, l.PriceActionRead,
 		l.Synthesis, l.RiskNote, l.Invalidation,
 		l.WsClose, l.Executed, l.SkipReason,
-	)
+		l.RawResponse, l.FinishReason,
+	}
+}
+
+// signalOutcomesSQL joins each directional signal to its candle's labelled
+// next_return; $1 symbol, $2 interval, $3 unix lower bound.
+const signalOutcomesSQL = `
+SELECT s.time, s.signal, s.confidence, p.next_return
+FROM trade_signal_log s
+JOIN market_pattern_go p
+	ON p.time = s.time AND p.symbol = s.symbol AND p.interval = s.interval
+WHERE s.symbol = $1
+	AND s.interval = $2
+	AND s.time >= $3
+	AND s.signal IN ('LONG', 'SHORT')
+	AND p.next_return IS NOT NULL
+ORDER BY s.time
+`
+
+// SignalOutcomes returns every LONG/SHORT signal since the given time whose
+// candle already has a next_return label, executed or not, so confidence can
+// be calibrated against what the market actually did.
+func (s *PatternStore) SignalOutcomes(ctx context.Context, symbol, interval string, since time.Time) ([]SignalOutcome, error) {
+	rows, err := s.db.Query(ctx, signalOutcomesSQL, symbol, interval, since.Unix())
 	if err != nil {
-		return fmt.Errorf("InsertTradeSignal: %w", err)
+		return nil, fmt.Errorf("SignalOutcomes: %w", err)
 	}
-	return nil
+	defer rows.Close()
+
+	var out []SignalOutcome
+	for rows.Next() {
+		var (
+			unixTime int64
+			o        SignalOutcome
+		)
+		if err := rows.Scan(&unixTime, &o.Signal, &o.Confidence, &o.NextReturn); err != nil {
+			return nil, fmt.Errorf("SignalOutcomes scan: %w", err)
+		}
+		o.Time = time.Unix(unixTime, 0)
+		out = append(out, o)
+	}
+	return out, rows.Err()
 }
@@ -0,0 +1,25 @@
+package postgresql
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTradeSignalArgs_IncludesRawResponse(t *testing.T) {
+	l := TradeSignalLog{
+		Time:         time.Unix(1700000000, 0),
+		Symbol:       "ETHUSDT",
+		Signal:       "LONG",
+		RawResponse:  `{"signal":"LONG","confidence":72}`,
+		FinishReason: "end_turn",
+	}
+
+	args := tradeSignalArgs(l)
+
+	assert.Len(t, args, strings.Count(insertTradeSignalSQL, "$"))
+	assert.Equal(t, l.RawResponse, args[14])
+	assert.Equal(t, "end_turn", args[15])
+}
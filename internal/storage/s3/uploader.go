@@ -0,0 +1,171 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	defaultMaxAttempts = 4
+	defaultBaseDelay   = 500 * time.Millisecond
+	defaultPresignTTL  = time.Hour
+	maxPresignTTL      = 7 * 24 * time.Hour // SigV4 limit
+)
+
+// ErrNoBucket is returned instead of sending a request S3 would reject with
+// a less obvious error.
+var ErrNoBucket = errors.New("S3 bucket not configured (set CHART_S3_BUCKET)")
+
+// PutObjectAPI is the part of *s3.Client the uploader uses, so tests can
+// stand in a fake bucket.
+type PutObjectAPI interface {
+	PutObject(ctx context.Context, in *awss3.PutObjectInput, optFns ...func(*awss3.Options)) (*awss3.PutObjectOutput, error)
+}
+
+// PresignGetObjectAPI is the part of *s3.PresignClient the uploader uses.
+type PresignGetObjectAPI interface {
+	PresignGetObject(ctx context.Context, in *awss3.GetObjectInput, optFns ...func(*awss3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+// ChartUploader stores decision charts in S3.
+type ChartUploader struct {
+	Client      PutObjectAPI
+	Presigner   PresignGetObjectAPI // nil = GetPresignedURL fails
+	Bucket      string
+	Prefix      string        // key prefix, e.g. "charts"
+	MaxAttempts int           // PutObject tries on transient errors; <= 1 = no retry
+	BaseDelay   time.Duration // first backoff; doubles per attempt
+	Logger      *slog.Logger
+}
+
+// NewChartUploader wraps client with the default retry policy.
+func NewChartUploader(client PutObjectAPI, bucket, prefix string, logger *slog.Logger) *ChartUploader {
+	return &ChartUploader{
+		Client:      client,
+		Bucket:      bucket,
+		Prefix:      prefix,
+		MaxAttempts: defaultMaxAttempts,
+		BaseDelay:   defaultBaseDelay,
+		Logger:      logger,
+	}
+}
+
+// NewS3ChartUploader builds the uploader on a real S3 client from the default
+// AWS credential chain.
+func NewS3ChartUploader(ctx context.Context, bucket, prefix string, logger *slog.Logger) (*ChartUploader, error) {
+	if bucket == "" {
+		return nil, ErrNoBucket
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load SDK config: %w", err)
+	}
+	client := awss3.NewFromConfig(awsCfg)
+	u := NewChartUploader(client, bucket, prefix, logger)
+	u.Presigner = awss3.NewPresignClient(client)
+	return u, nil
+}
+
+// ChartKey is the object key for one chart of one candle. It depends only on
+// its inputs, so a retried or repeated upload overwrites the same object
+// instead of leaving duplicates.
+func (u *ChartUploader) ChartKey(symbol, interval string, candleTime time.Time, name string) string {
+	return path.Join(u.Prefix, symbol, interval, fmt.Sprintf("%d-%s", candleTime.Unix(), name))
+}
+
+// UploadImageToS3 puts a PNG under key, retrying transient failures with
+// exponential backoff. The SDK already retries a single request; this covers
+// outages that outlast it, such as a dropped connection. Returns the s3:// URI.
+func (u *ChartUploader) UploadImageToS3(ctx context.Context, key string, png []byte) (string, error) {
+	if u.Bucket == "" {
+		return "", ErrNoBucket
+	}
+	attempts := max(u.MaxAttempts, 1)
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := u.BaseDelay << (attempt - 1)
+			if u.Logger != nil {
+				u.Logger.Warn("[S3] upload failed, retrying", "key", key, "attempt", attempt, "delay", delay, "err", lastErr)
+			}
+			select {
+			case <-ctx.Done():
+				return "", fmt.Errorf("upload %s: %w (last error: %v)", key, ctx.Err(), lastErr)
+			case <-time.After(delay):
+			}
+		}
+
+		_, err := u.Client.PutObject(ctx, &awss3.PutObjectInput{
+			Bucket:      aws.String(u.Bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(png),
+			ContentType: aws.String("image/png"),
+		})
+		if err == nil {
+			return "s3://" + u.Bucket + "/" + key, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil || !isRetryable(err) {
+			return "", fmt.Errorf("upload %s: %w", key, err)
+		}
+	}
+	return "", fmt.Errorf("upload %s: giving up after %d attempts: %w", key, attempts, lastErr)
+}
+
+// GetPresignedURL returns a GET link to key that works without AWS
+// credentials for ttl (<= 0 = one hour, capped at SigV4's seven days).
+func (u *ChartUploader) GetPresignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if u.Bucket == "" {
+		return "", ErrNoBucket
+	}
+	if u.Presigner == nil {
+		return "", errors.New("presign: no presign client")
+	}
+	if ttl <= 0 {
+		ttl = defaultPresignTTL
+	}
+	ttl = min(ttl, maxPresignTTL)
+	req, err := u.Presigner.PresignGetObject(ctx, &awss3.GetObjectInput{
+		Bucket: aws.String(u.Bucket),
+		Key:    aws.String(key),
+	}, awss3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("presign %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// UploadImageWithURL is UploadImageToS3 followed by GetPresignedURL. A presign
+// failure still returns the URI of the stored object.
+func (u *ChartUploader) UploadImageWithURL(ctx context.Context, key string, png []byte, ttl time.Duration) (string, string, error) {
+	uri, err := u.UploadImageToS3(ctx, key, png)
+	if err != nil {
+		return "", "", err
+	}
+	url, err := u.GetPresignedURL(ctx, key, ttl)
+	if err != nil {
+		return uri, "", err
+	}
+	return uri, url, nil
+}
+
+// isRetryable rejects errors S3 attributes to the request itself (bad
+// bucket, access denied); everything else is worth another try.
+func isRetryable(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorFault() != smithy.FaultClient
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBucket fails the first `failures` puts with err, then stores objects.
+type fakeBucket struct {
+	failures int
+	err      error
+	calls    int
+	buckets  []string
+	keys     []string
+	objects  map[string][]byte
+}
+
+func (f *fakeBucket) PutObject(ctx context.Context, in *awss3.PutObjectInput, _ ...func(*awss3.Options)) (*awss3.PutObjectOutput, error) {
+	f.calls++
+	f.buckets = append(f.buckets, *in.Bucket)
+	f.keys = append(f.keys, *in.Key)
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	body, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	if f.objects == nil {
+		f.objects = map[string][]byte{}
+	}
+	f.objects[*in.Key] = body
+	return &awss3.PutObjectOutput{}, nil
+}
+
+func newTestUploader(bucket *fakeBucket) *ChartUploader {
+	u := NewChartUploader(bucket, "charts-bucket", "charts", nil)
+	u.BaseDelay = time.Millisecond
+	return u
+}
+
+func TestUploadImageToS3_TransientFailureRetriesThenSucceeds(t *testing.T) {
+	bucket := &fakeBucket{failures: 2, err: errors.New("connection reset by peer")}
+	u := newTestUploader(bucket)
+	key := u.ChartKey("ETHUSDT", "15m", time.Unix(1700000100, 0), "candle.png")
+
+	uri, err := u.UploadImageToS3(context.Background(), key, []byte("png-bytes"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "s3://charts-bucket/charts/ETHUSDT/15m/1700000100-candle.png", uri)
+	assert.Equal(t, 3, bucket.calls)
+	assert.Equal(t, []string{key, key, key}, bucket.keys, "every retry targets the same object")
+	assert.Equal(t, map[string][]byte{key: []byte("png-bytes")}, bucket.objects)
+}
+
+func TestUploadImageToS3_GivesUpAfterMaxAttempts(t *testing.T) {
+	bucket := &fakeBucket{failures: 10, err: errors.New("i/o timeout")}
+	u := newTestUploader(bucket)
+	u.MaxAttempts = 3
+
+	_, err := u.UploadImageToS3(context.Background(), "k", []byte("x"))
+
+	assert.ErrorContains(t, err, "giving up after 3 attempts")
+	assert.Equal(t, 3, bucket.calls)
+}
+
+func TestUploadImageToS3_ClientFaultIsNotRetried(t *testing.T) {
+	denied := &smithy.GenericAPIError{Code: "AccessDenied", Fault: smithy.FaultClient}
+	bucket := &fakeBucket{failures: 10, err: denied}
+
+	_, err := newTestUploader(bucket).UploadImageToS3(context.Background(), "k", []byte("x"))
+
+	assert.ErrorIs(t, err, denied)
+	assert.Equal(t, 1, bucket.calls)
+}
+
+func TestChartKey_DeterministicPerCandle(t *testing.T) {
+	u := newTestUploader(&fakeBucket{})
+	ts := time.Unix(1700000100, 0)
+
+	assert.Equal(t, u.ChartKey("ETHUSDT", "15m", ts, "candle.png"), u.ChartKey("ETHUSDT", "15m", ts, "candle.png"))
+	assert.NotEqual(t, u.ChartKey("ETHUSDT", "15m", ts, "candle.png"), u.ChartKey("ETHUSDT", "15m", ts.Add(15*time.Minute), "candle.png"))
+}
+
+// fakePresigner signs nothing; it echoes the request into a fake URL.
+type fakePresigner struct {
+	err     error
+	expires time.Duration
+}
+
+func (f *fakePresigner) PresignGetObject(_ context.Context, in *awss3.GetObjectInput, optFns ...func(*awss3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var opts awss3.PresignOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+	f.expires = opts.Expires
+	return &v4.PresignedHTTPRequest{
+		URL:    "https://" + *in.Bucket + ".s3.amazonaws.com/" + *in.Key + "?X-Amz-Signature=sig",
+		Method: "GET",
+	}, nil
+}
+
+func TestGetPresignedURL(t *testing.T) {
+	u := newTestUploader(&fakeBucket{})
+	presigner := &fakePresigner{}
+	u.Presigner = presigner
+
+	url, err := u.GetPresignedURL(context.Background(), "charts/ETHUSDT/15m/1700000100-candle.png", 15*time.Minute)
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://charts-bucket.s3.amazonaws.com/charts/ETHUSDT/15m/1700000100-candle.png?X-Amz-Signature=sig", url)
+	assert.Equal(t, 15*time.Minute, presigner.expires)
+
+	_, err = u.GetPresignedURL(context.Background(), "k", 30*24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, maxPresignTTL, presigner.expires, "capped at the SigV4 limit")
+}
+
+func TestUploadImageWithURL(t *testing.T) {
+	bucket := &fakeBucket{}
+	u := newTestUploader(bucket)
+	u.Presigner = &fakePresigner{}
+
+	uri, url, err := u.UploadImageWithURL(context.Background(), "charts/k.png", []byte("png"), 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, "s3://charts-bucket/charts/k.png", uri)
+	assert.Contains(t, url, "charts/k.png?X-Amz-Signature=")
+	assert.Equal(t, []byte("png"), bucket.objects["charts/k.png"])
+
+	u.Presigner = &fakePresigner{err: errors.New("no credentials")}
+	uri, url, err = u.UploadImageWithURL(context.Background(), "charts/k.png", []byte("png"), 0)
+	assert.Error(t, err)
+	assert.Equal(t, "s3://charts-bucket/charts/k.png", uri, "object is stored even when presigning fails")
+	assert.Empty(t, url)
+}
+
+func TestUploadImageToS3_UsesConfiguredBucket(t *testing.T) {
+	bucket := &fakeBucket{}
+	u := NewChartUploader(bucket, "dev-charts", "charts", nil)
+
+	uri, err := u.UploadImageToS3(context.Background(), "charts/k.png", []byte("png"))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev-charts"}, bucket.buckets)
+	assert.Equal(t, "s3://dev-charts/charts/k.png", uri)
+}
+
+func TestUploadImageToS3_EmptyBucketFailsBeforeRequest(t *testing.T) {
+	bucket := &fakeBucket{}
+	u := NewChartUploader(bucket, "", "charts", nil)
+
+	_, err := u.UploadImageToS3(context.Background(), "k", []byte("x"))
+
+	assert.ErrorIs(t, err, ErrNoBucket)
+	assert.Zero(t, bucket.calls)
+
+	_, err = NewS3ChartUploader(context.Background(), "", "charts", nil)
+	assert.ErrorIs(t, err, ErrNoBucket)
+}
@@ -0,0 +1,287 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"time-series-rag-agent/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	defaultMaxReceives = 5
+	defaultWaitSeconds = 20 // SQS long-poll maximum
+	defaultBatchSize   = 10 // SQS per-receive and per-delete-batch maximum
+	defaultWorkers     = 4
+	receiveErrorDelay  = 5 * time.Second
+	maxErrorAttribute  = 256 // DLQ attribute values are truncated to this
+)
+
+// API is the part of *sqs.Client the consumer uses, so tests can stand in a
+// fake queue.
+type API interface {
+	ReceiveMessage(ctx context.Context, in *awssqs.ReceiveMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.ReceiveMessageOutput, error)
+	DeleteMessageBatch(ctx context.Context, in *awssqs.DeleteMessageBatchInput, optFns ...func(*awssqs.Options)) (*awssqs.DeleteMessageBatchOutput, error)
+	SendMessage(ctx context.Context, in *awssqs.SendMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.SendMessageOutput, error)
+}
+
+// TradingLogIngester stores one trading-log message body.
+type TradingLogIngester interface {
+	IngestTradingLog(ctx context.Context, body []byte) error
+}
+
+// Consumer reads trading logs off QueueURL. A message is deleted only after
+// it is ingested; a failure leaves it for the visibility timeout to
+// re-deliver, and once it has been received MaxReceives times it is copied to
+// DLQURL and deleted, so a poison message cannot stall the queue.
+type Consumer struct {
+	Client      API
+	QueueURL    string
+	DLQURL      string // dead-letter target; empty = leave failures to the queue's redrive policy
+	MaxReceives int    // deliveries before dead-lettering; <= 0 = defaultMaxReceives
+	Ingest      TradingLogIngester
+	Logger      *slog.Logger
+
+	// Workers bounds how many message groups are ingested at once; <= 0 =
+	// defaultWorkers.
+	Workers int
+
+	// receives counts deliveries per MessageId, for fakes and queues that do
+	// not report ApproximateReceiveCount.
+	mu       sync.Mutex
+	receives map[string]int
+}
+
+// NewConsumer wraps client with the default dead-letter threshold.
+func NewConsumer(client API, queueURL, dlqURL string, ingest TradingLogIngester, logger *slog.Logger) *Consumer {
+	return &Consumer{
+		Client:      client,
+		QueueURL:    queueURL,
+		DLQURL:      dlqURL,
+		MaxReceives: defaultMaxReceives,
+		Workers:     defaultWorkers,
+		Ingest:      ingest,
+		Logger:      logger,
+	}
+}
+
+// NewSQSConsumer builds the consumer on a real SQS client from the default
+// AWS credential chain, with queue, dead-letter queue and region taken from
+// cfg.Que.
+func NewSQSConsumer(ctx context.Context, cfg *config.AppConfig, ingest TradingLogIngester, logger *slog.Logger) (*Consumer, error) {
+	client, err := NewSQSClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newConsumerFromConfig(client, cfg.Que, ingest, logger)
+}
+
+func newConsumerFromConfig(client API, q config.QueConfig, ingest TradingLogIngester, logger *slog.Logger) (*Consumer, error) {
+	if q.QueUrl == "" {
+		return nil, errors.New("SQS_URL is not set")
+	}
+	c := NewConsumer(client, q.QueUrl, q.DLQUrl, ingest, logger)
+	if q.MaxReceives > 0 {
+		c.MaxReceives = q.MaxReceives
+	}
+	return c, nil
+}
+
+// ConsumeTradingLogs polls until ctx is cancelled. Receive errors are logged
+// and retried after a pause; no single message or ingest failure ends it.
+func (c *Consumer) ConsumeTradingLogs(ctx context.Context) error {
+	for ctx.Err() == nil {
+		if err := c.poll(ctx); err != nil && ctx.Err() == nil {
+			c.Logger.Error("[SQS] receive failed", "queue", c.QueueURL, "err", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(receiveErrorDelay):
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// poll receives one batch and ingests it on up to Workers goroutines, one per
+// message group at a time. Within a group messages run in receive order and a
+// failure holds back the rest of the group, so FIFO order survives the
+// redelivery. Messages without a group are independent. Everything ingested
+// or dead-lettered is removed with one DeleteMessageBatch.
+func (c *Consumer) poll(ctx context.Context) error {
+	out, err := c.Client.ReceiveMessage(ctx, &awssqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(c.QueueURL),
+		MaxNumberOfMessages: defaultBatchSize,
+		WaitTimeSeconds:     defaultWaitSeconds,
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+			types.MessageSystemAttributeNameMessageGroupId,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	workers := c.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	var (
+		mu   sync.Mutex
+		done []types.Message
+		sem  = make(chan struct{}, workers)
+		wg   sync.WaitGroup
+	)
+	for _, group := range groupMessages(out.Messages) {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			for _, msg := range group {
+				if !c.process(ctx, msg) {
+					return
+				}
+				mu.Lock()
+				done = append(done, msg)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	c.deleteBatch(ctx, done)
+	return nil
+}
+
+// groupMessages splits a batch by MessageGroupId, keeping receive order
+// within each group. Ungrouped messages each get their own group.
+func groupMessages(msgs []types.Message) [][]types.Message {
+	var groups [][]types.Message
+	index := map[string]int{}
+	for _, msg := range msgs {
+		id, ok := msg.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]
+		if !ok || id == "" {
+			groups = append(groups, []types.Message{msg})
+			continue
+		}
+		i, seen := index[id]
+		if !seen {
+			i = len(groups)
+			index[id] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], msg)
+	}
+	return groups
+}
+
+// process ingests msg, dead-lettering it once it has failed MaxReceives
+// times. Reports whether it should be deleted from the queue.
+func (c *Consumer) process(ctx context.Context, msg types.Message) bool {
+	id := aws.ToString(msg.MessageId)
+	err := c.Ingest.IngestTradingLog(ctx, []byte(aws.ToString(msg.Body)))
+	if err == nil {
+		c.forget(id)
+		return true
+	}
+
+	receives := c.receiveCount(msg)
+	maxReceives := c.MaxReceives
+	if maxReceives <= 0 {
+		maxReceives = defaultMaxReceives
+	}
+	if receives < maxReceives || c.DLQURL == "" {
+		c.Logger.Warn("[SQS] ingest failed, leaving for redelivery", "message_id", id, "receives", receives, "err", err)
+		return false
+	}
+
+	if err := c.deadLetter(ctx, msg, receives, err); err != nil {
+		c.Logger.Error("[SQS] dead-letter failed, leaving for redelivery", "message_id", id, "err", err)
+		return false
+	}
+	c.Logger.Error("[SQS] moved message to dead-letter queue", "message_id", id, "receives", receives)
+	c.forget(id)
+	return true
+}
+
+// receiveCount is SQS's ApproximateReceiveCount, or the local count when the
+// attribute is missing or lower.
+func (c *Consumer) receiveCount(msg types.Message) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.receives == nil {
+		c.receives = map[string]int{}
+	}
+	id := aws.ToString(msg.MessageId)
+	c.receives[id]++
+	n := c.receives[id]
+	if v, err := strconv.Atoi(msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)]); err == nil && v > n {
+		n = v
+	}
+	return n
+}
+
+func (c *Consumer) forget(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.receives, id)
+}
+
+// deadLetter copies msg to the DLQ. A FIFO DLQ (".fifo") keeps the source
+// message's group, or its id when it has none, and deduplicates on the
+// source message id so a retried move is not stored twice.
+func (c *Consumer) deadLetter(ctx context.Context, msg types.Message, receives int, cause error) error {
+	reason := cause.Error()
+	if len(reason) > maxErrorAttribute {
+		reason = reason[:maxErrorAttribute]
+	}
+	in := &awssqs.SendMessageInput{
+		QueueUrl:    aws.String(c.DLQURL),
+		MessageBody: msg.Body,
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"SourceMessageId": {DataType: aws.String("String"), StringValue: msg.MessageId},
+			"ReceiveCount":    {DataType: aws.String("Number"), StringValue: aws.String(strconv.Itoa(receives))},
+			"Error":           {DataType: aws.String("String"), StringValue: aws.String(reason)},
+		},
+	}
+	if strings.HasSuffix(c.DLQURL, ".fifo") {
+		group, ok := msg.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]
+		if !ok {
+			group = aws.ToString(msg.MessageId)
+		}
+		in.MessageGroupId = aws.String(group)
+		in.MessageDeduplicationId = msg.MessageId
+	}
+	_, err := c.Client.SendMessage(ctx, in)
+	return err
+}
+
+// deleteBatch removes msgs (at most one receive batch) in one call. A failed
+// entry comes back after the visibility timeout; ingest must tolerate the
+// duplicate.
+func (c *Consumer) deleteBatch(ctx context.Context, msgs []types.Message) {
+	if len(msgs) == 0 {
+		return
+	}
+	entries := make([]types.DeleteMessageBatchRequestEntry, len(msgs))
+	for i, msg := range msgs {
+		entries[i] = types.DeleteMessageBatchRequestEntry{Id: aws.String(strconv.Itoa(i)), ReceiptHandle: msg.ReceiptHandle}
+	}
+	out, err := c.Client.DeleteMessageBatch(ctx, &awssqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(c.QueueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		c.Logger.Warn("[SQS] delete batch failed", "messages", len(msgs), "err", err)
+		return
+	}
+	for _, f := range out.Failed {
+		c.Logger.Warn("[SQS] delete failed", "entry", aws.ToString(f.Id), "code", aws.ToString(f.Code), "err", aws.ToString(f.Message))
+	}
+}
@@ -0,0 +1,278 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time-series-rag-agent/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQueue redelivers every undeleted message, in id order, on each
+// receive, bumping its ApproximateReceiveCount like SQS does after the
+// visibility timeout.
+type fakeQueue struct {
+	messages map[string]string // id -> body, still on the queue
+	groups   map[string]string // id -> MessageGroupId; absent = ungrouped
+	receives map[string]int
+	deleted  []string
+	batches  int                         // DeleteMessageBatch calls
+	received *awssqs.ReceiveMessageInput // last receive request
+	polls    int
+	onPoll   func(n int) // runs before each receive
+
+	mu   sync.Mutex
+	sent []*awssqs.SendMessageInput
+}
+
+func newFakeQueue(bodies map[string]string) *fakeQueue {
+	return &fakeQueue{messages: bodies, receives: map[string]int{}}
+}
+
+func (q *fakeQueue) ReceiveMessage(ctx context.Context, in *awssqs.ReceiveMessageInput, _ ...func(*awssqs.Options)) (*awssqs.ReceiveMessageOutput, error) {
+	q.polls++
+	q.received = in
+	if q.onPoll != nil {
+		q.onPoll(q.polls)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	out := &awssqs.ReceiveMessageOutput{}
+	ids := make([]string, 0, len(q.messages))
+	for id := range q.messages {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids[:min(len(ids), int(in.MaxNumberOfMessages))] {
+		q.receives[id]++
+		attrs := map[string]string{"ApproximateReceiveCount": strconv.Itoa(q.receives[id])}
+		if g, ok := q.groups[id]; ok {
+			attrs["MessageGroupId"] = g
+		}
+		out.Messages = append(out.Messages, types.Message{
+			MessageId:     aws.String(id),
+			ReceiptHandle: aws.String("rh-" + id),
+			Body:          aws.String(q.messages[id]),
+			Attributes:    attrs,
+		})
+	}
+	return out, nil
+}
+
+func (q *fakeQueue) DeleteMessageBatch(_ context.Context, in *awssqs.DeleteMessageBatchInput, _ ...func(*awssqs.Options)) (*awssqs.DeleteMessageBatchOutput, error) {
+	q.batches++
+	for _, e := range in.Entries {
+		id := aws.ToString(e.ReceiptHandle)[len("rh-"):]
+		q.deleted = append(q.deleted, id)
+		delete(q.messages, id)
+	}
+	return &awssqs.DeleteMessageBatchOutput{}, nil
+}
+
+func (q *fakeQueue) SendMessage(_ context.Context, in *awssqs.SendMessageInput, _ ...func(*awssqs.Options)) (*awssqs.SendMessageOutput, error) {
+	fifo := strings.HasSuffix(aws.ToString(in.QueueUrl), ".fifo")
+	if fifo && (in.MessageGroupId == nil || in.MessageDeduplicationId == nil) {
+		return nil, errors.New("MissingParameter: FIFO queues need MessageGroupId and MessageDeduplicationId")
+	}
+	if !fifo && in.MessageGroupId != nil {
+		return nil, errors.New("InvalidParameterValue: MessageGroupId is only valid for FIFO queues")
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sent = append(q.sent, in)
+	return &awssqs.SendMessageOutput{}, nil
+}
+
+// failingIngest fails every body in bad and records the rest.
+type failingIngest struct {
+	bad map[string]bool
+
+	mu       sync.Mutex
+	ingested []string
+}
+
+func (f *failingIngest) IngestTradingLog(_ context.Context, body []byte) error {
+	if f.bad[string(body)] {
+		return errors.New("insert trading log: constraint violation")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ingested = append(f.ingested, string(body))
+	return nil
+}
+
+func newTestConsumer(q *fakeQueue, ingest TradingLogIngester, dlq string) *Consumer {
+	c := NewConsumer(q, "https://sqs/logs", dlq, ingest, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c.MaxReceives = 3
+	return c
+}
+
+func TestHandle_SuccessDeletes(t *testing.T) {
+	q := newFakeQueue(map[string]string{"m1": "ok"})
+	ingest := &failingIngest{}
+	c := newTestConsumer(q, ingest, "https://sqs/dlq")
+
+	require.NoError(t, c.poll(context.Background()))
+
+	assert.Equal(t, []string{"ok"}, ingest.ingested)
+	assert.Equal(t, []string{"m1"}, q.deleted)
+	assert.Empty(t, q.sent)
+}
+
+func TestHandle_FailureLeavesMessageForRedelivery(t *testing.T) {
+	q := newFakeQueue(map[string]string{"m1": "poison"})
+	c := newTestConsumer(q, &failingIngest{bad: map[string]bool{"poison": true}}, "https://sqs/dlq")
+
+	require.NoError(t, c.poll(context.Background()))
+	require.NoError(t, c.poll(context.Background()))
+
+	assert.Empty(t, q.deleted)
+	assert.Empty(t, q.sent)
+	assert.Contains(t, q.messages, "m1")
+}
+
+func TestConsumeTradingLogs_DeadLettersPoisonAndKeepsGoing(t *testing.T) {
+	q := newFakeQueue(map[string]string{"m1": "poison", "m2": "ok"})
+	ingest := &failingIngest{bad: map[string]bool{"poison": true}}
+	c := newTestConsumer(q, ingest, "https://sqs/dlq")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.onPoll = func(n int) {
+		if n == 5 {
+			cancel()
+		}
+	}
+
+	err := c.ConsumeTradingLogs(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"ok"}, ingest.ingested)
+	assert.ElementsMatch(t, []string{"m1", "m2"}, q.deleted)
+	require.Len(t, q.sent, 1, "poison message dead-lettered on its 3rd receive")
+	assert.Equal(t, "https://sqs/dlq", aws.ToString(q.sent[0].QueueUrl))
+	assert.Equal(t, "poison", aws.ToString(q.sent[0].MessageBody))
+	assert.Equal(t, "3", aws.ToString(q.sent[0].MessageAttributes["ReceiveCount"].StringValue))
+	assert.Equal(t, "m1", aws.ToString(q.sent[0].MessageAttributes["SourceMessageId"].StringValue))
+	assert.Empty(t, q.messages)
+}
+
+func TestHandle_FifoDLQKeepsGroupAndDedupesOnMessageId(t *testing.T) {
+	q := newFakeQueue(map[string]string{"m1": "poison"})
+	q.groups = map[string]string{"m1": "ETHUSDT"}
+	c := newTestConsumer(q, &failingIngest{bad: map[string]bool{"poison": true}}, "https://sqs/dlq.fifo")
+
+	for range 3 {
+		require.NoError(t, c.poll(context.Background()))
+	}
+
+	require.Len(t, q.sent, 1)
+	assert.Equal(t, "ETHUSDT", aws.ToString(q.sent[0].MessageGroupId))
+	assert.Equal(t, "m1", aws.ToString(q.sent[0].MessageDeduplicationId))
+	assert.Equal(t, []string{"m1"}, q.deleted, "removed from the source once dead-lettered")
+}
+
+func TestHandle_NoDLQKeepsRedelivering(t *testing.T) {
+	q := newFakeQueue(map[string]string{"m1": "poison"})
+	c := newTestConsumer(q, &failingIngest{bad: map[string]bool{"poison": true}}, "")
+
+	for range 5 {
+		require.NoError(t, c.poll(context.Background()))
+	}
+
+	assert.Empty(t, q.deleted)
+	assert.Empty(t, q.sent)
+}
+
+func TestNewConsumerFromConfig_UsesConfiguredQueues(t *testing.T) {
+	q := newFakeQueue(map[string]string{"m1": "poison"})
+	c, err := newConsumerFromConfig(q, config.QueConfig{
+		QueUrl:      "https://sqs.eu-west-1.amazonaws.com/123/fork-logs.fifo",
+		DLQUrl:      "https://sqs.eu-west-1.amazonaws.com/123/fork-logs-dlq.fifo",
+		MaxReceives: 1,
+	}, &failingIngest{bad: map[string]bool{"poison": true}}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	require.NoError(t, c.poll(context.Background()))
+
+	assert.Equal(t, "https://sqs.eu-west-1.amazonaws.com/123/fork-logs.fifo", aws.ToString(q.received.QueueUrl))
+	require.Len(t, q.sent, 1)
+	assert.Equal(t, "https://sqs.eu-west-1.amazonaws.com/123/fork-logs-dlq.fifo", aws.ToString(q.sent[0].QueueUrl))
+
+	_, err = newConsumerFromConfig(q, config.QueConfig{}, &failingIngest{}, nil)
+	assert.Error(t, err, "no queue URL")
+}
+
+func TestPoll_BatchOfTenIngestsConcurrentlyAndDeletesOnce(t *testing.T) {
+	bodies := map[string]string{}
+	for i := range 10 {
+		bodies[fmt.Sprintf("m%02d", i)] = fmt.Sprintf("log-%02d", i)
+	}
+	q := newFakeQueue(bodies)
+	ingest := &blockingIngest{release: make(chan struct{})}
+	c := newTestConsumer(q, ingest, "https://sqs/dlq")
+	c.Workers = 4
+
+	done := make(chan error)
+	go func() { done <- c.poll(context.Background()) }()
+	for ingest.running.Load() < 4 {
+		runtime.Gosched()
+	}
+	assert.EqualValues(t, 4, ingest.running.Load(), "bounded by Workers")
+	close(ingest.release)
+	require.NoError(t, <-done)
+
+	assert.EqualValues(t, 10, q.received.MaxNumberOfMessages)
+	assert.Equal(t, 1, q.batches, "one DeleteMessageBatch for the whole receive")
+	assert.Len(t, q.deleted, 10)
+	assert.EqualValues(t, 4, ingest.peak.Load())
+}
+
+func TestPoll_PreservesOrderWithinGroup(t *testing.T) {
+	q := newFakeQueue(map[string]string{"m1": "a1", "m2": "poison", "m3": "a3", "m4": "b1"})
+	q.groups = map[string]string{"m1": "A", "m2": "A", "m3": "A", "m4": "B"}
+	ingest := &failingIngest{bad: map[string]bool{"poison": true}}
+	c := newTestConsumer(q, ingest, "https://sqs/dlq")
+
+	require.NoError(t, c.poll(context.Background()))
+
+	assert.ElementsMatch(t, []string{"a1", "b1"}, ingest.ingested, "a3 waits behind the failed message")
+	assert.ElementsMatch(t, []string{"m1", "m4"}, q.deleted)
+	assert.Contains(t, q.messages, "m3")
+}
+
+// blockingIngest holds every call until release is closed and tracks how
+// many run at once.
+type blockingIngest struct {
+	release chan struct{}
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (b *blockingIngest) IngestTradingLog(ctx context.Context, _ []byte) error {
+	n := b.running.Add(1)
+	defer b.running.Add(-1)
+	for {
+		p := b.peak.Load()
+		if n <= p || b.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	<-b.release
+	return nil
+}
@@ -0,0 +1,53 @@
+package sqs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time-series-rag-agent/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// SendAPI is the part of *sqs.Client PutTradingLog uses.
+type SendAPI interface {
+	SendMessage(ctx context.Context, in *awssqs.SendMessageInput, optFns ...func(*awssqs.Options)) (*awssqs.SendMessageOutput, error)
+}
+
+// NewSQSClient builds an SQS client from the default AWS credential chain in
+// cfg.Que.Region (empty = the SDK default). A failure is returned, never
+// fatal: the trading log is not worth stopping the bot over.
+func NewSQSClient(ctx context.Context, cfg *config.AppConfig) (*awssqs.Client, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.Que.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Que.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load SDK config: %w", err)
+	}
+	return awssqs.NewFromConfig(awsCfg), nil
+}
+
+// PutTradingLog sends one trading-log body to queueURL. On a FIFO queue
+// (".fifo") groupID orders the messages and a hash of the body deduplicates
+// retries. Callers should log a failure and carry on trading.
+func PutTradingLog(ctx context.Context, client SendAPI, queueURL, groupID string, body []byte) error {
+	in := &awssqs.SendMessageInput{
+		QueueUrl:    aws.String(queueURL),
+		MessageBody: aws.String(string(body)),
+	}
+	if strings.HasSuffix(queueURL, ".fifo") {
+		sum := sha256.Sum256(body)
+		in.MessageGroupId = aws.String(groupID)
+		in.MessageDeduplicationId = aws.String(hex.EncodeToString(sum[:]))
+	}
+	if _, err := client.SendMessage(ctx, in); err != nil {
+		return fmt.Errorf("put trading log: %w", err)
+	}
+	return nil
+}
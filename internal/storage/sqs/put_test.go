@@ -0,0 +1,48 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awssqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSender struct {
+	err  error
+	sent []*awssqs.SendMessageInput
+}
+
+func (f *fakeSender) SendMessage(_ context.Context, in *awssqs.SendMessageInput, _ ...func(*awssqs.Options)) (*awssqs.SendMessageOutput, error) {
+	f.sent = append(f.sent, in)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &awssqs.SendMessageOutput{}, nil
+}
+
+func TestPutTradingLog_SendFailureReturnsError(t *testing.T) {
+	sender := &fakeSender{err: errors.New("AccessDenied")}
+
+	err := PutTradingLog(context.Background(), sender, "https://sqs/logs.fifo", "ETHUSDT", []byte(`{"pnl":1}`))
+
+	// Reaching this line at all means the send failure did not exit the process.
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AccessDenied")
+}
+
+func TestPutTradingLog_FifoSetsGroupAndDedup(t *testing.T) {
+	sender := &fakeSender{}
+
+	require.NoError(t, PutTradingLog(context.Background(), sender, "https://sqs/logs.fifo", "ETHUSDT", []byte(`{"pnl":1}`)))
+	require.NoError(t, PutTradingLog(context.Background(), sender, "https://sqs/logs", "ETHUSDT", []byte(`{"pnl":1}`)))
+
+	require.Len(t, sender.sent, 2)
+	assert.Equal(t, "https://sqs/logs.fifo", aws.ToString(sender.sent[0].QueueUrl))
+	assert.Equal(t, "ETHUSDT", aws.ToString(sender.sent[0].MessageGroupId))
+	assert.Len(t, aws.ToString(sender.sent[0].MessageDeduplicationId), 64)
+	assert.Nil(t, sender.sent[1].MessageGroupId, "standard queues reject a group id")
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+
+	"time-series-rag-agent/internal/embedding"
+)
+
+// PatternStore is the pattern persistence + similarity search contract.
+// postgresql.PatternStore (pgvector) and memory.Store (brute force) implement it.
+type PatternStore interface {
+	UpsertFeature(ctx context.Context, f embedding.PatternFeature) error
+	BulkUpsertFeature(ctx context.Context, features []embedding.PatternFeature) error
+	UpsertLabels(ctx context.Context, symbol, interval string, labels []embedding.LabelUpdate) error
+	QueryTopN(ctx context.Context, symbol, interval string, queryEmbedding []float64, topN int) ([]embedding.PatternLabel, error)
+	QueryTopNWithin(ctx context.Context, symbol, interval string, queryEmbedding []float64, topN int, maxDistance float64) ([]embedding.PatternLabel, error)
+}
+
+// WithinDistance keeps the leading rows whose cosine distance is at most
+// maxDistance. rows must be nearest first, as QueryTopN returns them;
+// maxDistance <= 0 keeps every row.
+func WithinDistance(rows []embedding.PatternLabel, maxDistance float64) []embedding.PatternLabel {
+	if maxDistance <= 0 {
+		return rows
+	}
+	for i, r := range rows {
+		if r.Distance > maxDistance {
+			return rows[:i]
+		}
+	}
+	return rows
+}
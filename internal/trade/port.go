@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"time"
 
+	"time-series-rag-agent/internal/exchange"
+
 	"github.com/adshao/go-binance/v2/futures"
 )
 
@@ -16,26 +18,10 @@ func CalculateRealizedDailyPnL(client *futures.Client) float64 {
 	// Calculate at 00:00:00 UTC, 7:00:00 Thailand
 	now := time.Now().UTC()
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-	startTime := startOfDay.UnixMilli()
-	incomes, err := client.NewGetIncomeHistoryService().
-		StartTime(startTime).
-		Limit(1000).
-		Do(context.Background())
+	totalPnL, err := exchange.RealizedPnLSince(context.Background(), client, startOfDay)
 	if err != nil {
 		fmt.Print(err)
 	}
-
-	var totalPnL float64
-	for _, income := range incomes {
-		// Convert string amount to float
-		var amt float64
-		fmt.Sscanf(income.Income, "%f", &amt)
-
-		switch income.IncomeType {
-		case "REALIZED_PNL", "FUNDING_FEE", "COMMISSION":
-			totalPnL += amt
-		}
-	}
 	return totalPnL
 }
 
@@ -63,7 +49,7 @@ func CalculateRealizedPnLHistory(client *futures.Client, lookbackDays int) ([]Tr
 	for _, income := range incomes {
 		switch income.IncomeType {
 		case "REALIZED_PNL", "FUNDING_FEE", "COMMISSION":
-			amt, err := strconv.ParseFloat(income.Income, 64)
+			amt, err := exchange.ParseNumber("income", income.Income)
 			if err != nil {
 				continue
 			}
@@ -122,7 +108,9 @@ func GetPositionHistory(client *futures.Client, symbol string, lookbackDays int)
 	}
 	for _, r := range riskList {
 		if r.Symbol == symbol {
-			leverage, _ = strconv.ParseFloat(r.Leverage, 64)
+			if leverage, err = exchange.ParseNumber("leverage", r.Leverage); err != nil {
+				return nil, fmt.Errorf("position risk: %w", err)
+			}
 			break
 		}
 	}
@@ -162,10 +150,22 @@ func GetPositionHistory(client *futures.Client, symbol string, lookbackDays int)
 	var result []PositionHistory
 
 	for _, t := range filtered {
-		qty, _ := strconv.ParseFloat(t.Quantity, 64)
-		price, _ := strconv.ParseFloat(t.Price, 64)
-		pnl, _ := strconv.ParseFloat(t.RealizedPnl, 64)
-		commission, _ := strconv.ParseFloat(t.Commission, 64)
+		qty, err := exchange.ParseNumber("qty", t.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("trade %d: %w", t.ID, err)
+		}
+		price, err := exchange.ParseNumber("price", t.Price)
+		if err != nil {
+			return nil, fmt.Errorf("trade %d: %w", t.ID, err)
+		}
+		pnl, err := exchange.ParseNumber("realizedPnl", t.RealizedPnl)
+		if err != nil {
+			return nil, fmt.Errorf("trade %d: %w", t.ID, err)
+		}
+		commission, err := exchange.ParseNumber("commission", t.Commission)
+		if err != nil {
+			return nil, fmt.Errorf("trade %d: %w", t.ID, err)
+		}
 		key := posKey{PositionSide: string(t.PositionSide)}
 
 		if _, ok := states[key]; !ok {
@@ -273,7 +273,10 @@ func CalculateDailyROI(client *futures.Client) (float64, float64, error) {
 		return 0, 0, err
 	}
 
-	currentBalance, _ := strconv.ParseFloat(acc.TotalWalletBalance, 64)
+	currentBalance, err := exchange.ParseNumber("totalWalletBalance", acc.TotalWalletBalance)
+	if err != nil {
+		return 0, 0, err
+	}
 
 	// 3. Get Today's PnL (from your existing logic)
 	dailyPnL := CalculateRealizedDailyPnL(client)
@@ -1,20 +1,29 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
 
 // Return slog.Logger object
+// LOG_FORMAT=text switches to the dev-friendly table output; default is JSON.
 func SetupLogger() *slog.Logger {
-	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	})
-
-	logger := slog.New(handler)
+	logger := New(os.Stdout, os.Getenv("LOG_FORMAT"))
 
 	slog.SetDefault(logger)
 
 	// No need & cuz logger is slog.New which returned *slog.Logger
 	return logger
 }
+
+// New builds a debug-level logger writing JSON, or aligned text when format is "text".
+func New(w io.Writer, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}
+	if format == "text" {
+		return slog.New(NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew_TextFormatIsReadable(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, "text")
+
+	log.Info(DecisionMsg, "symbol", "ETHUSDT", "signal", "LONG", "confidence", 72, "tier", 1)
+	log.Info(DecisionMsg, "symbol", "BTCUSDT", "signal", "HOLD", "confidence", 5, "tier", 0)
+	log.Info("[LivePipeline] Ingested feature", "count", 3)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	for _, l := range lines {
+		assert.False(t, json.Valid([]byte(l)), "line should not be JSON: %s", l)
+	}
+
+	assert.Contains(t, lines[0], "DECISION ETHUSDT    LONG    72  tier 1")
+	assert.Contains(t, lines[1], "DECISION BTCUSDT    HOLD     5  tier 0")
+	assert.Equal(t, len(lines[0]), len(lines[1]), "decision rows are column-aligned")
+	assert.Contains(t, lines[2], "INFO  [LivePipeline] Ingested feature  count=3")
+}
+
+func TestNew_DefaultIsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, "").Info(DecisionMsg, "symbol", "ETHUSDT")
+
+	assert.True(t, json.Valid(bytes.TrimSpace(buf.Bytes())))
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// DecisionMsg marks a trade decision record. The text handler renders it as
+// one aligned row: time, symbol, signal, confidence, tier.
+const DecisionMsg = "decision"
+
+// TextHandler is a compact, human-readable slog.Handler for local runs.
+type TextHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+func NewTextHandler(w io.Writer, opts *slog.HandlerOptions) *TextHandler {
+	var level slog.Leveler = slog.LevelInfo
+	if opts != nil && opts.Level != nil {
+		level = opts.Level
+	}
+	return &TextHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *TextHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+func (h *TextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &c
+}
+
+// WithGroup is flattened: group names are dropped, attributes kept.
+func (h *TextHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func (h *TextHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := append([]slog.Attr{}, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	var line string
+	if r.Message == DecisionMsg {
+		line = formatDecision(r, attrs)
+	} else {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s %-5s %s", r.Time.Format("15:04:05"), r.Level, r.Message)
+		for _, a := range attrs {
+			fmt.Fprintf(&sb, "  %s=%s", a.Key, a.Value.Resolve())
+		}
+		line = sb.String()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line+"\n")
+	return err
+}
+
+func formatDecision(r slog.Record, attrs []slog.Attr) string {
+	fields := map[string]string{"symbol": "-", "signal": "-", "confidence": "-", "tier": "-"}
+	for _, a := range attrs {
+		if _, ok := fields[a.Key]; ok {
+			fields[a.Key] = a.Value.Resolve().String()
+		}
+	}
+	return fmt.Sprintf("%s %-8s %-10s %-5s %4s  tier %s",
+		r.Time.Format("15:04:05"), "DECISION",
+		fields["symbol"], fields["signal"], fields["confidence"], fields["tier"])
+}
@@ -10,32 +10,107 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 )
 
 type DiscordClient struct {
 	OrderWebhookURL    string
 	PipelineWebhookURL string
+	AlertWebhookURL    string
 	Client             *http.Client
+
+	// Prefix is prepended to every message, e.g. "[dev] ", so a non-prod run
+	// is recognisable even when it shares a channel.
+	Prefix string
+
+	// CoalesceWindow drops a message identical to the last one sent to the same
+	// webhook within this window. 0 disables coalescing. NotifyError ignores it.
+	CoalesceWindow time.Duration
+
+	// MaxAttempts is tries per message on 429, 5xx or a transport error;
+	// <= 1 = no retry. A 429 waits Retry-After (capped at MaxRetryWait),
+	// anything else RetryBaseDelay doubled per attempt.
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	MaxRetryWait   time.Duration
+
+	mu       sync.Mutex
+	lastSent map[string]sentMessage
+}
+
+type sentMessage struct {
+	content string
+	at      time.Time
 }
 
 // NewDiscordClient sets up the webhook sender
-func NewDiscordClient(orderURL, pipelineURL string) *DiscordClient {
+func NewDiscordClient(orderURL, pipelineURL, alertURL string) *DiscordClient {
 	return &DiscordClient{
 		OrderWebhookURL:    orderURL,
 		PipelineWebhookURL: pipelineURL,
+		AlertWebhookURL:    alertURL,
 		Client:             &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts:        defaultDiscordAttempts,
+		RetryBaseDelay:     defaultDiscordRetryDelay,
+		MaxRetryWait:       defaultDiscordMaxRetryWait,
+		lastSent:           make(map[string]sentMessage),
+	}
+}
+
+const (
+	defaultDiscordAttempts     = 3
+	defaultDiscordRetryDelay   = 500 * time.Millisecond
+	defaultDiscordMaxRetryWait = 5 * time.Second // sends are synchronous; never stall a bar for long
+)
+
+// ProdEnv is the environment tag that posts to the production webhooks.
+const ProdEnv = "prod"
+
+// DiscordWebhooks is one environment's pair of channels.
+type DiscordWebhooks struct {
+	Notify string // orders + pipeline
+	Alert  string // critical errors
+}
+
+// NewDiscordClientForEnv routes env ProdEnv (or empty) to prod and every other
+// env to dev, tagging messages with the env. A dev run with no dev webhooks
+// configured sends nothing rather than falling back to prod. coalesce sets
+// CoalesceWindow.
+func NewDiscordClientForEnv(env string, prod, dev DiscordWebhooks, coalesce time.Duration) *DiscordClient {
+	var d *DiscordClient
+	if env == "" || env == ProdEnv {
+		d = NewDiscordClient(prod.Notify, prod.Notify, prod.Alert)
+	} else {
+		d = NewDiscordClient(dev.Notify, dev.Notify, dev.Alert)
+		d.Prefix = EnvPrefix(env)
 	}
+	d.CoalesceWindow = coalesce
+	return d
 }
 
 // NotifyOrder sends to the Order Room
 func (d *DiscordClient) NotifyOrder(msg string, imagePath string) {
-	d.send(d.OrderWebhookURL, "**TRADE ALERT**\n"+msg, imagePath)
+	d.send(d.OrderWebhookURL, d.Prefix+"**TRADE ALERT**\n"+msg, imagePath)
 }
 
 // NotifyPipeline sends to the Pipeline Room
 func (d *DiscordClient) NotifyPipeline(msg string, imagePath string) {
-	d.send(d.PipelineWebhookURL, msg, imagePath)
+	d.send(d.PipelineWebhookURL, d.Prefix+msg, imagePath)
+}
+
+// NotifyError sends a critical operational error to the Alert Room (falling
+// back to the Pipeline Room). It always delivers: coalescing is bypassed.
+func (d *DiscordClient) NotifyError(err error, context string) {
+	url := d.AlertWebhookURL
+	if url == "" {
+		url = d.PipelineWebhookURL
+	}
+	if url == "" {
+		return
+	}
+	d.sendSimpleText(url, d.Prefix+fmt.Sprintf("🚨 **CRITICAL ERROR** 🚨\n**Context:** %s\n```%v```", context, err))
 }
 
 // send handles the Logic: Text Only vs Text + Image
@@ -43,6 +118,9 @@ func (d *DiscordClient) send(webhookURL, content string, imagePath string) {
 	if webhookURL == "" {
 		return
 	}
+	if d.shouldCoalesce(webhookURL, content) {
+		return
+	}
 
 	// 1. If NO Image, send simple JSON
 	if imagePath == "" {
@@ -58,17 +136,33 @@ func (d *DiscordClient) send(webhookURL, content string, imagePath string) {
 	}
 }
 
+// shouldCoalesce reports whether content repeats the last message sent to
+// webhookURL within CoalesceWindow, and records it otherwise.
+func (d *DiscordClient) shouldCoalesce(webhookURL, content string) bool {
+	if d.CoalesceWindow <= 0 {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.lastSent == nil {
+		d.lastSent = make(map[string]sentMessage)
+	}
+	now := time.Now()
+	if last, ok := d.lastSent[webhookURL]; ok && last.content == content && now.Sub(last.at) < d.CoalesceWindow {
+		return true
+	}
+	d.lastSent[webhookURL] = sentMessage{content: content, at: now}
+	return false
+}
+
 // sendSimpleText sends a lightweight JSON payload
 func (d *DiscordClient) sendSimpleText(url, content string) {
 	payload := map[string]string{"content": content}
 	jsonBody, _ := json.Marshal(payload)
 
-	resp, err := d.Client.Post(url, "application/json", bytes.NewBuffer(jsonBody))
-	if err != nil {
+	if err := d.post(url, "application/json", jsonBody); err != nil {
 		log.Printf("⚠️ Webhook Error: %v", err)
-		return
 	}
-	defer resp.Body.Close()
 }
 
 // sendMultipart handles File Upload + Content
@@ -105,23 +199,79 @@ func (d *DiscordClient) sendMultipart(url, content, path string) error {
 	}
 
 	// C. Send Request
-	req, err := http.NewRequest("POST", url, body)
-	if err != nil {
-		return err
+	// CRITICAL: Set the Content-Type with the boundary
+	return d.post(url, writer.FormDataContentType(), body.Bytes())
+}
+
+// post sends body, retrying per MaxAttempts. The body is kept in memory so
+// every attempt resends it whole.
+func (d *DiscordClient) post(url, contentType string, body []byte) error {
+	attempts := max(d.MaxAttempts, 1)
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		wait, err := d.postOnce(url, contentType, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if wait < 0 || attempt == attempts-1 {
+			break
+		}
+		if wait == 0 {
+			wait = d.RetryBaseDelay << attempt
+		}
+		if d.MaxRetryWait > 0 && wait > d.MaxRetryWait {
+			wait = d.MaxRetryWait
+		}
+		time.Sleep(wait)
 	}
+	return lastErr
+}
 
-	// CRITICAL: Set the Content-Type with the boundary
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+// postOnce makes one request. wait is what to sleep before retrying: the
+// server's Retry-After on 429, 0 for the default backoff, -1 for a failure
+// that a retry won't fix.
+func (d *DiscordClient) postOnce(url, contentType string, body []byte) (wait time.Duration, err error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Content-Type", contentType)
 
 	resp, err := d.Client.Do(req)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return fmt.Errorf("bad status: %s", resp.Status)
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return retryAfter(resp), fmt.Errorf("rate limited: %s", resp.Status)
+	case resp.StatusCode >= 500:
+		return 0, fmt.Errorf("bad status: %s", resp.Status)
+	default:
+		return -1, fmt.Errorf("bad status: %s", resp.Status)
 	}
+}
 
-	return nil
+// retryAfter reads Discord's rate-limit delay in seconds, from the
+// Retry-After header or else the JSON body's retry_after; 0 when neither is
+// usable.
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+	if err != nil {
+		var body struct {
+			RetryAfter float64 `json:"retry_after"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&body) != nil {
+			return 0
+		}
+		secs = body.RetryAfter
+	}
+	if secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs * float64(time.Second))
 }
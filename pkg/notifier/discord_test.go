@@ -0,0 +1,203 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCountingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestNotifyPipeline_CoalescesDuplicates(t *testing.T) {
+	srv, hits := newCountingServer(t)
+	d := NewDiscordClient("", srv.URL, "")
+	d.CoalesceWindow = time.Minute
+
+	d.NotifyPipeline("same message", "")
+	d.NotifyPipeline("same message", "")
+
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestNotifyError_BypassesCoalescing(t *testing.T) {
+	srv, hits := newCountingServer(t)
+	d := NewDiscordClientForEnv(ProdEnv, DiscordWebhooks{Notify: srv.URL, Alert: srv.URL}, DiscordWebhooks{}, time.Minute)
+
+	d.NotifyPipeline("db down", "")
+	d.NotifyPipeline("db down", "") // suppressed
+	d.NotifyError(errors.New("db down"), "init")
+	d.NotifyError(errors.New("db down"), "init")
+
+	assert.Equal(t, int32(3), hits.Load())
+}
+
+func TestNotifyError_FallsBackToPipelineWebhook(t *testing.T) {
+	srv, hits := newCountingServer(t)
+	d := NewDiscordClient("", srv.URL, "")
+
+	d.NotifyError(errors.New("binance auth failed"), "startup")
+
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func newRecordingServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		bodies = append(bodies, p["content"])
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies
+}
+
+func TestNewDiscordClientForEnv_DevUsesDevWebhook(t *testing.T) {
+	prod, prodBodies := newRecordingServer(t)
+	dev, devBodies := newRecordingServer(t)
+	d := NewDiscordClientForEnv("dev",
+		DiscordWebhooks{Notify: prod.URL, Alert: prod.URL},
+		DiscordWebhooks{Notify: dev.URL, Alert: dev.URL}, 0,
+	)
+
+	d.NotifyPipeline("bar closed", "")
+	d.NotifyError(errors.New("boom"), "init")
+
+	assert.Empty(t, *prodBodies)
+	assert.Len(t, *devBodies, 2)
+	assert.Equal(t, "[dev] bar closed", (*devBodies)[0])
+	assert.True(t, strings.HasPrefix((*devBodies)[1], "[dev] "))
+}
+
+func TestNewDiscordClientForEnv_DevWithoutDevWebhookIsSilent(t *testing.T) {
+	prod, prodBodies := newRecordingServer(t)
+	d := NewDiscordClientForEnv("staging", DiscordWebhooks{Notify: prod.URL, Alert: prod.URL}, DiscordWebhooks{}, 0)
+
+	d.NotifyOrder("LONG ETHUSDT", "")
+	d.NotifyError(errors.New("boom"), "init")
+
+	assert.Empty(t, *prodBodies)
+}
+
+func TestNewDiscordClientForEnv_ProdUsesProdWebhookUnprefixed(t *testing.T) {
+	for _, env := range []string{ProdEnv, ""} {
+		prod, prodBodies := newRecordingServer(t)
+		dev, devBodies := newRecordingServer(t)
+		d := NewDiscordClientForEnv(env, DiscordWebhooks{Notify: prod.URL}, DiscordWebhooks{Notify: dev.URL}, 0)
+
+		d.NotifyPipeline("bar closed", "")
+
+		assert.Equal(t, []string{"bar closed"}, *prodBodies, env)
+		assert.Empty(t, *devBodies, env)
+	}
+}
+
+// newScriptedServer answers with the given responses in order, then 204s,
+// and records each request's content and arrival time.
+func newScriptedServer(t *testing.T, script ...func(w http.ResponseWriter)) (*httptest.Server, *[]string, *[]time.Time) {
+	t.Helper()
+	var bodies []string
+	var at []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		at = append(at, time.Now())
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			bodies = append(bodies, r.FormValue("content"))
+		} else {
+			var p map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			bodies = append(bodies, p["content"])
+		}
+		if n := len(at) - 1; n < len(script) {
+			script[n](w)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies, &at
+}
+
+func TestSend_RetriesAfter429HonoringRetryAfterHeader(t *testing.T) {
+	srv, bodies, at := newScriptedServer(t, func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "0.2")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	d := NewDiscordClient("", srv.URL, "")
+	d.RetryBaseDelay = time.Millisecond
+
+	d.NotifyPipeline("signal burst", "")
+
+	assert.Equal(t, []string{"signal burst", "signal burst"}, *bodies)
+	assert.GreaterOrEqual(t, (*at)[1].Sub((*at)[0]), 200*time.Millisecond)
+}
+
+func TestSend_RetriesAfter429WithJSONRetryAfter(t *testing.T) {
+	img := filepath.Join(t.TempDir(), "candle.png")
+	assert.NoError(t, os.WriteFile(img, []byte("png"), 0o644))
+	srv, bodies, _ := newScriptedServer(t, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"message":"You are being rate limited.","retry_after":0.01,"global":false}`))
+	})
+	d := NewDiscordClient(srv.URL, "", "")
+
+	d.NotifyOrder("LONG", img)
+
+	assert.Equal(t, []string{"**TRADE ALERT**\nLONG", "**TRADE ALERT**\nLONG"}, *bodies, "multipart retried, no text fallback")
+}
+
+func TestSend_ClientErrorIsNotRetried(t *testing.T) {
+	srv, bodies, _ := newScriptedServer(t, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	d := NewDiscordClient("", srv.URL, "")
+
+	d.NotifyPipeline("bad payload", "")
+
+	assert.Len(t, *bodies, 1)
+}
+
+func TestSend_GivesUpAfterMaxAttempts(t *testing.T) {
+	unavailable := func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) }
+	srv, bodies, _ := newScriptedServer(t, unavailable, unavailable, unavailable, unavailable)
+	d := NewDiscordClient("", srv.URL, "")
+	d.RetryBaseDelay = time.Millisecond
+
+	d.NotifyPipeline("outage", "")
+
+	assert.Len(t, *bodies, 3)
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := func(header, body string) *http.Response {
+		r := &http.Response{Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+		if header != "" {
+			r.Header.Set("Retry-After", header)
+		}
+		return r
+	}
+
+	assert.Equal(t, 2*time.Second, retryAfter(resp("2", "")))
+	assert.Equal(t, 1500*time.Millisecond, retryAfter(resp("", `{"retry_after":1.5}`)))
+	assert.Equal(t, time.Duration(0), retryAfter(resp("", "not json")))
+}
@@ -0,0 +1,45 @@
+package pkg
+
+// Notifier is an alert channel. imagePath is optional: "" sends text only,
+// otherwise the file is attached and the text becomes its caption.
+type Notifier interface {
+	NotifyOrder(msg string, imagePath string)
+	NotifyPipeline(msg string, imagePath string)
+	NotifyError(err error, context string)
+}
+
+var (
+	_ Notifier = (*DiscordClient)(nil)
+	_ Notifier = (*TelegramClient)(nil)
+	_ Notifier = MultiNotifier(nil)
+)
+
+// MultiNotifier fans every message out to each channel in order.
+type MultiNotifier []Notifier
+
+func (m MultiNotifier) NotifyOrder(msg string, imagePath string) {
+	for _, n := range m {
+		n.NotifyOrder(msg, imagePath)
+	}
+}
+
+func (m MultiNotifier) NotifyPipeline(msg string, imagePath string) {
+	for _, n := range m {
+		n.NotifyPipeline(msg, imagePath)
+	}
+}
+
+func (m MultiNotifier) NotifyError(err error, context string) {
+	for _, n := range m {
+		n.NotifyError(err, context)
+	}
+}
+
+// EnvPrefix tags messages from a non-prod env, e.g. "[dev] "; prod and empty
+// get no tag.
+func EnvPrefix(env string) string {
+	if env == "" || env == ProdEnv {
+		return ""
+	}
+	return "[" + env + "] "
+}
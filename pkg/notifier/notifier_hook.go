@@ -0,0 +1,42 @@
+package pkg
+
+import "fmt"
+
+// ChartFileName is the candle chart the live pipeline writes for symbol and
+// the order notification attaches. One file per symbol keeps pipelines running
+// side by side from attaching each other's chart.
+func ChartFileName(symbol string) string {
+	return "candle-" + symbol + ".png"
+}
+
+// NewPipelineHooks reports one symbol's pipeline events to n.
+func NewPipelineHooks(n Notifier, symbol, interval string) *PipelineHooks {
+	return &PipelineHooks{
+		OnOrderExecuted: func(sym, signal string, price float64, synthesis string, patternRead string, priceActionRead string) {
+			n.NotifyOrder(
+				fmt.Sprintf("%s `%s` @ `%.2f`\nInterval: %s", signal, sym, price, interval),
+				"",
+			)
+			n.NotifyOrder(
+				fmt.Sprintln("Synthesis", synthesis),
+				"",
+			)
+
+			n.NotifyOrder(
+				fmt.Sprintln("PriceActionRead: ", priceActionRead),
+				ChartFileName(sym),
+			)
+		},
+		OnPipelineError: func(phase string, err error) {
+			// init = DB / Binance unreachable: the bot cannot trade at all.
+			if phase == "init" {
+				n.NotifyError(err, fmt.Sprintf("%s %s pipeline init", symbol, interval))
+				return
+			}
+			n.NotifyPipeline(
+				fmt.Sprintf("[Pipeline Error] %s %s\nPhase: %s\n```%v```", symbol, interval, phase, err),
+				"",
+			)
+		},
+	}
+}
@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TelegramAPIURL is the Bot API root; tests point TelegramClient.BaseURL elsewhere.
+const TelegramAPIURL = "https://api.telegram.org"
+
+// telegramCaptionLimit is the Bot API cap on a photo caption.
+const telegramCaptionLimit = 1024
+
+// TelegramClient posts to one chat through a bot. Messages go out as plain
+// text, so Discord-style markdown shows literally but never fails to parse.
+type TelegramClient struct {
+	BaseURL string
+	Token   string
+	ChatID  string
+	Client  *http.Client
+
+	// Prefix is prepended to every message, e.g. "[dev] ".
+	Prefix string
+}
+
+// NewTelegramClient sets up the Bot API sender.
+func NewTelegramClient(token, chatID string) *TelegramClient {
+	return &TelegramClient{
+		BaseURL: TelegramAPIURL,
+		Token:   token,
+		ChatID:  chatID,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NotifyOrder sends a trade alert, with the chart when imagePath is set.
+func (t *TelegramClient) NotifyOrder(msg string, imagePath string) {
+	t.send(t.Prefix+"TRADE ALERT\n"+msg, imagePath)
+}
+
+// NotifyPipeline sends a pipeline status message.
+func (t *TelegramClient) NotifyPipeline(msg string, imagePath string) {
+	t.send(t.Prefix+msg, imagePath)
+}
+
+// NotifyError sends a critical operational error.
+func (t *TelegramClient) NotifyError(err error, context string) {
+	t.send(t.Prefix+fmt.Sprintf("🚨 CRITICAL ERROR 🚨\nContext: %s\n%v", context, err), "")
+}
+
+// send uses sendPhoto when there is an image and falls back to sendMessage.
+func (t *TelegramClient) send(text, imagePath string) {
+	if t.Token == "" || t.ChatID == "" {
+		return
+	}
+	if imagePath != "" && len(text) <= telegramCaptionLimit {
+		err := t.sendPhoto(text, imagePath)
+		if err == nil {
+			return
+		}
+		log.Printf("⚠️ Telegram Photo Failed (%s): %v. Fallback to text.", imagePath, err)
+	}
+	if err := t.sendMessage(text); err != nil {
+		log.Printf("⚠️ Telegram Error: %v", err)
+	}
+}
+
+func (t *TelegramClient) endpoint(method string) string {
+	return strings.TrimSuffix(t.BaseURL, "/") + "/bot" + t.Token + "/" + method
+}
+
+func (t *TelegramClient) sendMessage(text string) error {
+	body, _ := json.Marshal(map[string]string{"chat_id": t.ChatID, "text": text})
+	req, err := http.NewRequest(http.MethodPost, t.endpoint("sendMessage"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return t.do(req)
+}
+
+func (t *TelegramClient) sendPhoto(caption, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	_ = writer.WriteField("chat_id", t.ChatID)
+	_ = writer.WriteField("caption", caption)
+	part, err := writer.CreateFormFile("photo", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint("sendPhoto"), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return t.do(req)
+}
+
+// do sends req and checks both the status and the Bot API "ok" flag. The
+// token is part of the URL, so transport errors are reported without it.
+func (t *TelegramClient) do(req *http.Request) error {
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram request failed: %w", redactToken(err, t.Token))
+	}
+	defer resp.Body.Close()
+
+	var reply struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&reply)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || !reply.OK {
+		return fmt.Errorf("bad status: %s: %s", resp.Status, reply.Description)
+	}
+	return nil
+}
+
+func redactToken(err error, token string) error {
+	if token == "" {
+		return err
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), token, "<token>"))
+}
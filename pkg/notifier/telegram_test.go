@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type telegramCall struct {
+	Path        string
+	ChatID      string
+	Text        string // text for sendMessage, caption for sendPhoto
+	Photo       []byte
+	ContentType string
+}
+
+// newTelegramServer records every Bot API call and answers with ok, or with
+// failing for the listed methods.
+func newTelegramServer(t *testing.T, failing ...string) (*httptest.Server, *[]telegramCall) {
+	t.Helper()
+	var calls []telegramCall
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := telegramCall{Path: r.URL.Path, ContentType: r.Header.Get("Content-Type")}
+		if r.Header.Get("Content-Type") == "application/json" {
+			var p map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			c.ChatID, c.Text = p["chat_id"], p["text"]
+		} else {
+			_ = r.ParseMultipartForm(1 << 20)
+			c.ChatID, c.Text = r.FormValue("chat_id"), r.FormValue("caption")
+			if f, _, err := r.FormFile("photo"); err == nil {
+				c.Photo, _ = io.ReadAll(f)
+				f.Close()
+			}
+		}
+		calls = append(calls, c)
+		for _, m := range failing {
+			if filepath.Base(r.URL.Path) == m {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]any{"ok": false, "description": "Bad Request: wrong file"})
+				return
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestTelegram(url string) *TelegramClient {
+	tg := NewTelegramClient("123:abc", "-1001")
+	tg.BaseURL = url
+	return tg
+}
+
+func TestTelegram_NotifyPipeline_SendMessage(t *testing.T) {
+	srv, calls := newTelegramServer(t)
+	tg := newTestTelegram(srv.URL)
+	tg.Prefix = "[dev] "
+
+	tg.NotifyPipeline("bar done", "")
+
+	require.Len(t, *calls, 1)
+	c := (*calls)[0]
+	assert.Equal(t, "/bot123:abc/sendMessage", c.Path)
+	assert.Equal(t, "-1001", c.ChatID)
+	assert.Equal(t, "[dev] bar done", c.Text)
+}
+
+func TestTelegram_NotifyOrder_SendPhotoWithCaption(t *testing.T) {
+	srv, calls := newTelegramServer(t)
+	img := filepath.Join(t.TempDir(), "candle.png")
+	require.NoError(t, os.WriteFile(img, []byte("\x89PNG fake"), 0o644))
+
+	newTestTelegram(srv.URL).NotifyOrder("LONG ETHUSDT", img)
+
+	require.Len(t, *calls, 1)
+	c := (*calls)[0]
+	assert.Equal(t, "/bot123:abc/sendPhoto", c.Path)
+	assert.Contains(t, c.ContentType, "multipart/form-data")
+	assert.Equal(t, "-1001", c.ChatID)
+	assert.Equal(t, "TRADE ALERT\nLONG ETHUSDT", c.Text)
+	assert.Equal(t, []byte("\x89PNG fake"), c.Photo)
+}
+
+func TestTelegram_PhotoRejected_FallsBackToText(t *testing.T) {
+	srv, calls := newTelegramServer(t, "sendPhoto")
+	img := filepath.Join(t.TempDir(), "candle.png")
+	require.NoError(t, os.WriteFile(img, []byte("x"), 0o644))
+
+	newTestTelegram(srv.URL).NotifyPipeline("with chart", img)
+
+	require.Len(t, *calls, 2)
+	assert.Equal(t, "/bot123:abc/sendPhoto", (*calls)[0].Path)
+	assert.Equal(t, "/bot123:abc/sendMessage", (*calls)[1].Path)
+	assert.Equal(t, "with chart", (*calls)[1].Text)
+}
+
+func TestTelegram_NotConfigured_SendsNothing(t *testing.T) {
+	srv, calls := newTelegramServer(t)
+	tg := newTestTelegram(srv.URL)
+	tg.ChatID = ""
+
+	tg.NotifyError(errors.New("boom"), "startup")
+
+	assert.Empty(t, *calls)
+}
+
+func TestTelegram_Do_ReportsAPIErrorWithoutToken(t *testing.T) {
+	srv, _ := newTelegramServer(t, "sendMessage")
+	tg := newTestTelegram(srv.URL)
+
+	err := tg.sendMessage("hi")
+	assert.ErrorContains(t, err, "wrong file")
+
+	tg.BaseURL = "http://127.0.0.1:1"
+	err = tg.sendMessage("hi")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "123:abc")
+}
+
+func TestMultiNotifier_FansOut(t *testing.T) {
+	discordSrv, bodies := newRecordingServer(t)
+	tgSrv, calls := newTelegramServer(t)
+	n := MultiNotifier{NewDiscordClient("", discordSrv.URL, ""), newTestTelegram(tgSrv.URL)}
+
+	NewPipelineHooks(n, "ETHUSDT", "15m").OnPipelineError("llm", errors.New("timeout"))
+
+	assert.Len(t, *bodies, 1)
+	require.Len(t, *calls, 1)
+	assert.Contains(t, (*calls)[0].Text, "Phase: llm")
+}
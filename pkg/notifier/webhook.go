@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries "sha256=<hex HMAC-SHA256 of the body>" when a
+// webhook secret is configured, so receivers can verify the sender.
+const SignatureHeader = "X-Signature-256"
+
+// WebhookClient POSTs JSON events to a user-owned HTTP endpoint.
+type WebhookClient struct {
+	URL    string
+	Secret string // HMAC key; empty = unsigned
+	Client *http.Client
+}
+
+// NewWebhookClient sets up the generic webhook sender.
+func NewWebhookClient(url, secret string) *WebhookClient {
+	return &WebhookClient{
+		URL:    url,
+		Secret: secret,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// DecisionEvent is the body posted for every live decision.
+type DecisionEvent struct {
+	Time            time.Time `json:"time"`
+	Symbol          string    `json:"symbol"`
+	Interval        string    `json:"interval"`
+	Decision        string    `json:"decision"` // final action after the confidence floor
+	Price           float64   `json:"price"`
+	ConfidenceFloor int       `json:"confidence_floor"`
+	Signal          any       `json:"signal"` // the LLM's structured TradeSignal
+}
+
+// Sign returns the SignatureHeader value for body under secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Post sends event as JSON and fails on a transport error or non-2xx reply.
+func (w *WebhookClient) Post(ctx context.Context, event any) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("webhook marshal: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook post: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook status %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
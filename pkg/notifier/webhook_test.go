@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookPost_SignedJSONBody(t *testing.T) {
+	var body []byte
+	var sig, contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		sig = r.Header.Get(SignatureHeader)
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	event := DecisionEvent{
+		Time: time.Unix(1700000000, 0).UTC(), Symbol: "ETHUSDT", Interval: "15m",
+		Decision: "LONG", Price: 2000.5, ConfidenceFloor: 60,
+		Signal: map[string]any{"signal": "LONG", "confidence": 72},
+	}
+
+	err := NewWebhookClient(srv.URL, "s3cret").Post(context.Background(), event)
+
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", contentType)
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, "ETHUSDT", got["symbol"])
+	assert.Equal(t, "LONG", got["decision"])
+	assert.Equal(t, 2000.5, got["price"])
+	assert.Equal(t, "2023-11-14T22:13:20Z", got["time"])
+	assert.Equal(t, map[string]any{"signal": "LONG", "confidence": float64(72)}, got["signal"])
+	assert.Equal(t, Sign("s3cret", body), sig)
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+}
+
+func TestWebhookPost_UnsignedWithoutSecret(t *testing.T) {
+	var sig string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig = r.Header.Get(SignatureHeader)
+	}))
+	t.Cleanup(srv.Close)
+
+	assert.NoError(t, NewWebhookClient(srv.URL, "").Post(context.Background(), DecisionEvent{}))
+	assert.Empty(t, sig)
+}
+
+func TestWebhookPost_Non2xxIsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := NewWebhookClient(srv.URL, "").Post(context.Background(), DecisionEvent{})
+
+	assert.ErrorContains(t, err, "webhook status 502")
+}
+
+func TestSign_KnownVector(t *testing.T) {
+	// RFC 4231 test case 2
+	assert.Equal(t, "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		Sign("Jefe", []byte("what do ya want for nothing?")))
+}